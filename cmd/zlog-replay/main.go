@@ -0,0 +1,174 @@
+// Command zlog-replay replays archived NDJSON log files into the remote
+// outputs of a zlogconfig pipeline, keeping the entries' original
+// timestamps, e.g. to backfill Loki after an outage:
+//
+//	zlog-replay -config zlog.yaml -output loki -since 2024-05-01T10:00:00Z app.log app-2024-05-01.log.gz
+//
+// Files ending in .gz are decompressed. With no files it reads standard input.
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"github.com/hsdfat/go-zlog/zlogconfig"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "", "zlogconfig file describing the outputs (required)")
+		output     = flag.String("output", "", "only replay into the output with this name (default: all remote outputs)")
+		since      = flag.String("since", "", "skip entries before this RFC 3339 time")
+		until      = flag.String("until", "", "skip entries at or after this RFC 3339 time")
+		cfg        sink.ReplayConfig
+	)
+	flag.IntVar(&cfg.BatchSize, "batch", 100, "entries per batch")
+	flag.IntVar(&cfg.Rate, "rate", 0, "maximum entries per second (0 = unlimited)")
+	flag.Parse()
+
+	if err := run(*configPath, *output, *since, *until, cfg, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "zlog-replay:", err)
+		os.Exit(1)
+	}
+}
+
+// run builds the pipeline and replays the files into its sinks
+func run(configPath, output, since, until string, cfg sink.ReplayConfig, paths []string) error {
+	if configPath == "" {
+		return errors.New("-config is required")
+	}
+	var err error
+	if cfg.Since, err = parseTime(since); err != nil {
+		return err
+	}
+	if cfg.Until, err = parseTime(until); err != nil {
+		return err
+	}
+
+	c, err := zlogconfig.Load(configPath)
+	if err != nil {
+		return err
+	}
+	p, err := c.Build()
+	if err != nil {
+		return err
+	}
+	var targets teeSink
+	for _, s := range p.Sinks {
+		if output == "" || s.Name() == output {
+			targets = append(targets, s)
+		}
+	}
+	if len(targets) == 0 {
+		p.Close(context.Background())
+		if output == "" {
+			return errors.New("the configuration has no remote outputs")
+		}
+		return fmt.Errorf("no remote output named %q", output)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var total sink.ReplayStats
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	for _, path := range paths {
+		stats, err := replayFile(ctx, path, targets, cfg)
+		total.Lines += stats.Lines
+		total.Replayed += stats.Replayed
+		total.Skipped += stats.Skipped
+		total.Invalid += stats.Invalid
+		if err != nil {
+			err = fmt.Errorf("%s: %w", path, err)
+			return errors.Join(err, p.Close(context.Background()))
+		}
+	}
+
+	// Wait for the buffered entries to be sent
+	drainCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := p.Close(drainCtx); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "replayed %d entries from %d lines (%d outside the time window, %d invalid)\n",
+		total.Replayed, total.Lines, total.Skipped, total.Invalid)
+	return nil
+}
+
+// replayFile replays the file at path, or standard input for "-"
+func replayFile(ctx context.Context, path string, s sink.Sink, cfg sink.ReplayConfig) (sink.ReplayStats, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return sink.ReplayStats{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return sink.ReplayStats{}, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return sink.Replay(ctx, r, s, cfg)
+}
+
+// parseTime parses an optional RFC 3339 time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// teeSink writes batches to several sinks. Replayed entries are not pooled,
+// so they can be shared.
+type teeSink []*sink.BufferedSink
+
+func (t teeSink) Write(ctx context.Context, entry *sink.LogEntry) error {
+	return t.WriteBatch(ctx, []*sink.LogEntry{entry})
+}
+
+func (t teeSink) WriteBatch(ctx context.Context, entries []*sink.LogEntry) error {
+	var errs []error
+	for _, s := range t {
+		if err := s.WriteBatch(ctx, entries); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeSink) Flush(ctx context.Context) error {
+	var errs []error
+	for _, s := range t {
+		errs = append(errs, s.Flush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeSink) Close() error { return nil }
+
+func (t teeSink) IsHealthy() bool {
+	for _, s := range t {
+		if !s.IsHealthy() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// entry is a parsed log line
+type entry struct {
+	time    string
+	level   string // Lowercase go-zlog level name, or "" if unknown
+	message string
+	name    string // Logger name
+	caller  string
+	fields  map[string]any // Remaining fields
+}
+
+// Keys of the entry properties in the default, ECS and GCP layouts, in the
+// order they are looked up
+var (
+	timeKeys    = []string{"ts", "@timestamp", "time", "timestamp"}
+	levelKeys   = []string{"level", "log.level", "severity"}
+	messageKeys = []string{"msg", "message"}
+	nameKeys    = []string{"logger", "log.logger"}
+	callerKeys  = []string{"caller", "log.origin", "logging.googleapis.com/sourceLocation"}
+)
+
+// gcpLevels maps Cloud Logging severities to go-zlog levels
+var gcpLevels = map[string]string{
+	"default":   "info",
+	"warning":   "warn",
+	"notice":    "audit",
+	"critical":  "dpanic",
+	"alert":     "panic",
+	"emergency": "fatal",
+}
+
+// parseEntry parses a JSON or logfmt line, reporting false for other lines
+func parseEntry(line string) (*entry, bool) {
+	var fields map[string]any
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			return nil, false
+		}
+	} else if fields = parseLogfmt(line); fields == nil {
+		return nil, false
+	}
+
+	e := &entry{fields: fields}
+	e.time = e.take(timeKeys)
+	e.level = normalizeLevel(e.take(levelKeys))
+	e.message = e.take(messageKeys)
+	e.name = e.take(nameKeys)
+	e.caller = e.take(callerKeys)
+	return e, true
+}
+
+// take removes and returns the first of keys present in the fields
+func (e *entry) take(keys []string) string {
+	for _, key := range keys {
+		if v, ok := e.fields[key]; ok {
+			delete(e.fields, key)
+			return formatValue(v)
+		}
+	}
+	return ""
+}
+
+// normalizeLevel maps a level or GCP severity to a go-zlog level name
+func normalizeLevel(level string) string {
+	level = strings.ToLower(level)
+	if l, ok := gcpLevels[level]; ok {
+		return l
+	}
+	return level
+}
+
+// rank returns the entry's level for filtering; unknown levels rank as info
+func (e *entry) rank() zapcore.Level {
+	l, err := logger.ParseLevel(e.level)
+	if err != nil {
+		return zapcore.InfoLevel
+	}
+	return l
+}
+
+// filter selects the entries to print
+type filter struct {
+	minLevel zapcore.Level
+	fields   map[string]string // Required field values, compared as text
+	contains string            // Required message substring
+}
+
+// empty reports whether the filter selects every line
+func (f *filter) empty() bool {
+	return f.minLevel <= logger.TraceLevel && len(f.fields) == 0 && f.contains == ""
+}
+
+// match reports whether e passes the filter
+func (f *filter) match(e *entry) bool {
+	if e.rank() < f.minLevel {
+		return false
+	}
+	if !strings.Contains(e.message, f.contains) {
+		return false
+	}
+	for key, want := range f.fields {
+		var got string
+		switch key {
+		case "logger":
+			got = e.name
+		case "caller":
+			got = e.caller
+		default:
+			v, ok := e.fields[key]
+			if !ok {
+				return false
+			}
+			got = formatValue(v)
+		}
+		if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// ANSI colors of the pretty-printed output
+const (
+	colorReset   = "\x1b[0m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+)
+
+// levelColor returns the color of a level, like zap's colored console output
+func levelColor(level string) string {
+	switch level {
+	case "trace", "debug":
+		return colorMagenta
+	case "info":
+		return colorBlue
+	case "warn":
+		return colorYellow
+	case "audit":
+		return colorCyan
+	case "":
+		return ""
+	}
+	return colorRed
+}
+
+// format pretty-prints e on one line: time, level, logger, message, fields
+// in key order and caller
+func (e *entry) format(color bool) string {
+	paint := func(c, s string) string {
+		if !color || c == "" {
+			return s
+		}
+		return c + s + colorReset
+	}
+
+	var b strings.Builder
+	if e.time != "" {
+		b.WriteString(paint(colorDim, e.time))
+		b.WriteByte(' ')
+	}
+	b.WriteString(paint(levelColor(e.level), fmt.Sprintf("%-5s", strings.ToUpper(e.level))) + " ")
+	if e.name != "" {
+		b.WriteString(paint(colorDim, e.name+":") + " ")
+	}
+	b.WriteString(e.message)
+	for _, key := range slices.Sorted(maps.Keys(e.fields)) {
+		value := formatValue(e.fields[key])
+		switch e.fields[key].(type) {
+		case map[string]any, []any:
+		default:
+			value = quoteValue(value)
+		}
+		fmt.Fprintf(&b, " %s%s", paint(colorDim, key+"="), value)
+	}
+	if e.caller != "" {
+		b.WriteString(" " + paint(colorDim, "("+e.caller+")"))
+	}
+	return b.String()
+}
+
+// formatValue formats a decoded field value as text, objects as JSON
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// quoteValue quotes values containing spaces, quotes or control characters
+func quoteValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \"=\t\n\r") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// parseLogfmt parses a key=value line, returning nil if it is not logfmt
+func parseLogfmt(line string) map[string]any {
+	fields := make(map[string]any)
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimLeft(line, " ") {
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || strings.ContainsAny(line[:eq], " \"") {
+			return nil
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := closingQuote(line)
+			if end < 0 {
+				return nil
+			}
+			unquoted, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil
+			}
+			value, line = unquoted, line[end+1:]
+		} else if sp := strings.IndexByte(line, ' '); sp >= 0 {
+			value, line = line[:sp], line[sp:]
+		} else {
+			value, line = line, ""
+		}
+		fields[key] = value
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// closingQuote returns the index of the quote closing the quoted string s
+// starts with, or -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/hsdfat/go-zlog/lokiquery"
+)
+
+// lokiFlags selects the logs read back from Loki
+type lokiFlags struct {
+	url    string
+	query  string
+	tenant string
+	token  string
+	since  time.Duration
+}
+
+// tailLoki prints the last lines matching the query and, if follow is set,
+// the lines received over a live tail until ctx is done
+func tailLoki(ctx context.Context, p *printer, f lokiFlags, lines int, follow bool) error {
+	if f.query == "" {
+		return errors.New("-query is required with -loki")
+	}
+	c, err := lokiquery.New(lokiquery.Config{URL: f.url, TenantID: f.tenant, BearerToken: f.token})
+	if err != nil {
+		return err
+	}
+	start := time.Now().Add(-f.since)
+
+	if follow {
+		tail := lokiquery.Tail{Query: f.query, Start: start}
+		if lines >= 0 {
+			tail.Limit = max(lines, 1) // Loki treats 0 as its default
+		}
+		return c.Tail(ctx, tail, func(resp lokiquery.TailResponse) error {
+			printEntries(p, resp.Streams)
+			p.flush()
+			return nil
+		})
+	}
+
+	if lines == 0 {
+		return nil
+	}
+	q := lokiquery.QueryRange{Query: f.query, Start: start, Direction: lokiquery.Backward}
+	if lines > 0 {
+		q.Limit = lines
+	} else {
+		q.Limit = 5000 // Loki's default max_entries_limit_per_query
+	}
+	res, err := c.QueryRange(ctx, q)
+	if err != nil {
+		return err
+	}
+	printEntries(p, res.Streams)
+	return nil
+}
+
+// printEntries prints the lines of streams, oldest first
+func printEntries(p *printer, streams []lokiquery.Stream) {
+	var entries []lokiquery.Entry
+	for _, s := range streams {
+		entries = append(entries, s.Entries...)
+	}
+	slices.SortStableFunc(entries, func(a, b lokiquery.Entry) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	for _, e := range entries {
+		p.print("", e.Line)
+	}
+}
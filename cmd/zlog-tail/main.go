@@ -0,0 +1,290 @@
+// Command zlog-tail prints and follows log files written by go-zlog's file
+// outputs (JSON or logfmt, in the default, ECS or GCP layout), filtering
+// entries by level, field values and message and pretty-printing them:
+//
+//	zlog-tail -f -level warn -field service=checkout /var/log/app.log
+//
+// With no files it reads standard input. Followed files are reopened when
+// rotated or truncated. Lines that are not log entries are printed as-is
+// unless filtering.
+//
+// With -loki it reads the logs shipped to Loki instead, querying the last
+// lines of the -since window and, with -f, following them over a live tail:
+//
+//	zlog-tail -loki http://loki:3100 -query '{service="checkout"}' -f -level error
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+)
+
+// pollInterval is how often followed files are checked for new lines
+const pollInterval = 250 * time.Millisecond
+
+// fieldFlags collects repeated -field key=value flags
+type fieldFlags map[string]string
+
+func (f fieldFlags) String() string { return fmt.Sprint(map[string]string(f)) }
+
+func (f fieldFlags) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value, got %q", s)
+	}
+	f[key] = value
+	return nil
+}
+
+func main() {
+	fields := fieldFlags{}
+	var (
+		follow   = flag.Bool("f", false, "follow the files as they grow")
+		lines    = flag.Int("n", 10, "print the last n lines of each file first (-1 = all)")
+		level    = flag.String("level", "trace", "minimum level printed")
+		contains = flag.String("grep", "", "only print entries whose message contains this text")
+		raw      = flag.Bool("raw", false, "print matching lines as written instead of pretty-printing")
+		noColor  = flag.Bool("no-color", false, "disable colors (default: enabled on terminals)")
+	)
+	var loki lokiFlags
+	flag.StringVar(&loki.url, "loki", "", "read logs from the Loki server at this URL instead of files")
+	flag.StringVar(&loki.query, "query", "", "LogQL query of the logs read from Loki")
+	flag.StringVar(&loki.tenant, "tenant", "", "Loki tenant (X-Scope-OrgID)")
+	flag.StringVar(&loki.token, "token", os.Getenv("LOKI_TOKEN"), "Loki bearer token (default: $LOKI_TOKEN)")
+	flag.DurationVar(&loki.since, "since", time.Hour, "how far back to read logs from Loki")
+	flag.Var(fields, "field", "only print entries with this key=value field (repeatable)")
+	flag.Parse()
+
+	min, err := logger.ParseLevel(*level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "zlog-tail:", err)
+		os.Exit(2)
+	}
+	p := &printer{
+		filter: filter{minLevel: min, fields: fields, contains: *contains},
+		raw:    *raw,
+		color:  !*noColor && isTerminal(os.Stdout),
+		out:    bufio.NewWriter(os.Stdout),
+		prefix: flag.NArg() > 1,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch {
+	case loki.url != "":
+		err = tailLoki(ctx, p, loki, *lines, *follow)
+	case flag.NArg() == 0:
+		err = p.copy(ctx, "", os.Stdin)
+	default:
+		err = tailFiles(ctx, p, flag.Args(), *lines, *follow)
+	}
+	p.flush()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "zlog-tail:", err)
+		os.Exit(1)
+	}
+}
+
+// tailFiles prints the last lines of each file, then follows them
+// concurrently if follow is set
+func tailFiles(ctx context.Context, p *printer, paths []string, lines int, follow bool) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tailFile(ctx, p, path, lines, follow); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// tailFile prints the last lines of path and, if follow is set, the lines
+// appended to it until ctx is done
+func tailFile(ctx context.Context, p *printer, path string, lines int, follow bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	if lines >= 0 {
+		if err := seekLastLines(f, lines); err != nil {
+			return err
+		}
+	}
+	if !follow {
+		return p.copy(ctx, path, f)
+	}
+
+	r := bufio.NewReader(f)
+	var partial []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == nil {
+			p.print(path, string(append(partial, line[:len(line)-1]...)))
+			partial = partial[:0]
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial = append(partial, line...)
+		p.flush()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+
+		reopened, err := reopen(f, path)
+		if err != nil {
+			return err
+		}
+		if reopened != nil {
+			// Finish the old file's lines before switching, as rotation may
+			// race with the last writes
+			if _, err := r.WriteTo(io.Discard); err == nil && len(partial) > 0 {
+				p.print(path, string(partial))
+			}
+			f.Close()
+			f, partial = reopened, partial[:0]
+			r.Reset(f)
+		}
+	}
+}
+
+// reopen returns path opened anew if the file was rotated, or f rewound if
+// it was truncated, and nil if neither happened or path does not exist yet
+func reopen(f *os.File, path string) (*os.File, error) {
+	current, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	opened, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !os.SameFile(current, opened) {
+		return os.Open(path)
+	}
+	if pos, err := f.Seek(0, io.SeekCurrent); err == nil && current.Size() < pos {
+		_, err = f.Seek(0, io.SeekStart)
+		return nil, err
+	}
+	return nil, nil
+}
+
+// seekLastLines positions f at the start of its last n lines
+func seekLastLines(f *os.File, n int) error {
+	const chunk = 64 << 10
+	end, err := f.Seek(0, io.SeekEnd)
+	if err != nil || n == 0 {
+		return err
+	}
+	buf := make([]byte, chunk)
+	pos, newlines := end, 0
+	for pos > 0 {
+		size := int64(chunk)
+		if pos < size {
+			size = pos
+		}
+		pos -= size
+		if _, err := f.ReadAt(buf[:size], pos); err != nil && err != io.EOF {
+			return err
+		}
+		for i := size - 1; i >= 0; i-- {
+			if buf[i] != '\n' || pos+i == end-1 {
+				continue
+			}
+			if newlines++; newlines == n {
+				_, err := f.Seek(pos+i+1, io.SeekStart)
+				return err
+			}
+		}
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+// printer filters and prints lines from any number of goroutines
+type printer struct {
+	filter filter
+	raw    bool
+	color  bool
+	prefix bool // Prefix lines with their file name
+
+	mu  sync.Mutex
+	out *bufio.Writer
+}
+
+// copy prints the lines of r until it ends or ctx is done
+func (p *printer) copy(ctx context.Context, name string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.print(name, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// print prints line if it is a log entry matching the filter, or any other
+// line if the filter is empty
+func (p *printer) print(name, line string) {
+	line = strings.TrimRight(line, "\r")
+	e, ok := parseEntry(line)
+	if ok && !p.filter.match(e) || !ok && !p.filter.empty() {
+		return
+	}
+	if ok && !p.raw {
+		line = e.format(p.color)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.prefix && name != "" {
+		p.out.WriteString(name + ": ")
+	}
+	p.out.WriteString(line)
+	p.out.WriteByte('\n')
+}
+
+// flush writes the buffered output
+func (p *printer) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.out.Flush()
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
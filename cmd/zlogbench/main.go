@@ -0,0 +1,67 @@
+// Command zlogbench load-tests a logging pipeline built from a zlogconfig
+// file, or a discarding pipeline measuring go-zlog's own overhead:
+//
+//	zlogbench -config zlog.yaml -goroutines 16 -rate 200000 -duration 30s
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/hsdfat/go-zlog/zlogbench"
+	"github.com/hsdfat/go-zlog/zlogconfig"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "", "zlogconfig file of the pipeline (default: discard all entries)")
+		jsonOut    = flag.Bool("json", false, "print the result as JSON")
+		cfg        zlogbench.Config
+	)
+	flag.IntVar(&cfg.Goroutines, "goroutines", 0, "concurrent producers (default: GOMAXPROCS)")
+	flag.IntVar(&cfg.Rate, "rate", 0, "target entries per second across producers (0 = unlimited)")
+	flag.DurationVar(&cfg.Duration, "duration", 10*time.Second, "length of the run")
+	flag.IntVar(&cfg.Fields, "fields", 4, "key-value pairs per entry")
+	flag.StringVar(&cfg.Level, "level", "info", "entry level")
+	flag.Parse()
+
+	if err := run(*configPath, *jsonOut, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "zlogbench:", err)
+		os.Exit(1)
+	}
+}
+
+// run builds the pipeline, runs the load and prints the result
+func run(configPath string, jsonOut bool, cfg zlogbench.Config) error {
+	p := zlogbench.DiscardPipeline()
+	if configPath != "" {
+		c, err := zlogconfig.Load(configPath)
+		if err != nil {
+			return err
+		}
+		if p, err = c.Build(); err != nil {
+			return err
+		}
+	}
+	defer p.Close(context.Background())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	res, err := zlogbench.Run(ctx, p, cfg)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	fmt.Println(res)
+	return nil
+}
@@ -2,6 +2,20 @@ module github.com/hsdfat/go-zlog
 
 go 1.23
 
-require go.uber.org/zap v1.27.0
+require (
+	github.com/go-logr/logr v1.4.2
+	github.com/pelletier/go-toml/v2 v2.2.2
+	github.com/ugorji/go/codec v1.2.12
+	go.opentelemetry.io/otel/trace v1.31.0
+	go.uber.org/zap v1.27.0
+	golang.org/x/net v0.28.0
+	google.golang.org/protobuf v1.34.2
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
-require go.uber.org/multierr v1.10.0 // indirect
+require (
+	go.opentelemetry.io/otel v1.31.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+)
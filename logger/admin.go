@@ -0,0 +1,252 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// adminFlushTimeout bounds a flush triggered through the admin handler
+const adminFlushTimeout = 30 * time.Second
+
+// PatternLevel is a glob pattern set with SetLevelFor and its level
+type PatternLevel struct {
+	Pattern string `json:"pattern"`
+	Level   string `json:"level"`
+}
+
+// AdminLevels is the level state reported by the admin handler
+type AdminLevels struct {
+	Level    string            `json:"level"`              // Level of the administered logger
+	Named    map[string]string `json:"named,omitempty"`    // Levels of named loggers
+	Patterns []PatternLevel    `json:"patterns,omitempty"` // Patterns, in the order they apply
+}
+
+// AdminSampling is the sampling state reported and accepted by the admin
+// handler. Enabled defaults to true in requests; sending false disables
+// sampling.
+type AdminSampling struct {
+	Enabled    *bool  `json:"enabled,omitempty"`
+	Tick       string `json:"tick,omitempty"`
+	First      int    `json:"first,omitempty"`
+	Thereafter int    `json:"thereafter,omitempty"`
+}
+
+// AdminState is the full pipeline state returned by GET on the handler root
+type AdminState struct {
+	Levels   AdminLevels      `json:"levels"`
+	Sampling AdminSampling    `json:"sampling"`
+	Sinks    []sink.DebugInfo `json:"sinks"`
+}
+
+// AdminHandler returns an http.Handler controlling l and the sink pipeline at
+// runtime, meant to be mounted under a prefix such as /debug/zlog/:
+//
+//	GET  /debug/zlog/          levels, sampling and sink stats
+//	GET  /debug/zlog/level     levels of l, named loggers and patterns
+//	PUT  /debug/zlog/level     {"level":"debug"} sets l's level;
+//	                           {"name":"grpc.*","level":"warn"} calls SetLevelFor
+//	GET  /debug/zlog/sampling  current sampling
+//	PUT  /debug/zlog/sampling  {"tick":"1s","first":100,"thereafter":100},
+//	                           or {"enabled":false} to disable
+//	POST /debug/zlog/flush     flushes every buffered sink
+//	GET  /debug/zlog/sinks     sink stats (see sink.Snapshot)
+//
+// Like zap.AtomicLevel's handler, PUT also accepts form values in place of a
+// JSON body. Errors are returned as {"error": "..."}.
+func AdminHandler(l *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch route := path.Base(strings.TrimSuffix(r.URL.Path, "/")); route {
+		case "level":
+			l.serveLevel(w, r)
+		case "sampling":
+			l.serveSampling(w, r)
+		case "flush":
+			serveFlush(w, r)
+		case "sinks":
+			if allowMethods(w, r, http.MethodGet) {
+				writeAdminJSON(w, http.StatusOK, map[string]any{"sinks": sink.Snapshot()})
+			}
+		default:
+			if allowMethods(w, r, http.MethodGet) {
+				writeAdminJSON(w, http.StatusOK, AdminState{
+					Levels:   l.adminLevels(),
+					Sampling: l.adminSampling(),
+					Sinks:    sink.Snapshot(),
+				})
+			}
+		}
+	})
+}
+
+// serveLevel reports or changes levels
+func (l *Logger) serveLevel(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut) {
+		return
+	}
+	if r.Method == http.MethodPut {
+		var req struct {
+			Name  string `json:"name"`
+			Level string `json:"level"`
+		}
+		if err := decodeAdminRequest(r, &req, func(form func(string) string) {
+			req.Name, req.Level = form("name"), form("level")
+		}); err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Level == "" {
+			writeAdminError(w, http.StatusBadRequest, errors.New("level is required"))
+			return
+		}
+		if req.Name != "" {
+			if err := SetLevelFor(req.Name, req.Level); err != nil {
+				writeAdminError(w, http.StatusBadRequest, err)
+				return
+			}
+		} else {
+			lvl, err := ParseLevel(req.Level)
+			if err != nil {
+				writeAdminError(w, http.StatusBadRequest, err)
+				return
+			}
+			l.level.SetLevel(lvl)
+		}
+	}
+	writeAdminJSON(w, http.StatusOK, l.adminLevels())
+}
+
+// serveSampling reports or changes sampling
+func (l *Logger) serveSampling(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut) {
+		return
+	}
+	if r.Method == http.MethodPut {
+		var req AdminSampling
+		if err := decodeAdminRequest(r, &req, func(form func(string) string) {
+			fmt.Sscan(form("first"), &req.First)
+			fmt.Sscan(form("thereafter"), &req.Thereafter)
+			req.Tick = form("tick")
+			if v := form("enabled"); v != "" {
+				enabled := v == "true" || v == "1"
+				req.Enabled = &enabled
+			}
+		}); err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+		cfg, err := req.config()
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+		l.SetSampling(cfg)
+	}
+	writeAdminJSON(w, http.StatusOK, l.adminSampling())
+}
+
+// serveFlush flushes every buffered sink
+func serveFlush(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), adminFlushTimeout)
+	defer cancel()
+	if err := sink.FlushAll(ctx); err != nil {
+		writeAdminError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"sinks": sink.Snapshot()})
+}
+
+// adminLevels returns the level state of l and the named loggers
+func (l *Logger) adminLevels() AdminLevels {
+	named, patterns := namedLevelSnapshot()
+	return AdminLevels{Level: LevelString(l.level.Level()), Named: named, Patterns: patterns}
+}
+
+// adminSampling returns the sampling state of l
+func (l *Logger) adminSampling() AdminSampling {
+	cfg := l.Sampling()
+	enabled := cfg != nil
+	if !enabled {
+		return AdminSampling{Enabled: &enabled}
+	}
+	return AdminSampling{
+		Enabled:    &enabled,
+		Tick:       cfg.Tick.String(),
+		First:      cfg.First,
+		Thereafter: cfg.Thereafter,
+	}
+}
+
+// config converts a sampling request into settings, nil disabling sampling
+func (s AdminSampling) config() (*SamplingConfig, error) {
+	if s.Enabled != nil && !*s.Enabled {
+		return nil, nil
+	}
+	cfg := &SamplingConfig{Tick: time.Second, First: s.First, Thereafter: s.Thereafter}
+	if s.Tick != "" {
+		tick, err := time.ParseDuration(s.Tick)
+		if err != nil {
+			return nil, err
+		}
+		if tick <= 0 {
+			return nil, errors.New("tick must be positive")
+		}
+		cfg.Tick = tick
+	}
+	if cfg.First < 0 || cfg.Thereafter < 0 {
+		return nil, errors.New("first and thereafter must not be negative")
+	}
+	return cfg, nil
+}
+
+// decodeAdminRequest decodes a JSON body into dst, or passes form values to
+// fromForm for form-encoded requests
+func decodeAdminRequest(r *http.Request, dst any, fromForm func(form func(string) string)) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		fromForm(r.Form.Get)
+		return nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// allowMethods replies 405 and returns false unless r uses one of methods
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+// writeAdminJSON writes v as an indented JSON response
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeAdminError writes err as a JSON error response
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
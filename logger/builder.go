@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"io"
+	"os"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// output builds the core for one destination from the logger's encoder
+// configs and default level
+type output func(enc encoderConfigs, defaultLevel zapcore.LevelEnabler) zapcore.Core
+
+// consoleOutput marks the core of a console output, which request tails do
+// not buffer; NewLogger unwraps it
+type consoleOutput struct {
+	zapcore.Core
+}
+
+// FileConfig configures a rotating log file output
+type FileConfig struct {
+	Filename   string // Path of the log file
+	MaxSizeMB  int    // Size in megabytes before rotating (default: 100)
+	MaxBackups int    // Number of rotated files to keep (0 = all)
+	MaxAgeDays int    // Days to keep rotated files (0 = forever)
+	Compress   bool   // Gzip rotated files
+	Encoder    string // json (default) or logfmt
+}
+
+// Builder composes several outputs, each with its own minimum level, into a
+// single logger backed by one core tee
+type Builder struct {
+	outputs []output
+	opts    []Option
+}
+
+// NewBuilder creates an empty logger builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Console adds human-readable output on stderr. An empty level uses the
+// logger's level.
+func (b *Builder) Console(level string) *Builder {
+	b.outputs = append(b.outputs, func(enc encoderConfigs, def zapcore.LevelEnabler) zapcore.Core {
+		return consoleOutput{zapcore.NewCore(zapcore.NewConsoleEncoder(enc.console), zapcore.Lock(os.Stderr), levelOrDefault(level, def))}
+	})
+	return b
+}
+
+// ConsoleTo adds human-readable output written to w, e.g. a test's log (see
+// zlogtest). An empty level uses the logger's level.
+func (b *Builder) ConsoleTo(w io.Writer, level string) *Builder {
+	b.outputs = append(b.outputs, func(enc encoderConfigs, def zapcore.LevelEnabler) zapcore.Core {
+		return consoleOutput{zapcore.NewCore(zapcore.NewConsoleEncoder(enc.console), zapcore.Lock(zapcore.AddSync(w)), levelOrDefault(level, def))}
+	})
+	return b
+}
+
+// StderrJSON adds JSON output on stderr. An empty level uses the logger's level.
+func (b *Builder) StderrJSON(level string) *Builder {
+	b.outputs = append(b.outputs, func(enc encoderConfigs, def zapcore.LevelEnabler) zapcore.Core {
+		return zapcore.NewCore(zapcore.NewJSONEncoder(enc.json), zapcore.Lock(os.Stderr), levelOrDefault(level, def))
+	})
+	return b
+}
+
+// StdoutJSON adds JSON output on stdout. An empty level uses the logger's level.
+func (b *Builder) StdoutJSON(level string) *Builder {
+	b.outputs = append(b.outputs, func(enc encoderConfigs, def zapcore.LevelEnabler) zapcore.Core {
+		return zapcore.NewCore(zapcore.NewJSONEncoder(enc.json), zapcore.Lock(os.Stdout), levelOrDefault(level, def))
+	})
+	return b
+}
+
+// StderrLogfmt adds logfmt output on stderr. An empty level uses the logger's level.
+func (b *Builder) StderrLogfmt(level string) *Builder {
+	b.outputs = append(b.outputs, func(enc encoderConfigs, def zapcore.LevelEnabler) zapcore.Core {
+		return zapcore.NewCore(NewLogfmtEncoder(enc.json), zapcore.Lock(os.Stderr), levelOrDefault(level, def))
+	})
+	return b
+}
+
+// File adds JSON or logfmt output to a size-rotated file. An empty level uses
+// the logger's level.
+func (b *Builder) File(fc FileConfig, level string) *Builder {
+	if fc.MaxSizeMB == 0 {
+		fc.MaxSizeMB = 100
+	}
+	writer := &lumberjack.Logger{
+		Filename:   fc.Filename,
+		MaxSize:    fc.MaxSizeMB,
+		MaxBackups: fc.MaxBackups,
+		MaxAge:     fc.MaxAgeDays,
+		Compress:   fc.Compress,
+	}
+	b.outputs = append(b.outputs, func(enc encoderConfigs, def zapcore.LevelEnabler) zapcore.Core {
+		var encoder zapcore.Encoder
+		if fc.Encoder == "logfmt" {
+			encoder = NewLogfmtEncoder(enc.json)
+		} else {
+			encoder = zapcore.NewJSONEncoder(enc.json)
+		}
+		return zapcore.NewCore(encoder, zapcore.AddSync(writer), levelOrDefault(level, def))
+	})
+	return b
+}
+
+// Sink adds a remote sink (e.g., Loki, HTTP). An empty level uses the
+// logger's level.
+func (b *Builder) Sink(s sink.Sink, level string) *Builder {
+	b.outputs = append(b.outputs, func(enc encoderConfigs, def zapcore.LevelEnabler) zapcore.Core {
+		return newZapSinkCore(s, enc.sink, levelOrDefault(level, def))
+	})
+	return b
+}
+
+// Options adds logger options applied on Build
+func (b *Builder) Options(opts ...Option) *Builder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Build creates the logger. Only the outputs added to the builder are used;
+// the default console output is disabled.
+func (b *Builder) Build() *Logger {
+	opts := append([]Option{WithConsole(false)}, b.opts...)
+	opts = append(opts, withOutputs(b.outputs...))
+	return NewLogger(opts...)
+}
+
+// withOutputs adds outputs to the logger core tee
+func withOutputs(outputs ...output) Option {
+	return func(o *options) {
+		o.outputs = append(o.outputs, outputs...)
+	}
+}
+
+// levelOrDefault parses level, falling back to def when level is empty
+func levelOrDefault(level string, def zapcore.LevelEnabler) zapcore.LevelEnabler {
+	if level == "" {
+		return def
+	}
+	return parseLevel(level)
+}
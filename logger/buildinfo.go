@@ -0,0 +1,63 @@
+package logger
+
+import "runtime/debug"
+
+// Field keys set by BuildInfoEnricher
+const (
+	FieldVersion   = "version"
+	FieldGitCommit = "git_commit"
+	FieldGitDirty  = "git_dirty"
+)
+
+// Build metadata injected at link time, overriding what BuildInfoEnricher
+// reads from the binary, e.g.
+//
+//	go build -ldflags "-X github.com/hsdfat/go-zlog/logger.BuildVersion=1.4.2 -X github.com/hsdfat/go-zlog/logger.BuildCommit=$(git rev-parse HEAD)"
+var (
+	BuildVersion string
+	BuildCommit  string
+)
+
+// BuildInfoEnricher stamps the main module's version, VCS revision and dirty
+// flag (from debug.ReadBuildInfo) as "version", "git_commit" and "git_dirty".
+// BuildVersion and BuildCommit take precedence when set.
+func BuildInfoEnricher() Enricher {
+	return func() []any {
+		version, commit, dirty := readBuildInfo()
+		if BuildVersion != "" {
+			version = BuildVersion
+		}
+		if BuildCommit != "" {
+			commit, dirty = BuildCommit, false
+		}
+
+		var fields []any
+		fields = appendNonEmpty(fields, FieldVersion, version)
+		fields = appendNonEmpty(fields, FieldGitCommit, commit)
+		if dirty {
+			fields = append(fields, FieldGitDirty, true)
+		}
+		return fields
+	}
+}
+
+// readBuildInfo returns the main module version ("" for development builds)
+// and the VCS revision and modified flag embedded by the go tool
+func readBuildInfo() (version, commit string, dirty bool) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", "", false
+	}
+	if info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			commit = setting.Value
+		case "vcs.modified":
+			dirty = setting.Value == "true"
+		}
+	}
+	return version, commit, dirty
+}
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sinkWriteTimeout bounds writing an entry to a sink
+const sinkWriteTimeout = 5 * time.Second
+
+// Context keys for the request-scoped logger and fields
+type (
+	loggerKey struct{}
+	fieldsKey struct{}
+)
+
+// ContextExtractor returns key-value pairs to log for a context (e.g. IDs
+// injected by middleware or tracing libraries)
+type ContextExtractor func(ctx context.Context) []any
+
+// contextExtractors are run by the *Ctx methods in registration order
+var contextExtractors struct {
+	sync.RWMutex
+	fns []ContextExtractor
+}
+
+// RegisterContextExtractor adds an extractor whose fields are added to every
+// entry logged through the *Ctx methods
+func RegisterContextExtractor(fn ContextExtractor) {
+	contextExtractors.Lock()
+	defer contextExtractors.Unlock()
+	contextExtractors.fns = append(contextExtractors.fns, fn)
+}
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger (see L)
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return L()
+}
+
+// WithContext returns a copy of ctx carrying additional key-value pairs that
+// the *Ctx methods add to every entry
+func WithContext(ctx context.Context, args ...any) context.Context {
+	existing, _ := ctx.Value(fieldsKey{}).([]any)
+	fields := make([]any, 0, len(existing)+len(args))
+	fields = append(fields, existing...)
+	fields = append(fields, args...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// WithRequestID returns a copy of ctx carrying a request_id field
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithContext(ctx, "request_id", id)
+}
+
+// WithUserID returns a copy of ctx carrying a user_id field
+func WithUserID(ctx context.Context, id string) context.Context {
+	return WithContext(ctx, "user_id", id)
+}
+
+// contextValue is the value of a Context field
+type contextValue struct {
+	ctx context.Context
+}
+
+// Context returns a field carrying ctx to sinks, which the *Ctx methods add
+// to every entry. The entry is written to sinks with ctx's values (e.g. a
+// request ID), bounded by ctx's deadline unless it has already passed, and
+// not canceled with ctx, so entries logged as a request ends are kept. The
+// deadline is recorded in LogEntry.Deadline, so entries shipped after their
+// request's deadline are counted (see sink.Stats.Late). Encoder outputs do
+// not write it.
+func Context(ctx context.Context) Field {
+	return zap.Field{Key: "context", Type: zapcore.SkipType, Interface: contextValue{ctx}}
+}
+
+// isContext reports whether f is a Context field
+func isContext(f zapcore.Field) bool {
+	v, ok := f.Interface.(contextValue)
+	return ok && v.ctx != nil && f.Type == zapcore.SkipType
+}
+
+// isCarried reports whether f carries entry metadata to sinks (see Labels
+// and Context), which core wrappers dropping skipped fields must keep
+func isCarried(f zapcore.Field) bool {
+	return isLabels(f) || isContext(f)
+}
+
+// callerContext returns the context of the last Context field in fields, or
+// ctx if there is none
+func callerContext(ctx context.Context, fields []zapcore.Field) context.Context {
+	for _, f := range fields {
+		if isContext(f) {
+			ctx = f.Interface.(contextValue).ctx
+		}
+	}
+	return ctx
+}
+
+// writeContext returns the context of writing an entry logged with caller
+// to a sink (nil = none) and the caller's deadline. It keeps the caller's
+// values but not its cancellation, and is bounded by sinkWriteTimeout and
+// by the caller's deadline while that has not passed.
+func writeContext(caller context.Context) (context.Context, context.CancelFunc, time.Time) {
+	if caller == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), sinkWriteTimeout)
+		return ctx, cancel, time.Time{}
+	}
+	deadline, _ := caller.Deadline()
+	limit := time.Now().Add(sinkWriteTimeout)
+	if deadline.After(time.Now()) && deadline.Before(limit) {
+		limit = deadline
+	}
+	ctx, cancel := context.WithDeadline(context.WithoutCancel(caller), limit)
+	return ctx, cancel, deadline
+}
+
+// ctxArgs returns the key-value pairs logged by the *Ctx methods: the
+// context's fields, args and the Context field
+func ctxArgs(ctx context.Context, args []any) []any {
+	fields := append(ContextFields(ctx), args...)
+	if ctx != nil {
+		fields = append(fields, Context(ctx))
+	}
+	return fields
+}
+
+// ContextFields returns the key-value pairs carried by ctx followed by those
+// produced by the registered extractors
+func ContextFields(ctx context.Context) []any {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+
+	contextExtractors.RLock()
+	defer contextExtractors.RUnlock()
+	if len(contextExtractors.fns) == 0 {
+		return slices.Clip(fields) // Callers may append without touching ctx
+	}
+
+	fields = append([]any(nil), fields...)
+	for _, fn := range contextExtractors.fns {
+		fields = append(fields, fn(ctx)...)
+	}
+	return fields
+}
+
+// The *Ctx methods check the level before collecting the context fields, so
+// disabled calls don't allocate. Fatal entries are always logged.
+
+func (l *Logger) InfowCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l.level.Enabled(zapcore.InfoLevel) {
+		l.sugar.Infow(msg, ctxArgs(ctx, args)...)
+	}
+}
+
+func (l *Logger) WarnwCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l.level.Enabled(zapcore.WarnLevel) {
+		l.sugar.Warnw(msg, ctxArgs(ctx, args)...)
+	}
+}
+
+func (l *Logger) ErrorwCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l.level.Enabled(zapcore.ErrorLevel) {
+		l.sugar.Errorw(msg, ctxArgs(ctx, args)...)
+	}
+}
+
+func (l *Logger) DebugwCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l.level.Enabled(zapcore.DebugLevel) {
+		l.sugar.Debugw(msg, ctxArgs(ctx, args)...)
+	}
+}
+
+func (l *Logger) FatalwCtx(ctx context.Context, msg string, args ...interface{}) {
+	l.sugar.Fatalw(msg, ctxArgs(ctx, args)...)
+}
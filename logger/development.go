@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// encoderConfigs holds the encoder settings for machine-readable outputs,
+// human-readable console output and the lines pre-encoded for sinks
+type encoderConfigs struct {
+	json    zapcore.EncoderConfig
+	console zapcore.EncoderConfig
+	sink    zapcore.EncoderConfig
+}
+
+// WithDevelopment switches to a development setup like zap's: colored
+// levels and a short wall-clock time on the console, stack traces on error
+// entries, and DPanic panicking. JSON and sink outputs are unchanged.
+func WithDevelopment() Option {
+	return func(o *options) {
+		o.development = true
+	}
+}
+
+// devTimeLayout is the console time format in development mode
+const devTimeLayout = "15:04:05.000"
+
+// devStacktrace adds stack traces to error, panic and fatal entries but not
+// to audit entries, which rank above fatal
+var devStacktrace = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+	return l >= zapcore.ErrorLevel && l <= zapcore.FatalLevel
+})
+
+// levelColors are the ANSI colors of the levels zap's color encoder does not know
+var levelColors = map[zapcore.Level]string{
+	TraceLevel: "\x1b[36m", // Cyan
+	AuditLevel: "\x1b[32m", // Green
+}
+
+// encodeColorLevel encodes levels in colored capitals, naming trace and audit
+func encodeColorLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	color, ok := levelColors[l]
+	if !ok {
+		zapcore.CapitalColorLevelEncoder(l, enc)
+		return
+	}
+	enc.AppendString(color + strings.ToUpper(LevelString(l)) + "\x1b[0m")
+}
+
+// developmentConsole adapts a console encoder config for development mode
+func developmentConsole(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	cfg.EncodeLevel = encodeColorLevel
+	cfg.EncodeTime = zapcore.TimeEncoderOfLayout(devTimeLayout)
+	cfg.EncodeCaller = zapcore.ShortCallerEncoder
+	return cfg
+}
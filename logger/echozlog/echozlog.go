@@ -0,0 +1,73 @@
+// Package echozlog provides Echo middleware for access logging and panic
+// recovery built on the httpzlog request-scoped logger conventions.
+// It is a separate module, so Echo only enters builds importing it.
+package echozlog
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"github.com/hsdfat/go-zlog/logger/httpzlog"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware propagates or generates an X-Request-ID, stores a request-scoped
+// logger in the request context (see logger.FromContext) and logs one access
+// entry per request. Paths in skipPaths are not logged.
+func Middleware(l *logger.Logger, skipPaths ...string) echo.MiddlewareFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, p := range skipPaths {
+		skip[p] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			requestID := httpzlog.RequestID(req.Header.Get(httpzlog.DefaultRequestIDHeader))
+			c.Response().Header().Set(httpzlog.DefaultRequestIDHeader, requestID)
+
+			reqLogger := httpzlog.RequestLogger(l, requestID, req.Method, c.Path())
+			c.SetRequest(req.WithContext(logger.NewContext(req.Context(), reqLogger)))
+
+			err := next(c)
+			if err != nil {
+				// Let Echo render the error so the logged status is accurate
+				c.Error(err)
+			}
+
+			if skip[req.URL.Path] {
+				return nil
+			}
+			httpzlog.LogAccess(reqLogger, "http request", httpzlog.AccessInfo{
+				Status:    c.Response().Status,
+				Bytes:     int(c.Response().Size),
+				Latency:   time.Since(start),
+				RemoteIP:  c.RealIP(),
+				UserAgent: req.UserAgent(),
+			})
+			return nil
+		}
+	}
+}
+
+// Recovery recovers from panics in later handlers, logs them with a stack
+// trace through the request-scoped logger and returns a 500 error
+func Recovery() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.FromContext(c.Request().Context()).Errorw("panic recovered",
+						"panic", r,
+						"stack", string(debug.Stack()),
+					)
+					err = echo.ErrInternalServerError
+				}
+			}()
+			return next(c)
+		}
+	}
+}
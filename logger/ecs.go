@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap/zapcore"
+)
+
+// Field layouts of encoder outputs
+const (
+	layoutECS = "ecs"
+	layoutGCP = "gcp"
+)
+
+// WithECS lays out JSON output with Elastic Common Schema field names
+// (@timestamp, log.level, message, error.message, trace.id, service.name,
+// host.name, ...) so Kibana dashboards work without ingest pipelines. It
+// applies to encoder outputs; sinks are configured separately (see
+// sink.HTTPSinkConfig.Format).
+func WithECS() Option {
+	return func(o *options) {
+		o.layout = layoutECS
+	}
+}
+
+// ecsEncoderConfig renames the entry keys of cfg to their ECS names
+func ecsEncoderConfig(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	cfg.TimeKey = sink.ECSTimestamp
+	cfg.LevelKey = sink.ECSLevel
+	cfg.NameKey = sink.ECSLogger
+	cfg.MessageKey = sink.ECSMessage
+	cfg.CallerKey = "log.origin"
+	cfg.EncodeCaller = encodeECSCaller
+	if cfg.FunctionKey != zapcore.OmitKey {
+		cfg.FunctionKey = sink.ECSOriginFunction
+	}
+	cfg.StacktraceKey = sink.ECSErrorStackTrace
+	cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	return cfg
+}
+
+// encodeECSCaller encodes the caller as ECS log.origin.file.{name,line}
+func encodeECSCaller(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	ae, ok := enc.(zapcore.ArrayEncoder)
+	if !ok {
+		enc.AppendString(caller.TrimmedPath())
+		return
+	}
+	file := caller.TrimmedPath()
+	if i := strings.LastIndexByte(file, ':'); i >= 0 {
+		file = file[:i]
+	}
+	_ = ae.AppendObject(zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+		return oe.AddObject("file", zapcore.ObjectMarshalerFunc(func(fe zapcore.ObjectEncoder) error {
+			fe.AddString("name", file)
+			fe.AddInt("line", caller.Line)
+			return nil
+		}))
+	}))
+}
+
+// ecsContext returns the ECS version, host and service fields added to
+// every entry of encoder outputs
+func ecsContext(r sink.Resource) []zapcore.Field {
+	fields := []zapcore.Field{{Key: sink.ECSVersionField, Type: zapcore.StringType, String: sink.ECSVersion}}
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, zapcore.Field{Key: key, Type: zapcore.StringType, String: value})
+		}
+	}
+	hostname, _ := os.Hostname()
+	add(sink.ECSHostName, hostname)
+	add(sink.ECSServiceName, r.ServiceName)
+	add(sink.ECSServiceNode, r.InstanceID)
+	add(sink.ECSServiceEnv, r.Environment)
+	add(sink.ECSServiceVersion, r.Version)
+	return fields
+}
+
+// ecsCore renames well-known field keys (error, trace_id, span_id, ...) to
+// their ECS names before they reach an encoder output
+type ecsCore struct {
+	zapcore.Core
+}
+
+// With adds structured context under ECS names
+func (c *ecsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &ecsCore{Core: c.Core.With(ecsFields(fields))}
+}
+
+// Check defers to the wrapped core's level, adding this core
+func (c *ecsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write renames the fields, then writes them to the wrapped core
+func (c *ecsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, ecsFields(fields))
+}
+
+// ecsFields returns fields with ECS keys, copying the slice only if a key changes
+func ecsFields(fields []zapcore.Field) []zapcore.Field {
+	out := fields
+	copied := false
+	for i, f := range fields {
+		name := sink.ECSFieldName(f.Key)
+		if name == f.Key {
+			continue
+		}
+		if !copied {
+			out, copied = slices.Clone(fields), true
+		}
+		out[i].Key = name
+	}
+	return out
+}
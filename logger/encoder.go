@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EncoderConfig customizes the entry layout of every output, so logs can
+// match an organization-wide schema. Zero values keep the defaults; a key set
+// to "-" omits that part of the entry.
+type EncoderConfig struct {
+	TimeKey       string // Default: "ts"
+	LevelKey      string // Default: "level"
+	MessageKey    string // Default: "msg"
+	NameKey       string // Default: "logger"
+	CallerKey     string // Default: "caller"
+	FunctionKey   string // Default: "function" with WithCallerFunction
+	StacktraceKey string // Default: "stacktrace"
+
+	// TimeFormat is iso8601 (default), rfc3339, rfc3339nano, epoch, millis,
+	// nanos or a time.Format layout
+	TimeFormat string
+	// TimeZone converts entry times before formatting (default: local time)
+	TimeZone *time.Location
+	// LevelCase is lower (default), upper or color
+	LevelCase string
+	// CallerFormat is short (default, package/file:line) or full
+	CallerFormat string
+	// DurationUnit is s (default, float seconds), ms, nanos or string
+	DurationUnit string
+
+	// FieldKeys renames field keys in every output, e.g. {"error": "err"}
+	FieldKeys map[string]string
+}
+
+// WithEncoderConfig customizes the entry layout of every output. Layouts
+// such as WithECS and WithGCP take precedence over the keys set here, and
+// invalid formats keep the defaults (see EncoderConfig.Validate).
+func WithEncoderConfig(ec EncoderConfig) Option {
+	return func(o *options) {
+		o.encoderConfig = &ec
+	}
+}
+
+// Validate reports unknown level cases, caller formats and duration units
+func (ec *EncoderConfig) Validate() error {
+	_, err := ec.apply(zapcore.EncoderConfig{})
+	return err
+}
+
+// apply returns cfg customized by ec, and the first invalid setting, which
+// is left at its default
+func (ec *EncoderConfig) apply(cfg zapcore.EncoderConfig) (zapcore.EncoderConfig, error) {
+	var err error
+	setKey := func(dst *string, key string) {
+		switch key {
+		case "":
+		case "-":
+			*dst = zapcore.OmitKey
+		default:
+			*dst = key
+		}
+	}
+	setKey(&cfg.TimeKey, ec.TimeKey)
+	setKey(&cfg.LevelKey, ec.LevelKey)
+	setKey(&cfg.MessageKey, ec.MessageKey)
+	setKey(&cfg.NameKey, ec.NameKey)
+	setKey(&cfg.CallerKey, ec.CallerKey)
+	setKey(&cfg.StacktraceKey, ec.StacktraceKey)
+	if cfg.FunctionKey != zapcore.OmitKey {
+		setKey(&cfg.FunctionKey, ec.FunctionKey)
+	}
+
+	switch strings.ToLower(ec.TimeFormat) {
+	case "":
+	case "iso8601", "rfc3339", "rfc3339nano", "epoch", "millis", "nanos":
+		var enc zapcore.TimeEncoder
+		_ = enc.UnmarshalText([]byte(strings.ToLower(ec.TimeFormat)))
+		cfg.EncodeTime = enc
+	default:
+		cfg.EncodeTime = zapcore.TimeEncoderOfLayout(ec.TimeFormat)
+	}
+	if loc := ec.TimeZone; loc != nil {
+		encodeTime := cfg.EncodeTime
+		cfg.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			encodeTime(t.In(loc), enc)
+		}
+	}
+
+	switch strings.ToLower(ec.LevelCase) {
+	case "", "lower":
+	case "upper":
+		cfg.EncodeLevel = encodeUpperLevel
+	case "color":
+		cfg.EncodeLevel = encodeColorLevel
+	default:
+		err = cmp.Or(err, fmt.Errorf("unknown level case %q", ec.LevelCase))
+	}
+
+	switch strings.ToLower(ec.CallerFormat) {
+	case "", "short":
+	case "full":
+		cfg.EncodeCaller = zapcore.FullCallerEncoder
+	default:
+		err = cmp.Or(err, fmt.Errorf("unknown caller format %q", ec.CallerFormat))
+	}
+
+	switch strings.ToLower(ec.DurationUnit) {
+	case "":
+	case "s":
+		cfg.EncodeDuration = zapcore.SecondsDurationEncoder
+	case "ms":
+		cfg.EncodeDuration = zapcore.MillisDurationEncoder
+	case "nanos", "ns":
+		cfg.EncodeDuration = zapcore.NanosDurationEncoder
+	case "string":
+		cfg.EncodeDuration = zapcore.StringDurationEncoder
+	default:
+		err = cmp.Or(err, fmt.Errorf("unknown duration unit %q", ec.DurationUnit))
+	}
+	return cfg, err
+}
+
+// encodeUpperLevel encodes levels in capitals, naming trace and audit
+func encodeUpperLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(strings.ToUpper(LevelString(l)))
+}
+
+// renameCore renames field keys before they reach the wrapped core
+type renameCore struct {
+	zapcore.Core
+	keys map[string]string
+}
+
+// With adds structured context under the renamed keys
+func (c *renameCore) With(fields []zapcore.Field) zapcore.Core {
+	return &renameCore{Core: c.Core.With(c.rename(fields)), keys: c.keys}
+}
+
+// Check defers to the wrapped core's level, adding this core
+func (c *renameCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write renames the fields, then writes them to the wrapped core
+func (c *renameCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.rename(fields))
+}
+
+// rename returns fields with renamed keys, copying the slice only if a key changes
+func (c *renameCore) rename(fields []zapcore.Field) []zapcore.Field {
+	out := fields
+	copied := false
+	for i, f := range fields {
+		key, ok := c.keys[f.Key]
+		if !ok {
+			continue
+		}
+		if !copied {
+			out, copied = slices.Clone(fields), true
+		}
+		out[i].Key = key
+	}
+	return out
+}
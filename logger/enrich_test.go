@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// memorySink records the entries written to it
+type memorySink struct {
+	mu      sync.Mutex
+	entries []*sink.LogEntry
+}
+
+func (m *memorySink) Write(ctx context.Context, entry *sink.LogEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memorySink) WriteBatch(ctx context.Context, entries []*sink.LogEntry) error {
+	for _, entry := range entries {
+		m.Write(ctx, entry)
+	}
+	return nil
+}
+
+func (m *memorySink) Flush(ctx context.Context) error { return nil }
+func (m *memorySink) Close() error                    { return nil }
+func (m *memorySink) IsHealthy() bool                 { return true }
+
+func (m *memorySink) written() []*sink.LogEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*sink.LogEntry(nil), m.entries...)
+}
+
+func TestEntryFieldsWithSink(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"sequence":     WithSequence(),
+		"goroutine_id": WithGoroutineID(),
+		"entry_ids":    WithEntryIDs(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := &memorySink{}
+			l := NewLogger(WithConsole(false), WithSink(s), opt)
+			l.Infow("hello", "k", "v")
+
+			entries := s.written()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			if entries[0].Message != "hello" || entries[0].Fields["k"] != "v" {
+				t.Errorf("got %q %v", entries[0].Message, entries[0].Fields)
+			}
+		})
+	}
+}
+
+func TestEntryFieldsWithTail(t *testing.T) {
+	s := &memorySink{}
+	l := NewLogger(WithConsole(false), WithSink(s), WithSequence())
+
+	tl, tail := NewTail(l, TailConfig{})
+	tl.Info("buffered")
+	if n := len(s.written()); n != 0 {
+		t.Fatalf("got %d entries before the tail ended, want 0", n)
+	}
+	tail.End(true)
+
+	entries := s.written()
+	if len(entries) != 1 || entries[0].Message != "buffered" {
+		t.Fatalf("got %d entries after release, want the buffered one", len(entries))
+	}
+	if _, ok := entries[0].Fields[FieldSequence]; !ok {
+		t.Errorf("released entry lost %s: %v", FieldSequence, entries[0].Fields)
+	}
+}
+
+func TestEntryFieldsTailTrigger(t *testing.T) {
+	s := &memorySink{}
+	l := NewLogger(WithConsole(false), WithSink(s), WithEntryIDs())
+
+	tl, tail := NewTail(l, TailConfig{})
+	tl.Info("buffered")
+	tl.Error("failed")
+	entries := s.written()
+	if len(entries) != 2 || entries[0].Message != "buffered" || entries[1].Message != "failed" {
+		t.Fatalf("got %d entries, want the buffered entry released by the error", len(entries))
+	}
+	if !tail.Released() {
+		t.Error("tail not released by the error entry")
+	}
+}
@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// recentErrorsSize is the number of error entries remembered for RecentErrors
+const recentErrorsSize = 256
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// WithEntryIDs adds a ULID to every entry in the log_id field, so a metric
+// exemplar or span event can link to the exact log line. The IDs of recent
+// error entries are available from RecentErrors.
+func WithEntryIDs() Option {
+	return func(o *options) {
+		o.entryIDs = true
+	}
+}
+
+// EntryRef identifies a logged entry
+type EntryRef struct {
+	ID      string        // ULID in the log_id field
+	Time    time.Time     // Entry time
+	Level   zapcore.Level // Entry level
+	Logger  string        // Dotted logger name
+	Message string
+}
+
+// ulids generates monotonic ULIDs: IDs created in the same millisecond
+// increment the random part, so they sort in creation order
+var ulids struct {
+	sync.Mutex
+	ms      uint64
+	entropy [10]byte
+}
+
+// NewEntryID returns a new ULID
+func NewEntryID() string {
+	return newEntryID(time.Now())
+}
+
+// newEntryID returns a new ULID for an entry logged at t, so the ID agrees
+// with the entry's timestamp (including under WithClock). IDs stay in
+// creation order when t goes backwards.
+func newEntryID(t time.Time) string {
+	ms := uint64(max(t.UnixMilli(), 0))
+
+	ulids.Lock()
+	if ms <= ulids.ms {
+		ms = ulids.ms
+		incrementEntropy(&ulids.entropy)
+	} else {
+		ulids.ms = ms
+		rand.Read(ulids.entropy[:])
+	}
+	var id [16]byte
+	binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	copy(id[6:], ulids.entropy[:])
+	ulids.Unlock()
+
+	return encodeULID(id)
+}
+
+// incrementEntropy adds one to the big-endian random part
+func incrementEntropy(e *[10]byte) {
+	for i := len(e) - 1; i >= 0; i-- {
+		e[i]++
+		if e[i] != 0 {
+			return
+		}
+	}
+}
+
+// encodeULID encodes 128 bits as 26 Crockford base32 characters
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[0:8])
+	lo := binary.BigEndian.Uint64(id[8:16])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// recentErrors remembers the latest error entries of WithEntryIDs loggers
+var recentErrors struct {
+	sync.Mutex
+	refs [recentErrorsSize]EntryRef
+	next int
+	n    int
+}
+
+// rememberError records an error entry's ID
+func rememberError(ref EntryRef) {
+	recentErrors.Lock()
+	defer recentErrors.Unlock()
+	recentErrors.refs[recentErrors.next] = ref
+	recentErrors.next = (recentErrors.next + 1) % recentErrorsSize
+	recentErrors.n = min(recentErrors.n+1, recentErrorsSize)
+}
+
+// RecentErrors returns up to n of the latest entries at error level or above
+// logged by WithEntryIDs loggers, newest first, e.g. to attach their IDs as
+// exemplars or span events. At most 256 entries are remembered.
+func RecentErrors(n int) []EntryRef {
+	recentErrors.Lock()
+	defer recentErrors.Unlock()
+	n = min(n, recentErrors.n)
+	refs := make([]EntryRef, 0, max(n, 0))
+	for i := 1; i <= n; i++ {
+		refs = append(refs, recentErrors.refs[(recentErrors.next-i+recentErrorsSize)%recentErrorsSize])
+	}
+	return refs
+}
+
+// RecentErrorIDs returns the IDs of up to n of the latest error entries,
+// newest first (see RecentErrors)
+func RecentErrorIDs(n int) []string {
+	refs := RecentErrors(n)
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.ID
+	}
+	return ids
+}
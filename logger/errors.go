@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// errorKeySuffixes are the suffixes of the keys expandError returns, in
+// output order
+var errorKeySuffixes = []string{"", "_type", "_causes", "_stack"}
+
+// WithError returns a child logger carrying err expanded by ErrorFields
+func (l *Logger) WithError(err error) *Logger {
+	child, _ := l.With(ErrorFields(err)...).(*Logger)
+	return child
+}
+
+// ErrorFields expands err into key-value pairs: "error" (the message),
+// "error_type" (the root cause's type), "error_causes" (messages of the
+// wrapped chain, outermost first) and "error_stack" for errors carrying a
+// pkg/errors-style StackTrace
+func ErrorFields(err error) []any {
+	if err == nil {
+		return nil
+	}
+
+	expanded := expandError("error", err)
+	fields := make([]any, 0, 2*len(expanded))
+	for _, suffix := range errorKeySuffixes {
+		if v, ok := expanded["error"+suffix]; ok {
+			fields = append(fields, "error"+suffix, v)
+		}
+	}
+	return fields
+}
+
+// errorCore expands error fields into their message, type, wrapped causes
+// and stack trace before they reach the wrapped core, so encoder outputs and
+// pre-encoding sinks carry the same fields as the other sinks
+type errorCore struct {
+	zapcore.Core
+}
+
+// With adds structured context with errors expanded
+func (c *errorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorCore{Core: c.Core.With(expandErrorFields(fields))}
+}
+
+// Check defers to the wrapped core's level, adding this core
+func (c *errorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write expands the errors, then writes the fields to the wrapped core
+func (c *errorCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, expandErrorFields(fields))
+}
+
+// expandErrorFields returns fields with every non-nil error replaced by its
+// expansion, copying the slice only if it holds an error
+func expandErrorFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		err, ok := f.Interface.(error)
+		if f.Type != zapcore.ErrorType || !ok || err == nil {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]zapcore.Field, 0, len(fields)+3), fields[:i]...)
+		}
+		expanded := expandError(f.Key, err)
+		for _, suffix := range errorKeySuffixes {
+			if v, ok := expanded[f.Key+suffix]; ok {
+				out = append(out, zap.Any(f.Key+suffix, v))
+			}
+		}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// expandError returns the structured fields for err under the given key
+func expandError(key string, err error) map[string]any {
+	fields := map[string]any{key: err.Error()}
+
+	causes := errorCauses(err)
+	root := err
+	if len(causes) > 0 {
+		fields[key+"_causes"] = causeMessages(causes)
+		root = causes[len(causes)-1]
+	}
+	fields[key+"_type"] = fmt.Sprintf("%T", root)
+
+	if stack := errorStack(err); stack != "" {
+		fields[key+"_stack"] = stack
+	}
+	return fields
+}
+
+// errorCauses walks the wrapped error chain, following the first error of
+// multi-errors (errors.Join) and including the rest as causes
+func errorCauses(err error) []error {
+	var causes []error
+	for err != nil {
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			wrapped := e.Unwrap()
+			if len(wrapped) == 0 {
+				return causes
+			}
+			causes = append(causes, wrapped...)
+			err = wrapped[0]
+			continue
+		}
+		err = errors.Unwrap(err)
+		if err != nil {
+			causes = append(causes, err)
+		}
+	}
+	return causes
+}
+
+// causeMessages returns the messages of errs
+func causeMessages(errs []error) []string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return msgs
+}
+
+// errorStack returns the innermost pkg/errors-style stack trace in the chain,
+// detected by a StackTrace() method whose result formats frames with %+v
+func errorStack(err error) string {
+	stack := ""
+	for _, e := range append([]error{err}, errorCauses(err)...) {
+		m := reflect.ValueOf(e).MethodByName("StackTrace")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+		stack = fmt.Sprintf("%+v", m.Call(nil)[0].Interface())
+	}
+	return stack
+}
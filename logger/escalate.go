@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EscalationConfig configures an Escalator
+type EscalationConfig struct {
+	Pattern   string        // Glob pattern of the named loggers watched, as for SetLevelFor ("" = all)
+	Threshold int           // Error entries within Window that trigger escalation (default: 10)
+	Window    time.Duration // Window over which errors are counted (default: 1m)
+	Level     string        // Level set while escalated (default: debug)
+	Duration  time.Duration // Time before the previous level is restored (default: 2m)
+
+	// Logger logs every escalation and restoration at warn level (default:
+	// the default logger, see L)
+	Logger *Logger
+
+	// OnEscalate and OnRestore, if set, are called after a logger's level is
+	// raised and after it is restored
+	OnEscalate func(name string, level zapcore.Level, until time.Time)
+	OnRestore  func(name string, level zapcore.Level)
+}
+
+// Escalator raises the verbosity of a named logger for a while when its
+// error rate crosses a threshold, then restores its level, so incidents are
+// logged with full context without permanent debug logging. Register its Hook
+// with the logger:
+//
+//	esc := logger.NewEscalator(logger.EscalationConfig{Threshold: 20, Duration: 5 * time.Minute})
+//	base := logger.NewLogger(logger.WithHooks(esc.Hook))
+//
+// Only named loggers (see Named) are escalated.
+type Escalator struct {
+	config EscalationConfig
+	level  zapcore.Level
+
+	mu        sync.Mutex
+	counts    map[string]*errorWindow
+	escalated map[string]*escalation
+	stopped   bool
+}
+
+// errorWindow counts the errors of a logger in the current window
+type errorWindow struct {
+	start time.Time
+	count int
+}
+
+// escalation is a raised level and what to restore
+type escalation struct {
+	previous zapcore.Level
+	until    time.Time
+	timer    *time.Timer
+}
+
+// NewEscalator returns an escalator configured by cfg. An invalid level falls
+// back to debug.
+func NewEscalator(cfg EscalationConfig) *Escalator {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 10
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = 2 * time.Minute
+	}
+	level := zapcore.DebugLevel
+	if lvl, err := ParseLevel(cfg.Level); err == nil && cfg.Level != "" {
+		level = lvl
+	}
+	return &Escalator{
+		config:    cfg,
+		level:     level,
+		counts:    make(map[string]*errorWindow),
+		escalated: make(map[string]*escalation),
+	}
+}
+
+// Hook counts the error entries of named loggers, escalating a logger when it
+// crosses the threshold
+func (e *Escalator) Hook(ent zapcore.Entry) error {
+	if ent.Level < zapcore.ErrorLevel || ent.Level == AuditLevel || ent.LoggerName == "" {
+		return nil
+	}
+	if e.config.Pattern != "" && !matchName(e.config.Pattern, ent.LoggerName) {
+		return nil
+	}
+
+	e.mu.Lock()
+	if e.stopped || e.escalated[ent.LoggerName] != nil {
+		e.mu.Unlock()
+		return nil
+	}
+	w := e.counts[ent.LoggerName]
+	if w == nil || ent.Time.Sub(w.start) >= e.config.Window {
+		w = &errorWindow{start: ent.Time}
+		e.counts[ent.LoggerName] = w
+	}
+	w.count++
+	if w.count < e.config.Threshold {
+		e.mu.Unlock()
+		return nil
+	}
+	delete(e.counts, ent.LoggerName)
+	esc := e.escalate(ent.LoggerName)
+	e.mu.Unlock()
+
+	if esc != nil {
+		e.notifyEscalate(ent.LoggerName, esc.until)
+	}
+	return nil
+}
+
+// escalate raises the level of the named logger, returning nil if it is
+// already at least as verbose. The caller holds e.mu.
+func (e *Escalator) escalate(name string) *escalation {
+	lvl := namedLevel(name, e.level)
+	previous := lvl.Level()
+	if previous <= e.level {
+		return nil
+	}
+	lvl.SetLevel(e.level)
+	esc := &escalation{previous: previous, until: time.Now().Add(e.config.Duration)}
+	esc.timer = time.AfterFunc(e.config.Duration, func() { e.restore(name, esc) })
+	e.escalated[name] = esc
+	return esc
+}
+
+// restore sets the previous level of the named logger back, unless its level
+// was changed while escalated
+func (e *Escalator) restore(name string, esc *escalation) {
+	e.mu.Lock()
+	if e.escalated[name] != esc {
+		e.mu.Unlock()
+		return
+	}
+	delete(e.escalated, name)
+	lvl := namedLevel(name, esc.previous)
+	restored := lvl.Level() == e.level
+	if restored {
+		lvl.SetLevel(esc.previous)
+	}
+	e.mu.Unlock()
+
+	if restored {
+		e.notifyRestore(name, esc.previous)
+	}
+}
+
+// notifyEscalate logs the escalation and runs the OnEscalate hook
+func (e *Escalator) notifyEscalate(name string, until time.Time) {
+	e.logger().Warnw("log level escalated after error burst",
+		"logger", name, "level", LevelString(e.level), "until", until,
+		"threshold", e.config.Threshold, "window", e.config.Window)
+	if e.config.OnEscalate != nil {
+		e.config.OnEscalate(name, e.level, until)
+	}
+}
+
+// notifyRestore logs the restoration and runs the OnRestore hook
+func (e *Escalator) notifyRestore(name string, level zapcore.Level) {
+	e.logger().Warnw("log level restored", "logger", name, "level", LevelString(level))
+	if e.config.OnRestore != nil {
+		e.config.OnRestore(name, level)
+	}
+}
+
+// logger returns the logger of the escalation entries
+func (e *Escalator) logger() *Logger {
+	if e.config.Logger != nil {
+		return e.config.Logger
+	}
+	return L()
+}
+
+// Escalated returns the names of the escalated loggers and when their levels
+// will be restored
+func (e *Escalator) Escalated() map[string]time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	escalated := make(map[string]time.Time, len(e.escalated))
+	for name, esc := range e.escalated {
+		escalated[name] = esc.until
+	}
+	return escalated
+}
+
+// Stop restores every escalated logger now and stops escalating
+func (e *Escalator) Stop() {
+	e.mu.Lock()
+	e.stopped = true
+	pending := e.escalated
+	e.escalated = make(map[string]*escalation)
+	e.mu.Unlock()
+
+	for name, esc := range pending {
+		esc.timer.Stop()
+		lvl := namedLevel(name, esc.previous)
+		if lvl.Level() == e.level {
+			lvl.SetLevel(esc.previous)
+			e.notifyRestore(name, esc.previous)
+		}
+	}
+}
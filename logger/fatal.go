@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultFlushTimeout bounds the sink flush before a fatal exit
+const defaultFlushTimeout = 5 * time.Second
+
+// WithExitFunc replaces os.Exit after fatal entries, e.g. so tests can
+// intercept Fatal. If fn returns, execution continues after the Fatal call.
+func WithExitFunc(fn func(code int)) Option {
+	return func(o *options) {
+		o.exitFunc = fn
+	}
+}
+
+// WithFlushTimeout bounds how long panic and fatal entries wait for sinks to
+// flush before the panic unwinds or the process exits (default: 5s)
+func WithFlushTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.flushTimeout = d
+		}
+	}
+}
+
+// DPanic logs at dpanic level, panicking in development mode
+func (l *Logger) DPanic(args ...interface{}) {
+	l.sugar.DPanic(args...)
+}
+
+// DPanicf logs a formatted message at dpanic level, panicking in development mode
+func (l *Logger) DPanicf(template string, args ...interface{}) {
+	l.sugar.DPanicf(template, args...)
+}
+
+// DPanicw logs a message with key-value pairs at dpanic level, panicking in
+// development mode
+func (l *Logger) DPanicw(msg string, args ...interface{}) {
+	l.sugar.DPanicw(msg, args...)
+}
+
+// fatalHook exits after a fatal entry has been written (and flushed by
+// flushCore)
+type fatalHook struct {
+	exit func(code int)
+}
+
+// newFatalHook creates a fatal hook calling exit, or os.Exit if nil
+func newFatalHook(exit func(code int)) *fatalHook {
+	if exit == nil {
+		exit = os.Exit
+	}
+	return &fatalHook{exit: exit}
+}
+
+// OnWrite exits with status 1
+func (h *fatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	h.exit(1)
+}
+
+// flushCore flushes the logger's sinks and every live BufferedSink after a
+// dpanic, panic or fatal entry has been written, before the stack unwinds
+// or the process exits, so crash logs and the entries buffered before them
+// reach remote outputs
+type flushCore struct {
+	zapcore.Core
+	sinks   []sink.Sink
+	timeout time.Duration
+}
+
+// newFlushCore wraps core to flush sinks on panic and fatal entries
+func newFlushCore(core zapcore.Core, sinks []sink.Sink, timeout time.Duration) *flushCore {
+	if timeout <= 0 {
+		timeout = defaultFlushTimeout
+	}
+	return &flushCore{Core: core, sinks: sinks, timeout: timeout}
+}
+
+// With adds structured context to the wrapped core
+func (c *flushCore) With(fields []zapcore.Field) zapcore.Core {
+	return &flushCore{Core: c.Core.With(fields), sinks: c.sinks, timeout: c.timeout}
+}
+
+// Check defers to the wrapped core, adding this core after it for panic and
+// fatal entries so Write runs once the outputs have written them
+func (c *flushCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < zapcore.DPanicLevel || ent.Level > zapcore.FatalLevel {
+		return c.Core.Check(ent, ce)
+	}
+	if ce = c.Core.Check(ent, ce); ce != nil {
+		ce = ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write flushes the sinks within the timeout; the entry itself was written
+// by the wrapped core
+func (c *flushCore) Write(zapcore.Entry, []zapcore.Field) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	return flushSinks(ctx, c.sinks)
+}
+
+// flushSinks flushes sinks and every live BufferedSink until ctx expires
+func flushSinks(ctx context.Context, sinks []sink.Sink) error {
+	errs := []error{sink.FlushAll(ctx)}
+	for _, s := range sinks {
+		errs = append(errs, s.Flush(ctx))
+	}
+	return errors.Join(errs...)
+}
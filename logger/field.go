@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Field is a strongly typed key-value pair for the *t methods, which log
+// without the reflection and boxing of the variadic key-value methods
+type Field = zap.Field
+
+// Str returns a string field
+func Str(key, val string) Field {
+	return zap.String(key, val)
+}
+
+// Int returns an int field
+func Int(key string, val int) Field {
+	return zap.Int(key, val)
+}
+
+// Int64 returns an int64 field
+func Int64(key string, val int64) Field {
+	return zap.Int64(key, val)
+}
+
+// Float64 returns a float64 field
+func Float64(key string, val float64) Field {
+	return zap.Float64(key, val)
+}
+
+// Bool returns a bool field
+func Bool(key string, val bool) Field {
+	return zap.Bool(key, val)
+}
+
+// Dur returns a time.Duration field
+func Dur(key string, val time.Duration) Field {
+	return zap.Duration(key, val)
+}
+
+// Time returns a time.Time field
+func Time(key string, val time.Time) Field {
+	return zap.Time(key, val)
+}
+
+// Err returns an "error" field, expanded like the variadic methods' errors
+func Err(err error) Field {
+	return zap.Error(err)
+}
+
+// Any returns a field for val, choosing the typed encoding when it can
+func Any(key string, val any) Field {
+	return zap.Any(key, val)
+}
+
+// WithFields returns a child logger adding fields to every entry
+func (l *Logger) WithFields(fields ...Field) *Logger {
+	return newLogger(l.SugaredLogger.Desugar().With(fields...).Sugar(), l.cores, l.sampler, l.level, l.name)
+}
+
+// Tracet logs a message with typed fields at trace level
+func (l *Logger) Tracet(msg string, fields ...Field) {
+	l.typed.Log(TraceLevel, msg, fields...)
+}
+
+// Debugt logs a message with typed fields at debug level
+func (l *Logger) Debugt(msg string, fields ...Field) {
+	l.typed.Debug(msg, fields...)
+}
+
+// Infot logs a message with typed fields at info level
+func (l *Logger) Infot(msg string, fields ...Field) {
+	l.typed.Info(msg, fields...)
+}
+
+// Warnt logs a message with typed fields at warn level
+func (l *Logger) Warnt(msg string, fields ...Field) {
+	l.typed.Warn(msg, fields...)
+}
+
+// Errort logs a message with typed fields at error level
+func (l *Logger) Errort(msg string, fields ...Field) {
+	l.typed.Error(msg, fields...)
+}
+
+// Fatalt logs a message with typed fields at fatal level, then exits
+func (l *Logger) Fatalt(msg string, fields ...Field) {
+	l.typed.Fatal(msg, fields...)
+}
+
+// Auditt logs a message with typed fields at audit level
+func (l *Logger) Auditt(msg string, fields ...Field) {
+	l.typed.Log(AuditLevel, msg, fields...)
+}
+
+// Logt logs a message with typed fields at the given level
+func (l *Logger) Logt(lvl zapcore.Level, msg string, fields ...Field) {
+	l.typed.Log(lvl, msg, fields...)
+}
@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldSchemaViolations lists the fields logged with an unexpected type
+const FieldSchemaViolations = "schema_violations"
+
+// FieldType is the expected type of a field's values
+type FieldType string
+
+// Field types
+const (
+	TypeString   FieldType = "string"
+	TypeInt      FieldType = "int"
+	TypeFloat    FieldType = "float"
+	TypeBool     FieldType = "bool"
+	TypeDuration FieldType = "duration"
+	TypeTime     FieldType = "time"
+)
+
+// ParseFieldType parses a field type name
+func ParseFieldType(s string) (FieldType, error) {
+	switch t := FieldType(s); t {
+	case TypeString, TypeInt, TypeFloat, TypeBool, TypeDuration, TypeTime:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown field type %q", s)
+}
+
+// FieldTypesConfig configures field type enforcement
+type FieldTypesConfig struct {
+	Types  map[string]FieldType // Expected type by field key, e.g. "user_id": TypeString
+	Coerce bool                 // Convert mismatched values to the expected type when possible
+}
+
+// WithFieldTypes checks the type of the registered fields before entries
+// reach any output, so one call site logging user_id as an int cannot break
+// queries and index mappings expecting a string. Mismatches are listed in
+// "schema_violations" as "key: got, want" and, with Coerce, converted when
+// the value allows it (int to string, "42" to int, ...); values that cannot
+// be converted are kept as logged. Integers are accepted as floats.
+func WithFieldTypes(cfg FieldTypesConfig) Option {
+	return func(o *options) {
+		o.fieldTypes = &cfg
+	}
+}
+
+// wrap returns core with the field types enforced
+func (t *FieldTypesConfig) wrap(core zapcore.Core) zapcore.Core {
+	return &fieldTypesCore{Core: core, types: t}
+}
+
+// check returns f, converted if needed and possible, and the violation it
+// represents ("" when f has the expected type)
+func (t *FieldTypesConfig) check(f zapcore.Field) (zapcore.Field, string) {
+	want, ok := t.Types[f.Key]
+	if !ok || f.Type == zapcore.SkipType {
+		return f, ""
+	}
+	got := fieldTypeOf(f)
+	if got == want || got == TypeInt && want == TypeFloat {
+		return f, ""
+	}
+	violation := fmt.Sprintf("%s: %s, want %s", f.Key, got, want)
+	if t.Coerce {
+		if coerced, ok := coerceField(f, want); ok {
+			return coerced, violation
+		}
+	}
+	return f, violation
+}
+
+// fieldTypeOf returns the type of a field's value, or the zap type name for
+// values matching none of the field types
+func fieldTypeOf(f zapcore.Field) FieldType {
+	switch f.Type {
+	case zapcore.StringType, zapcore.ByteStringType, zapcore.StringerType:
+		return TypeString
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return TypeInt
+	case zapcore.Float64Type, zapcore.Float32Type:
+		return TypeFloat
+	case zapcore.BoolType:
+		return TypeBool
+	case zapcore.DurationType:
+		return TypeDuration
+	case zapcore.TimeType, zapcore.TimeFullType:
+		return TypeTime
+	case zapcore.ErrorType:
+		return "error"
+	case zapcore.ArrayMarshalerType:
+		return "array"
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		return "object"
+	}
+	return FieldType(fmt.Sprintf("%T", fieldValue(f)))
+}
+
+// coerceField converts f to the wanted type, reporting whether it could
+func coerceField(f zapcore.Field, want FieldType) (zapcore.Field, bool) {
+	v := fieldValue(f)
+	switch want {
+	case TypeString:
+		return zap.String(f.Key, fmt.Sprint(v)), true
+	case TypeInt:
+		switch v := v.(type) {
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return zap.Int64(f.Key, n), true
+			}
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+				return zap.Int64(f.Key, int64(v)), true
+			}
+		case float32:
+			if n := float64(v); n == math.Trunc(n) && math.Abs(n) < 1<<63 {
+				return zap.Int64(f.Key, int64(n)), true
+			}
+		}
+	case TypeFloat:
+		if s, ok := v.(string); ok {
+			if n, err := strconv.ParseFloat(s, 64); err == nil {
+				return zap.Float64(f.Key, n), true
+			}
+		}
+	case TypeBool:
+		if s, ok := v.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return zap.Bool(f.Key, b), true
+			}
+		}
+	case TypeDuration:
+		if s, ok := v.(string); ok {
+			if d, err := time.ParseDuration(s); err == nil {
+				return zap.Duration(f.Key, d), true
+			}
+		}
+	case TypeTime:
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return zap.Time(f.Key, t), true
+			}
+		}
+	}
+	return f, false
+}
+
+// fieldTypesCore checks field types before passing entries to the wrapped
+// core
+type fieldTypesCore struct {
+	zapcore.Core
+	types      *FieldTypesConfig
+	violations []string // Violations of the context fields
+}
+
+// enforce checks fields, returning them (converted with Coerce) and the
+// violations appended to violations
+func (c *fieldTypesCore) enforce(fields []zapcore.Field, violations []string) ([]zapcore.Field, []string) {
+	var out []zapcore.Field
+	for i, f := range fields {
+		checked, violation := c.types.check(f)
+		if violation == "" {
+			continue
+		}
+		violations = append(violations, violation)
+		if c.types.Coerce {
+			if out == nil {
+				out = make([]zapcore.Field, len(fields))
+				copy(out, fields)
+			}
+			out[i] = checked
+		}
+	}
+	if out == nil {
+		return fields, violations
+	}
+	return out, violations
+}
+
+// With checks the context fields once, when they are added
+func (c *fieldTypesCore) With(fields []zapcore.Field) zapcore.Core {
+	checked, violations := c.enforce(fields, c.violations[:len(c.violations):len(c.violations)])
+	return &fieldTypesCore{Core: c.Core.With(checked), types: c.types, violations: violations}
+}
+
+// Check adds this core (not the wrapped one) so that Write checks the fields
+func (c *fieldTypesCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write checks the fields, then writes to the wrapped core
+func (c *fieldTypesCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	checked, violations := c.enforce(fields, c.violations[:len(c.violations):len(c.violations)])
+	if len(violations) > 0 {
+		checked = append(checked[:len(checked):len(checked)], zap.Strings(FieldSchemaViolations, violations))
+	}
+	return c.Core.Write(ent, checked)
+}
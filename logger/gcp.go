@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"os"
+	"slices"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Google Cloud Logging special fields
+const (
+	GCPTraceKey          = "logging.googleapis.com/trace"
+	GCPSpanKey           = "logging.googleapis.com/spanId"
+	GCPTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	GCPSourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+// WithGCP lays out JSON output as Google Cloud structured logs (severity,
+// time, message, sourceLocation and trace fields) and writes the console
+// output as such JSON to stdout, for GKE and Cloud Run services relying on
+// the built-in logging agent. projectID qualifies trace IDs so entries link
+// to Cloud Trace; an empty projectID uses $GOOGLE_CLOUD_PROJECT.
+func WithGCP(projectID string) Option {
+	return func(o *options) {
+		if projectID == "" {
+			projectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+		}
+		o.layout = layoutGCP
+		o.gcpProject = projectID
+	}
+}
+
+// gcpEncoderConfig renames the entry keys of cfg to the Cloud Logging ones
+func gcpEncoderConfig(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	cfg.TimeKey = "time"
+	cfg.LevelKey = "severity"
+	cfg.NameKey = "logger"
+	cfg.MessageKey = "message"
+	cfg.CallerKey = GCPSourceLocationKey
+	cfg.FunctionKey = zapcore.OmitKey // Part of sourceLocation
+	cfg.StacktraceKey = "stack_trace"
+	cfg.EncodeLevel = encodeGCPSeverity
+	cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	cfg.EncodeCaller = encodeGCPSourceLocation
+	return cfg
+}
+
+// gcpSeverities maps levels to Cloud Logging severities
+var gcpSeverities = map[zapcore.Level]string{
+	TraceLevel:          "DEBUG",
+	zapcore.DebugLevel:  "DEBUG",
+	zapcore.InfoLevel:   "INFO",
+	zapcore.WarnLevel:   "WARNING",
+	zapcore.ErrorLevel:  "ERROR",
+	zapcore.DPanicLevel: "CRITICAL",
+	zapcore.PanicLevel:  "ALERT",
+	zapcore.FatalLevel:  "EMERGENCY",
+	AuditLevel:          "NOTICE",
+}
+
+// encodeGCPSeverity encodes a level as a Cloud Logging severity
+func encodeGCPSeverity(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	severity, ok := gcpSeverities[l]
+	if !ok {
+		severity = "DEFAULT"
+	}
+	enc.AppendString(severity)
+}
+
+// encodeGCPSourceLocation encodes the caller as a sourceLocation object
+func encodeGCPSourceLocation(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	ae, ok := enc.(zapcore.ArrayEncoder)
+	if !ok {
+		enc.AppendString(caller.TrimmedPath())
+		return
+	}
+	_ = ae.AppendObject(zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+		oe.AddString("file", caller.File)
+		oe.AddInt("line", caller.Line)
+		if caller.Function != "" {
+			oe.AddString("function", caller.Function)
+		}
+		return nil
+	}))
+}
+
+// gcpCore moves trace correlation fields to the Cloud Logging trace fields
+// before they reach an encoder output
+type gcpCore struct {
+	zapcore.Core
+	project string
+}
+
+// With adds structured context with Cloud Logging trace fields
+func (c *gcpCore) With(fields []zapcore.Field) zapcore.Core {
+	return &gcpCore{Core: c.Core.With(c.fields(fields)), project: c.project}
+}
+
+// Check defers to the wrapped core's level, adding this core
+func (c *gcpCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write converts the trace fields, then writes to the wrapped core
+func (c *gcpCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.fields(fields))
+}
+
+// fields returns fields with trace_id, span_id and trace_flags converted,
+// copying the slice only if one is present
+func (c *gcpCore) fields(fields []zapcore.Field) []zapcore.Field {
+	out := fields
+	copied := false
+	for i, f := range fields {
+		if f.Type != zapcore.StringType || (f.Key != sink.FieldTraceID && f.Key != sink.FieldSpanID && f.Key != sink.FieldTraceFlags) {
+			continue
+		}
+		if !copied {
+			out, copied = slices.Clone(fields), true
+		}
+		switch f.Key {
+		case sink.FieldSpanID:
+			out[i].Key = GCPSpanKey
+			continue
+		case sink.FieldTraceFlags:
+			flags, _ := sink.ParseTraceFlags(f.String)
+			out[i] = zap.Bool(GCPTraceSampledKey, flags&1 == 1)
+			continue
+		}
+		out[i].Key = GCPTraceKey
+		if c.project != "" {
+			out[i].String = "projects/" + c.project + "/traces/" + f.String
+		}
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+// Package ginzlog provides Gin middleware for access logging and panic
+// recovery built on the httpzlog request-scoped logger conventions.
+// It is a separate module, so Gin only enters builds importing it.
+package ginzlog
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hsdfat/go-zlog/logger"
+	"github.com/hsdfat/go-zlog/logger/httpzlog"
+)
+
+// Middleware propagates or generates an X-Request-ID, stores a request-scoped
+// logger in the request context (see logger.FromContext) and logs one access
+// entry per request. Paths in skipPaths are not logged.
+func Middleware(l *logger.Logger, skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, p := range skipPaths {
+		skip[p] = true
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := httpzlog.RequestID(c.GetHeader(httpzlog.DefaultRequestIDHeader))
+		c.Header(httpzlog.DefaultRequestIDHeader, requestID)
+
+		reqLogger := httpzlog.RequestLogger(l, requestID, c.Request.Method, c.FullPath())
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), reqLogger))
+
+		c.Next()
+
+		if skip[c.Request.URL.Path] {
+			return
+		}
+		httpzlog.LogAccess(reqLogger, "http request", httpzlog.AccessInfo{
+			Status:    c.Writer.Status(),
+			Bytes:     max(c.Writer.Size(), 0), // -1 when nothing was written
+			Latency:   time.Since(start),
+			RemoteIP:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+	}
+}
+
+// Recovery recovers from panics in later handlers, logs them with a stack
+// trace through the request-scoped logger and responds with 500
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.FromContext(c.Request.Context()).Errorw("panic recovered",
+					"panic", r,
+					"stack", string(debug.Stack()),
+				)
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+		}()
+		c.Next()
+	}
+}
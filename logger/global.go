@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// global is the default logger returned by L, swapped by ReplaceGlobals
+var (
+	global    atomic.Pointer[Logger]
+	globalLog atomic.Pointer[Logger] // global skipping the frame of defaultLogger's methods
+	globalMu  sync.Mutex             // Serializes ReplaceGlobals and restores
+)
+
+func init() {
+	setGlobal(NewLogger(WithAtomicLevel(level)))
+}
+
+// L returns the default logger. It is safe to call concurrently with
+// ReplaceGlobals.
+func L() *Logger {
+	return global.Load()
+}
+
+// ReplaceGlobals makes l the default logger returned by L, FromContext,
+// Named and Log, and installs it as zap's global logger (zap.L and zap.S).
+// The returned function restores the previous loggers, e.g.
+//
+//	defer logger.ReplaceGlobals(testLogger)()
+func ReplaceGlobals(l *Logger) (restore func()) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	prev := setGlobal(l)
+	restoreZap := zap.ReplaceGlobals(l.SugaredLogger.Desugar())
+	return func() {
+		globalMu.Lock()
+		defer globalMu.Unlock()
+		setGlobal(prev)
+		restoreZap()
+	}
+}
+
+// setGlobal makes l the default logger, returning the previous one
+func setGlobal(l *Logger) (prev *Logger) {
+	globalLog.Store(newLogger(l.SugaredLogger.WithOptions(zap.AddCallerSkip(1)), l.cores, l.sampler, l.level, l.name))
+	return global.Swap(l)
+}
+
+// defaultLogger is the value of Log. It forwards every call to the default
+// logger, so Log follows ReplaceGlobals without being written.
+type defaultLogger struct{}
+
+func (defaultLogger) Infow(msg string, args ...interface{})  { globalLog.Load().Infow(msg, args...) }
+func (defaultLogger) Warnw(msg string, args ...interface{})  { globalLog.Load().Warnw(msg, args...) }
+func (defaultLogger) Errorw(msg string, args ...interface{}) { globalLog.Load().Errorw(msg, args...) }
+func (defaultLogger) Debugw(msg string, args ...interface{}) { globalLog.Load().Debugw(msg, args...) }
+func (defaultLogger) Fatalw(msg string, args ...interface{}) { globalLog.Load().Fatalw(msg, args...) }
+func (defaultLogger) Tracew(msg string, args ...interface{}) { globalLog.Load().Tracew(msg, args...) }
+func (defaultLogger) Auditw(msg string, args ...interface{}) { globalLog.Load().Auditw(msg, args...) }
+
+func (defaultLogger) InfowCtx(ctx context.Context, msg string, args ...interface{}) {
+	globalLog.Load().InfowCtx(ctx, msg, args...)
+}
+func (defaultLogger) WarnwCtx(ctx context.Context, msg string, args ...interface{}) {
+	globalLog.Load().WarnwCtx(ctx, msg, args...)
+}
+func (defaultLogger) ErrorwCtx(ctx context.Context, msg string, args ...interface{}) {
+	globalLog.Load().ErrorwCtx(ctx, msg, args...)
+}
+func (defaultLogger) DebugwCtx(ctx context.Context, msg string, args ...interface{}) {
+	globalLog.Load().DebugwCtx(ctx, msg, args...)
+}
+func (defaultLogger) FatalwCtx(ctx context.Context, msg string, args ...interface{}) {
+	globalLog.Load().FatalwCtx(ctx, msg, args...)
+}
+
+func (defaultLogger) Infof(template string, args ...interface{}) {
+	globalLog.Load().Infof(template, args...)
+}
+func (defaultLogger) Debugf(template string, args ...interface{}) {
+	globalLog.Load().Debugf(template, args...)
+}
+func (defaultLogger) Errorf(template string, args ...interface{}) {
+	globalLog.Load().Errorf(template, args...)
+}
+func (defaultLogger) Warnf(template string, args ...interface{}) {
+	globalLog.Load().Warnf(template, args...)
+}
+func (defaultLogger) Fatalf(template string, args ...interface{}) {
+	globalLog.Load().Fatalf(template, args...)
+}
+func (defaultLogger) Tracef(template string, args ...interface{}) {
+	globalLog.Load().Tracef(template, args...)
+}
+func (defaultLogger) Auditf(template string, args ...interface{}) {
+	globalLog.Load().Auditf(template, args...)
+}
+
+func (defaultLogger) Info(args ...interface{})  { globalLog.Load().Info(args...) }
+func (defaultLogger) Debug(args ...interface{}) { globalLog.Load().Debug(args...) }
+func (defaultLogger) Error(args ...interface{}) { globalLog.Load().Error(args...) }
+func (defaultLogger) Warn(args ...interface{})  { globalLog.Load().Warn(args...) }
+func (defaultLogger) Fatal(args ...interface{}) { globalLog.Load().Fatal(args...) }
+func (defaultLogger) Trace(args ...interface{}) { globalLog.Load().Trace(args...) }
+func (defaultLogger) Audit(args ...interface{}) { globalLog.Load().Audit(args...) }
+
+func (defaultLogger) Infoln(args ...interface{})  { globalLog.Load().Infoln(args...) }
+func (defaultLogger) Debugln(args ...interface{}) { globalLog.Load().Debugln(args...) }
+func (defaultLogger) Errorln(args ...interface{}) { globalLog.Load().Errorln(args...) }
+func (defaultLogger) Warnln(args ...interface{})  { globalLog.Load().Warnln(args...) }
+func (defaultLogger) Fatalln(args ...interface{}) { globalLog.Load().Fatalln(args...) }
+
+// With and Named return children of the default logger itself, whose calls
+// have no extra frame to skip
+func (defaultLogger) With(args ...any) LoggerI  { return L().With(args...) }
+func (defaultLogger) Named(name string) LoggerI { return L().Named(name) }
@@ -0,0 +1,24 @@
+module github.com/hsdfat/go-zlog/logger/gormzlog
+
+go 1.23
+
+require (
+	github.com/hsdfat/go-zlog v0.0.0
+	gorm.io/gorm v1.25.12
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/ugorji/go/codec v1.2.12 // indirect
+	go.opentelemetry.io/otel v1.31.0 // indirect
+	go.opentelemetry.io/otel/trace v1.31.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+)
+
+replace github.com/hsdfat/go-zlog => ../..
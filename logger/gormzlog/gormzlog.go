@@ -0,0 +1,102 @@
+// Package gormzlog implements GORM's logger.Interface on top of a go-zlog
+// Logger, with slow-query detection. It is a separate module, so GORM only
+// enters builds importing it.
+package gormzlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// Config configures the GORM logger
+type Config struct {
+	LogLevel                  gormlogger.LogLevel // GORM log level (default: Warn)
+	SlowThreshold             time.Duration       // Queries slower than this are logged at warn (0 = disabled)
+	IgnoreRecordNotFoundError bool                // Don't log gorm.ErrRecordNotFound as an error
+}
+
+// Logger implements gorm.io/gorm/logger.Interface
+type Logger struct {
+	logger *logger.Logger
+	config Config
+}
+
+// New returns a GORM logger writing through l under the "gorm" logger name
+func New(l *logger.Logger, config Config) *Logger {
+	if config.LogLevel == 0 {
+		config.LogLevel = gormlogger.Warn
+	}
+	return &Logger{logger: l.Named("gorm").(*logger.Logger), config: config}
+}
+
+// LogMode returns a copy of the logger with the given GORM log level
+func (g *Logger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *g
+	clone.config.LogLevel = level
+	return &clone
+}
+
+// Info logs GORM info messages
+func (g *Logger) Info(ctx context.Context, msg string, args ...any) {
+	if g.config.LogLevel >= gormlogger.Info {
+		g.logger.InfowCtx(ctx, fmt.Sprintf(msg, args...), "sql.source", utils.FileWithLineNum())
+	}
+}
+
+// Warn logs GORM warnings
+func (g *Logger) Warn(ctx context.Context, msg string, args ...any) {
+	if g.config.LogLevel >= gormlogger.Warn {
+		g.logger.WarnwCtx(ctx, fmt.Sprintf(msg, args...), "sql.source", utils.FileWithLineNum())
+	}
+}
+
+// Error logs GORM errors
+func (g *Logger) Error(ctx context.Context, msg string, args ...any) {
+	if g.config.LogLevel >= gormlogger.Error {
+		g.logger.ErrorwCtx(ctx, fmt.Sprintf(msg, args...), "sql.source", utils.FileWithLineNum())
+	}
+}
+
+// Trace logs a completed SQL statement with its rows and duration. Failed
+// statements are logged at error, except context cancelation which is
+// logged at warn; statements over SlowThreshold are logged at warn with
+// slow_query=true.
+func (g *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if g.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	fields := func() []any {
+		sql, rows := fc()
+		return []any{
+			"sql", sql,
+			"sql.rows", rows,
+			"sql.duration", elapsed,
+			"sql.source", utils.FileWithLineNum(),
+		}
+	}
+
+	switch {
+	case err != nil && g.config.LogLevel >= gormlogger.Error &&
+		!(g.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound)):
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			g.logger.WarnwCtx(ctx, "sql canceled", append(fields(), "error", err)...)
+			return
+		}
+		g.logger.ErrorwCtx(ctx, "sql error", append(fields(), "error", err)...)
+
+	case g.config.SlowThreshold > 0 && elapsed > g.config.SlowThreshold && g.config.LogLevel >= gormlogger.Warn:
+		g.logger.WarnwCtx(ctx, "slow sql", append(fields(), "slow_query", true, "sql.threshold", g.config.SlowThreshold)...)
+
+	case g.config.LogLevel >= gormlogger.Info:
+		g.logger.InfowCtx(ctx, "sql", fields()...)
+	}
+}
@@ -0,0 +1,74 @@
+package grpczlog
+
+import (
+	"fmt"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+)
+
+// grpcLogger implements grpclog.LoggerV2 on top of a Logger
+type grpcLogger struct {
+	logger    *zap.SugaredLogger
+	infoLevel zapcore.Level
+	verbosity int
+}
+
+// NewGRPCLogger returns a grpclog.LoggerV2 that writes gRPC's internal logs
+// through l. Info messages are logged at infoLevel (usually "debug"),
+// warnings and errors at their own levels. V(n) reports true for n up to
+// verbosity. Install it with grpclog.SetLoggerV2.
+func NewGRPCLogger(l *logger.Logger, infoLevel string, verbosity int) grpclog.LoggerV2 {
+	lvl, err := logger.ParseLevel(infoLevel)
+	if err != nil {
+		lvl = zapcore.DebugLevel
+	}
+	named := l.Named("grpc").(*logger.Logger)
+	return &grpcLogger{
+		logger:    named.Desugar().WithOptions(zap.AddCallerSkip(2)).Sugar(),
+		infoLevel: lvl,
+		verbosity: verbosity,
+	}
+}
+
+func (g *grpcLogger) Info(args ...any)   { g.log(g.infoLevel, fmt.Sprint(args...)) }
+func (g *grpcLogger) Infoln(args ...any) { g.log(g.infoLevel, sprintln(args)) }
+func (g *grpcLogger) Infof(format string, args ...any) {
+	g.log(g.infoLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Warning(args ...any)   { g.log(zapcore.WarnLevel, fmt.Sprint(args...)) }
+func (g *grpcLogger) Warningln(args ...any) { g.log(zapcore.WarnLevel, sprintln(args)) }
+func (g *grpcLogger) Warningf(format string, args ...any) {
+	g.log(zapcore.WarnLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Error(args ...any)   { g.log(zapcore.ErrorLevel, fmt.Sprint(args...)) }
+func (g *grpcLogger) Errorln(args ...any) { g.log(zapcore.ErrorLevel, sprintln(args)) }
+func (g *grpcLogger) Errorf(format string, args ...any) {
+	g.log(zapcore.ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Fatal(args ...any)   { g.log(zapcore.FatalLevel, fmt.Sprint(args...)) }
+func (g *grpcLogger) Fatalln(args ...any) { g.log(zapcore.FatalLevel, sprintln(args)) }
+func (g *grpcLogger) Fatalf(format string, args ...any) {
+	g.log(zapcore.FatalLevel, fmt.Sprintf(format, args...))
+}
+
+// V reports whether verbosity level l is enabled
+func (g *grpcLogger) V(l int) bool {
+	return l <= g.verbosity
+}
+
+// log writes msg at lvl
+func (g *grpcLogger) log(lvl zapcore.Level, msg string) {
+	g.logger.Logw(lvl, msg)
+}
+
+// sprintln formats args like fmt.Sprintln without the trailing newline
+func sprintln(args []any) string {
+	msg := fmt.Sprintln(args...)
+	return msg[:len(msg)-1]
+}
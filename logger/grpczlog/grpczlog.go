@@ -0,0 +1,245 @@
+// Package grpczlog provides gRPC server and client interceptors that log
+// each call through a go-zlog Logger and inject a request-scoped logger into
+// the call context. It is a separate module, so gRPC only enters builds
+// importing it.
+package grpczlog
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Option configures the interceptors
+type Option func(*options)
+
+// options holds interceptor settings
+type options struct {
+	logPayload bool
+	excluded   map[string]bool
+}
+
+// WithPayloadLogging logs request and response messages as JSON. Payloads may
+// contain sensitive data, so this is off by default.
+func WithPayloadLogging(enabled bool) Option {
+	return func(o *options) {
+		o.logPayload = enabled
+	}
+}
+
+// WithExcludedMethods skips logging for the given full method names (e.g.
+// "/grpc.health.v1.Health/Check"); the request-scoped logger is still injected
+func WithExcludedMethods(methods ...string) Option {
+	return func(o *options) {
+		for _, m := range methods {
+			o.excluded[m] = true
+		}
+	}
+}
+
+// newOptions applies opts to the defaults
+func newOptions(opts []Option) *options {
+	o := &options{excluded: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// UnaryServerInterceptor logs unary calls and injects a request-scoped logger
+func UnaryServerInterceptor(l *logger.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		reqLogger := requestLogger(ctx, l, info.FullMethod)
+		ctx = logger.NewContext(ctx, reqLogger)
+
+		resp, err := handler(ctx, req)
+		if !o.excluded[info.FullMethod] {
+			fields := []any{"grpc.request_size", messageSize(req), "grpc.response_size", messageSize(resp)}
+			if o.logPayload {
+				fields = append(fields, "grpc.request", payload(req), "grpc.response", payload(resp))
+			}
+			logCall(ctx, reqLogger, "finished unary call", start, err, fields)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs streaming calls and injects a request-scoped logger
+func StreamServerInterceptor(l *logger.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		reqLogger := requestLogger(ss.Context(), l, info.FullMethod)
+		wrapped := &serverStream{
+			ServerStream: ss,
+			ctx:          logger.NewContext(ss.Context(), reqLogger),
+			logPayload:   o.logPayload && !o.excluded[info.FullMethod],
+			logger:       reqLogger,
+		}
+
+		err := handler(srv, wrapped)
+		if !o.excluded[info.FullMethod] {
+			fields := []any{
+				"grpc.msgs_received", wrapped.received, "grpc.bytes_received", wrapped.bytesReceived,
+				"grpc.msgs_sent", wrapped.sent, "grpc.bytes_sent", wrapped.bytesSent,
+			}
+			logCall(wrapped.ctx, reqLogger, "finished streaming call", start, err, fields)
+		}
+		return err
+	}
+}
+
+// UnaryClientInterceptor logs outgoing unary calls
+func UnaryClientInterceptor(l *logger.Logger, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		if !o.excluded[method] {
+			callLogger := withCallFields(l, method, cc.Target())
+			fields := []any{"grpc.request_size", messageSize(req), "grpc.response_size", messageSize(reply)}
+			if o.logPayload {
+				fields = append(fields, "grpc.request", payload(req), "grpc.response", payload(reply))
+			}
+			logCall(ctx, callLogger, "finished client unary call", start, err, fields)
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor logs the establishment of outgoing streams
+func StreamClientInterceptor(l *logger.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if !o.excluded[method] {
+			logCall(ctx, withCallFields(l, method, cc.Target()), "started client stream", start, err, nil)
+		}
+		return cs, err
+	}
+}
+
+// serverStream wraps a grpc.ServerStream to carry the request context and
+// count messages
+type serverStream struct {
+	grpc.ServerStream
+	ctx        context.Context
+	logger     *logger.Logger
+	logPayload bool
+
+	received, sent           int
+	bytesReceived, bytesSent int
+}
+
+// Context returns the context carrying the request-scoped logger
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg counts and optionally logs outgoing messages
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+		s.bytesSent += messageSize(m)
+		if s.logPayload {
+			s.logger.DebugwCtx(s.ctx, "sent stream message", "grpc.response", payload(m))
+		}
+	}
+	return err
+}
+
+// RecvMsg counts and optionally logs incoming messages
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+		s.bytesReceived += messageSize(m)
+		if s.logPayload {
+			s.logger.DebugwCtx(s.ctx, "received stream message", "grpc.request", payload(m))
+		}
+	}
+	return err
+}
+
+// requestLogger returns l enriched with the method and peer of a server call
+func requestLogger(ctx context.Context, l *logger.Logger, fullMethod string) *logger.Logger {
+	addr := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	return withCallFields(l, fullMethod, addr)
+}
+
+// withCallFields returns l with service, method and peer fields
+func withCallFields(l *logger.Logger, fullMethod, peerAddr string) *logger.Logger {
+	service, method := path.Split(fullMethod)
+	child, _ := l.With(
+		"grpc.service", strings.Trim(service, "/"),
+		"grpc.method", method,
+		"peer.address", peerAddr,
+	).(*logger.Logger)
+	return child
+}
+
+// logCall logs the outcome of a call at a level derived from its status code
+func logCall(ctx context.Context, l *logger.Logger, msg string, start time.Time, err error, fields []any) {
+	code := status.Code(err)
+	fields = append(fields, "grpc.code", code.String(), "grpc.duration", time.Since(start))
+	if err != nil {
+		fields = append(fields, "error", err)
+	}
+
+	switch codeLevel(code) {
+	case "error":
+		l.ErrorwCtx(ctx, msg, fields...)
+	case "warn":
+		l.WarnwCtx(ctx, msg, fields...)
+	default:
+		l.InfowCtx(ctx, msg, fields...)
+	}
+}
+
+// codeLevel maps a gRPC status code to a log level: server faults are
+// errors, client faults are warnings, everything else is info
+func codeLevel(code codes.Code) string {
+	switch code {
+	case codes.OK, codes.Canceled, codes.NotFound, codes.AlreadyExists:
+		return "info"
+	case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated,
+		codes.FailedPrecondition, codes.OutOfRange, codes.ResourceExhausted, codes.Aborted:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// messageSize returns the encoded size of a proto message, or 0
+func messageSize(m any) int {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+	return 0
+}
+
+// payload renders a message for logging
+func payload(m any) any {
+	if msg, ok := m.(proto.Message); ok {
+		if data, err := protojson.Marshal(msg); err == nil {
+			return string(data)
+		}
+	}
+	return m
+}
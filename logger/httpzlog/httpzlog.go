@@ -0,0 +1,253 @@
+// Package httpzlog provides net/http middleware that writes structured access
+// logs through a go-zlog Logger and stores a request-scoped logger in the
+// request context.
+package httpzlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+)
+
+// DefaultRequestIDHeader is the header used to propagate request IDs
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds the length of propagated request IDs
+const maxRequestIDLen = 128
+
+// Option configures the middleware
+type Option func(*options)
+
+// options holds middleware settings
+type options struct {
+	requestIDHeader string
+	skipPaths       map[string]bool
+	trustProxy      bool
+	tail            *logger.TailConfig // Buffers request entries when set
+}
+
+// WithRequestIDHeader sets the header read and written for request IDs
+// (default: X-Request-ID)
+func WithRequestIDHeader(header string) Option {
+	return func(o *options) {
+		o.requestIDHeader = header
+	}
+}
+
+// WithSkipPaths disables access logging for the given URL paths (e.g. health
+// checks); the request-scoped logger is still provided
+func WithSkipPaths(paths ...string) Option {
+	return func(o *options) {
+		for _, p := range paths {
+			o.skipPaths[p] = true
+		}
+	}
+}
+
+// WithTrustProxy takes the remote IP from X-Forwarded-For or X-Real-IP. Only
+// enable it behind a proxy that sets these headers.
+func WithTrustProxy(enabled bool) Option {
+	return func(o *options) {
+		o.trustProxy = enabled
+	}
+}
+
+// WithTail buffers the entries logged through the request-scoped logger below
+// the trigger level, writing them only for requests that end with a 5xx
+// status, log an error or exceed the latency threshold (see logger.StartTail).
+// The access entry is always written.
+func WithTail(cfg logger.TailConfig) Option {
+	return func(o *options) {
+		o.tail = &cfg
+	}
+}
+
+// newOptions applies opts to the defaults
+func newOptions(opts []Option) *options {
+	o := &options{
+		requestIDHeader: DefaultRequestIDHeader,
+		skipPaths:       make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Middleware returns net/http middleware that propagates or generates a
+// request ID, stores a logger enriched with request_id, http.method and
+// http.path in the request context (see logger.FromContext), and logs one
+// access entry per request.
+func Middleware(l *logger.Logger, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := RequestID(r.Header.Get(o.requestIDHeader))
+			w.Header().Set(o.requestIDHeader, requestID)
+
+			reqLogger := RequestLogger(l, requestID, r.Method, r.URL.Path)
+			var tail *logger.Tail
+			if o.tail != nil {
+				reqLogger, tail = logger.NewTail(reqLogger, *o.tail)
+			}
+			r = r.WithContext(logger.NewContext(r.Context(), reqLogger))
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			if tail != nil {
+				tail.End(rw.status >= 500)
+			}
+
+			if o.skipPaths[r.URL.Path] {
+				return
+			}
+			LogAccess(reqLogger, "http request", AccessInfo{
+				Status:    rw.status,
+				Bytes:     rw.bytes,
+				Latency:   time.Since(start),
+				RemoteIP:  remoteIP(r, o.trustProxy),
+				UserAgent: r.UserAgent(),
+			})
+		})
+	}
+}
+
+// AccessInfo describes a completed request for LogAccess
+type AccessInfo struct {
+	Status    int
+	Bytes     int
+	Latency   time.Duration
+	RemoteIP  string
+	UserAgent string
+}
+
+// RequestLogger returns l enriched with the request-scoped fields shared by
+// all go-zlog HTTP middleware
+func RequestLogger(l *logger.Logger, requestID, method, path string) *logger.Logger {
+	child, _ := l.With("request_id", requestID, "http.method", method, "http.path", path).(*logger.Logger)
+	return child
+}
+
+// LogAccess writes an access log entry: 5xx at error, 4xx at warn, else info
+func LogAccess(l *logger.Logger, msg string, info AccessInfo) {
+	fields := []any{
+		"http.status", info.Status,
+		"http.bytes", info.Bytes,
+		"http.latency", info.Latency,
+		"http.remote_ip", info.RemoteIP,
+		"http.user_agent", info.UserAgent,
+	}
+	switch {
+	case info.Status >= 500:
+		l.Errorw(msg, fields...)
+	case info.Status >= 400:
+		l.Warnw(msg, fields...)
+	default:
+		l.Infow(msg, fields...)
+	}
+}
+
+// NewRequestID returns a random 128-bit hex request ID
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// RequestID returns the incoming request ID, or a new one if it is empty,
+// longer than 128 bytes or holds characters other than ASCII letters, digits
+// and "-_.:", so clients cannot inject arbitrary data into logs and headers
+func RequestID(incoming string) string {
+	if incoming == "" || len(incoming) > maxRequestIDLen {
+		return NewRequestID()
+	}
+	for i := 0; i < len(incoming); i++ {
+		switch c := incoming[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return NewRequestID()
+		}
+	}
+	return incoming
+}
+
+// remoteIP returns the client IP, optionally trusting proxy headers
+func remoteIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(first)
+		}
+		if xri := r.Header.Get("X-Real-IP"); xri != "" {
+			return xri
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseWriter records the status code and body size
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush sends buffered data to the client if the underlying writer supports it
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection (e.g. for WebSockets) if
+// the underlying writer supports it
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httpzlog: %T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
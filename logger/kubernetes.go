@@ -0,0 +1,273 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Field keys set by the Kubernetes and cloud enrichers, following the
+// OpenTelemetry resource conventions
+const (
+	FieldPodName         = "k8s.pod.name"
+	FieldNamespace       = "k8s.namespace.name"
+	FieldNodeName        = "k8s.node.name"
+	FieldContainerID     = "container.id"
+	FieldCloudProvider   = "cloud.provider"
+	FieldCloudRegion     = "cloud.region"
+	FieldCloudZone       = "cloud.availability_zone"
+	FieldCloudInstanceID = "host.id"
+)
+
+const (
+	// serviceAccountNSFile holds the pod's namespace when a service account is mounted
+	serviceAccountNSFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	// cloudMetadataEndpoint is the link-local instance metadata service of AWS and Azure
+	cloudMetadataEndpoint = "http://169.254.169.254"
+)
+
+// containerIDPattern matches a 64 hex digit container ID in cgroup and
+// mountinfo paths
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// KubernetesEnricher detects the pod name, namespace and node from downward
+// API environment variables (POD_NAME, POD_NAMESPACE, NODE_NAME), falling
+// back to the hostname and the service account namespace when running in a
+// cluster, and the container ID from the process's cgroups
+func KubernetesEnricher() Enricher {
+	return func() []any {
+		var fields []any
+		if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+			pod := firstEnv("POD_NAME", "MY_POD_NAME", "K8S_POD_NAME")
+			if pod == "" {
+				pod, _ = os.Hostname()
+			}
+			namespace := firstEnv("POD_NAMESPACE", "MY_POD_NAMESPACE", "K8S_NAMESPACE")
+			if namespace == "" {
+				if b, err := os.ReadFile(serviceAccountNSFile); err == nil {
+					namespace = strings.TrimSpace(string(b))
+				}
+			}
+			fields = appendNonEmpty(fields, FieldPodName, pod)
+			fields = appendNonEmpty(fields, FieldNamespace, namespace)
+			fields = appendNonEmpty(fields, FieldNodeName, firstEnv("NODE_NAME", "MY_NODE_NAME", "K8S_NODE_NAME"))
+		}
+		return appendNonEmpty(fields, FieldContainerID, containerID())
+	}
+}
+
+// CloudEnricher detects the cloud provider, region, zone and instance ID.
+// Environment variables set by AWS, GCP and Azure runtimes are used first;
+// when timeout is positive the instance metadata service is also queried,
+// waiting at most timeout.
+func CloudEnricher(timeout time.Duration) Enricher {
+	return func() []any {
+		md := cloudFromEnv()
+		if timeout > 0 && md.instanceID == "" {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			md = md.merge(cloudFromIMDS(ctx, md.provider))
+		}
+
+		var fields []any
+		fields = appendNonEmpty(fields, FieldCloudProvider, md.provider)
+		fields = appendNonEmpty(fields, FieldCloudRegion, md.region)
+		fields = appendNonEmpty(fields, FieldCloudZone, md.zone)
+		return appendNonEmpty(fields, FieldCloudInstanceID, md.instanceID)
+	}
+}
+
+// firstEnv returns the first non-empty environment variable among keys
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// containerID extracts the container ID from /proc/self/cgroup (cgroup v1)
+// or /proc/self/mountinfo (cgroup v2), returning "" outside containers
+func containerID() string {
+	for _, file := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		id := scanContainerID(f)
+		f.Close()
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// scanContainerID returns the first container ID found in a container
+// runtime path (docker, containerd, cri-o, kubepods) in r
+func scanContainerID(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, "docker") && !strings.Contains(line, "containerd") &&
+			!strings.Contains(line, "crio") && !strings.Contains(line, "kubepods") {
+			continue
+		}
+		if id := containerIDPattern.FindString(line); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// cloudMetadata holds detected cloud instance metadata
+type cloudMetadata struct {
+	provider   string
+	region     string
+	zone       string
+	instanceID string
+}
+
+// merge fills the empty values of md from other
+func (md cloudMetadata) merge(other cloudMetadata) cloudMetadata {
+	if md.provider == "" {
+		md.provider = other.provider
+	}
+	if md.region == "" {
+		md.region = other.region
+	}
+	if md.zone == "" {
+		md.zone = other.zone
+	}
+	if md.instanceID == "" {
+		md.instanceID = other.instanceID
+	}
+	return md
+}
+
+// cloudFromEnv detects cloud metadata from runtime environment variables
+func cloudFromEnv() cloudMetadata {
+	switch {
+	case firstEnv("AWS_REGION", "AWS_DEFAULT_REGION", "AWS_EXECUTION_ENV") != "":
+		return cloudMetadata{provider: "aws", region: firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")}
+	case firstEnv("GOOGLE_CLOUD_PROJECT", "GCP_PROJECT", "K_SERVICE") != "":
+		return cloudMetadata{provider: "gcp", region: os.Getenv("GOOGLE_CLOUD_REGION")}
+	case firstEnv("WEBSITE_SITE_NAME", "AZURE_REGION") != "":
+		return cloudMetadata{provider: "azure", region: firstEnv("REGION_NAME", "AZURE_REGION")}
+	default:
+		return cloudMetadata{}
+	}
+}
+
+// cloudFromIMDS queries the instance metadata service of provider, or of each
+// provider in turn when it is unknown
+func cloudFromIMDS(ctx context.Context, provider string) cloudMetadata {
+	client := &http.Client{}
+	probes := map[string]func(context.Context, *http.Client) cloudMetadata{
+		"aws":   awsMetadata,
+		"gcp":   gcpMetadata,
+		"azure": azureMetadata,
+	}
+	if probe, ok := probes[provider]; ok {
+		return probe(ctx, client)
+	}
+	for _, name := range []string{"aws", "gcp", "azure"} {
+		if md := probes[name](ctx, client); md.instanceID != "" {
+			return md
+		}
+	}
+	return cloudMetadata{}
+}
+
+// awsMetadata queries the EC2 instance metadata service (IMDSv2)
+func awsMetadata(ctx context.Context, client *http.Client) cloudMetadata {
+	token, err := metadataGet(ctx, client, http.MethodPut, cloudMetadataEndpoint+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return cloudMetadata{}
+	}
+	header := map[string]string{"X-aws-ec2-metadata-token": token}
+	get := func(path string) string {
+		v, _ := metadataGet(ctx, client, http.MethodGet, cloudMetadataEndpoint+"/latest/meta-data/"+path, header)
+		return v
+	}
+	id := get("instance-id")
+	if id == "" {
+		return cloudMetadata{}
+	}
+	return cloudMetadata{
+		provider:   "aws",
+		region:     get("placement/region"),
+		zone:       get("placement/availability-zone"),
+		instanceID: id,
+	}
+}
+
+// gcpMetadata queries the GCE metadata server
+func gcpMetadata(ctx context.Context, client *http.Client) cloudMetadata {
+	header := map[string]string{"Metadata-Flavor": "Google"}
+	get := func(path string) string {
+		v, _ := metadataGet(ctx, client, http.MethodGet, "http://metadata.google.internal/computeMetadata/v1/instance/"+path, header)
+		return v
+	}
+	id := get("id")
+	if id == "" {
+		return cloudMetadata{}
+	}
+	// The zone is returned as projects/<number>/zones/<zone>
+	zone := get("zone")
+	zone = zone[strings.LastIndexByte(zone, '/')+1:]
+	region := zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		region = zone[:i]
+	}
+	return cloudMetadata{provider: "gcp", region: region, zone: zone, instanceID: id}
+}
+
+// azureMetadata queries the Azure instance metadata service
+func azureMetadata(ctx context.Context, client *http.Client) cloudMetadata {
+	body, err := metadataGet(ctx, client, http.MethodGet,
+		cloudMetadataEndpoint+"/metadata/instance/compute?api-version=2021-02-01",
+		map[string]string{"Metadata": "true"})
+	if err != nil {
+		return cloudMetadata{}
+	}
+	var compute struct {
+		VMID     string `json:"vmId"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+	}
+	if json.Unmarshal([]byte(body), &compute) != nil || compute.VMID == "" {
+		return cloudMetadata{}
+	}
+	return cloudMetadata{provider: "azure", region: compute.Location, zone: compute.Zone, instanceID: compute.VMID}
+}
+
+// metadataGet performs a metadata service request and returns the body
+func metadataGet(ctx context.Context, client *http.Client, method, url string, header map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata service returned %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return strings.TrimSpace(string(b)), err
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"maps"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// labelSet is the value of a Labels field
+type labelSet map[string]string
+
+// Labels returns a field setting index dimensions of the entry, e.g. Loki
+// stream labels or Datadog tags, which sinks receive in LogEntry.Labels
+// rather than in Fields. Encoder outputs such as the console do not write
+// labels; add them as fields as well where they should appear in the line.
+func Labels(labels map[string]string) Field {
+	return zap.Field{Key: "labels", Type: zapcore.SkipType, Interface: labelSet(maps.Clone(labels))}
+}
+
+// WithLabels returns a child logger adding labels to the entries it sends
+// to sinks (see Labels)
+func (l *Logger) WithLabels(labels map[string]string) *Logger {
+	return l.WithFields(Labels(labels))
+}
+
+// isLabels reports whether f is a Labels field
+func isLabels(f zapcore.Field) bool {
+	_, ok := f.Interface.(labelSet)
+	return ok && f.Type == zapcore.SkipType
+}
+
+// mergeLabels returns labels with those of the Labels fields in fields
+// added, or labels itself if there are none
+func mergeLabels(labels map[string]string, fields []zapcore.Field) map[string]string {
+	merged := labels
+	copied := false
+	for _, f := range fields {
+		if !isLabels(f) {
+			continue
+		}
+		if !copied {
+			merged, copied = make(map[string]string, len(labels)), true
+			maps.Copy(merged, labels)
+		}
+		maps.Copy(merged, f.Interface.(labelSet))
+	}
+	return merged
+}
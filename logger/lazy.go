@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// lazyValue defers computing a field value until an entry is encoded, and
+// computes it at most once
+type lazyValue struct {
+	once sync.Once
+	fn   func() any
+	v    any
+}
+
+// Lazy returns a field whose value is computed by fn only if the entry passes
+// the level check and sampling, so expensive values cost nothing for
+// suppressed entries. Pass it among the key-value pairs of the *w methods:
+//
+//	l.Debugw("state", logger.Lazy("snapshot", db.Snapshot))
+//
+// Fields given to With are encoded immediately, so fn runs at With time.
+func Lazy(key string, fn func() any) zap.Field {
+	return zap.Reflect(key, &lazyValue{fn: fn})
+}
+
+// value computes and caches the value
+func (l *lazyValue) value() any {
+	l.once.Do(func() {
+		l.v = l.fn()
+	})
+	return l.v
+}
+
+// MarshalJSON encodes the computed value
+func (l *lazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.value())
+}
+
+// String formats the computed value
+func (l *lazyValue) String() string {
+	return fmt.Sprint(l.value())
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// allLevels enables every level; the logger-level decision is made by levelCore
+var allLevels = zap.LevelEnablerFunc(func(zapcore.Level) bool { return true })
+
+// levelCore filters entries by a logger's own level before they reach the
+// shared output cores, so loggers sharing outputs can have different levels
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+// Enabled reports whether the logger's level enables l
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l)
+}
+
+// Level returns the logger's minimum enabled level
+func (c *levelCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.level)
+}
+
+// With adds structured context while keeping the logger's level
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+// Check filters by the logger's level, then defers to the output cores
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// withLevel replaces the level of a levelCore-wrapped core
+func withLevel(core zapcore.Core, level zapcore.LevelEnabler) zapcore.Core {
+	if lc, ok := core.(*levelCore); ok {
+		core = lc.Core
+	}
+	return &levelCore{Core: core, level: level}
+}
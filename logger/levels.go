@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// TraceLevel logs very chatty wire-level detail, below debug
+	TraceLevel = zapcore.DebugLevel - 1
+	// AuditLevel logs audit records. It ranks above every other level so it
+	// is never filtered by a logger's level, and it is never sampled. It
+	// skips FatalLevel+1, which is zapcore.InvalidLevel.
+	AuditLevel = zapcore.FatalLevel + 2
+)
+
+// ParseLevel parses a level name, including "trace" and "audit"
+func ParseLevel(l string) (zapcore.Level, error) {
+	switch strings.ToLower(l) {
+	case "trace":
+		return TraceLevel, nil
+	case "audit":
+		return AuditLevel, nil
+	}
+	return zapcore.ParseLevel(l)
+}
+
+// LevelString returns the lowercase name of a level, including trace and audit
+func LevelString(l zapcore.Level) string {
+	switch l {
+	case TraceLevel:
+		return "trace"
+	case AuditLevel:
+		return "audit"
+	}
+	return l.String()
+}
+
+// noStacktrace disables automatic stack traces at every level
+var noStacktrace = zap.LevelEnablerFunc(func(zapcore.Level) bool { return false })
+
+// encodeLevel encodes levels in lowercase, naming trace and audit
+func encodeLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(LevelString(l))
+}
+
+// Trace logs at trace level
+func (l *Logger) Trace(args ...interface{}) {
+	l.sugar.Log(TraceLevel, args...)
+}
+
+// Tracef logs a formatted message at trace level
+func (l *Logger) Tracef(template string, args ...interface{}) {
+	l.sugar.Logf(TraceLevel, template, args...)
+}
+
+// Tracew logs a message with key-value pairs at trace level
+func (l *Logger) Tracew(msg string, args ...interface{}) {
+	l.sugar.Logw(TraceLevel, msg, args...)
+}
+
+// Audit logs at audit level
+func (l *Logger) Audit(args ...interface{}) {
+	l.sugar.Log(AuditLevel, args...)
+}
+
+// Auditf logs a formatted message at audit level
+func (l *Logger) Auditf(template string, args ...interface{}) {
+	l.sugar.Logf(AuditLevel, template, args...)
+}
+
+// Auditw logs a message with key-value pairs at audit level
+func (l *Logger) Auditw(msg string, args ...interface{}) {
+	l.sugar.Logw(AuditLevel, msg, args...)
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"unicode/utf8"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldTruncated lists the keys of values truncated or dropped by Limits
+const FieldTruncated = "truncated_fields"
+
+// defaultTruncationSuffix marks truncated values
+const defaultTruncationSuffix = "...[truncated]"
+
+// Limits bounds the size of entries. Zero values disable a limit.
+type Limits struct {
+	MaxMessageBytes int    // Maximum message length
+	MaxValueBytes   int    // Maximum length of string and binary field values
+	MaxFields       int    // Maximum number of fields, including context fields
+	Suffix          string // Appended to truncated values (default: "...[truncated]")
+}
+
+// WithLimits truncates oversized messages and field values and drops fields
+// beyond the maximum count before entries reach any output. The keys of
+// affected fields ("message" for the message) are listed in "truncated_fields".
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		if limits.Suffix == "" {
+			limits.Suffix = defaultTruncationSuffix
+		}
+		o.limits = &limits
+	}
+}
+
+// wrap returns core with the limits applied
+func (l *Limits) wrap(core zapcore.Core) zapcore.Core {
+	return &limitCore{Core: core, limits: l}
+}
+
+// truncate shortens s to at most max bytes including the suffix, cutting at a
+// rune boundary, and reports whether it did
+func (l *Limits) truncate(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	cut := max - len(l.Suffix)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + l.Suffix, true
+}
+
+// limitField returns f with its value truncated, reporting whether it was.
+// Message templates are kept whole, since entries are grouped by them.
+func (l *Limits) limitField(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Key == sink.FieldMessageTemplate {
+		return f, false
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		if s, ok := l.truncate(f.String, l.MaxValueBytes); ok {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ByteStringType:
+		if b := f.Interface.([]byte); l.MaxValueBytes > 0 && len(b) > l.MaxValueBytes {
+			s, _ := l.truncate(string(b), l.MaxValueBytes)
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.BinaryType:
+		if b := f.Interface.([]byte); l.MaxValueBytes > 0 && len(b) > l.MaxValueBytes {
+			return zap.Binary(f.Key, b[:l.MaxValueBytes]), true
+		}
+	}
+	return f, false
+}
+
+// limitCore truncates messages and field values and caps the field count
+// before passing entries to the wrapped core
+type limitCore struct {
+	zapcore.Core
+	limits    *Limits
+	count     int      // Fields added with With
+	truncated []string // Keys truncated or dropped from the context fields
+}
+
+// limit applies the limits to fields, returning the kept fields, their
+// count and the keys truncated or dropped
+func (c *limitCore) limit(fields []zapcore.Field, truncated []string) ([]zapcore.Field, int, []string) {
+	out := make([]zapcore.Field, 0, len(fields))
+	count := c.count
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			if isCarried(f) {
+				out = append(out, f)
+			}
+			continue
+		}
+		if f.Type != zapcore.NamespaceType {
+			if c.limits.MaxFields > 0 && count >= c.limits.MaxFields {
+				truncated = append(truncated, f.Key)
+				continue
+			}
+			count++
+		}
+		limited, ok := c.limits.limitField(f)
+		if ok {
+			truncated = append(truncated, f.Key)
+		}
+		out = append(out, limited)
+	}
+	return out, count, truncated
+}
+
+// With applies the limits to the context fields once, when they are added
+func (c *limitCore) With(fields []zapcore.Field) zapcore.Core {
+	kept, count, truncated := c.limit(fields, c.truncated[:len(c.truncated):len(c.truncated)])
+	return &limitCore{Core: c.Core.With(kept), limits: c.limits, count: count, truncated: truncated}
+}
+
+// Check adds this core (not the wrapped one) so that Write applies the limits
+func (c *limitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write applies the limits, then writes to the wrapped core
+func (c *limitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var truncated []string
+	if msg, ok := c.limits.truncate(ent.Message, c.limits.MaxMessageBytes); ok {
+		ent.Message = msg
+		truncated = append(truncated, "message")
+	}
+	kept, _, truncated := c.limit(fields, append(truncated, c.truncated...))
+	if len(truncated) > 0 {
+		kept = append(kept, zap.Strings(FieldTruncated, truncated))
+	}
+	return c.Core.Write(ent, kept)
+}
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// logfmtPool allocates the buffers returned by the logfmt encoder
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder is a zapcore.Encoder writing key=value lines. Context fields
+// are kept in a map encoder and written in key order; entry fields are
+// written in the order they were given. Nested objects and namespaces are
+// flattened into dotted keys.
+type logfmtEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg       zapcore.EncoderConfig
+	namespace []string // Namespaces opened by context fields
+}
+
+// NewLogfmtEncoder creates an encoder writing logfmt lines, using the keys
+// and time, level, caller and name encoders of cfg
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), cfg: cfg}
+}
+
+// WithLogfmt makes the console output write logfmt lines
+func WithLogfmt() Option {
+	return func(o *options) {
+		o.logfmt = true
+	}
+}
+
+// OpenNamespace nests the fields added after it under key
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.MapObjectEncoder.OpenNamespace(key)
+	e.namespace = append(slices.Clip(e.namespace), key)
+}
+
+// Clone copies the encoder and its context fields, keeping the same
+// namespaces open
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		clone.Fields[k] = cloneLogfmtValue(v)
+	}
+
+	// The map encoder cannot be pointed at an existing namespace, so open
+	// each one again and move the copied fields into it
+	cur := clone.Fields
+	for _, key := range e.namespace {
+		copied, _ := cur[key].(map[string]any)
+		clone.OpenNamespace(key)
+		cur = cur[key].(map[string]any)
+		maps.Copy(cur, copied)
+	}
+	return &logfmtEncoder{MapObjectEncoder: clone, cfg: e.cfg, namespace: e.namespace}
+}
+
+// cloneLogfmtValue deep-copies the maps opened by namespaces so clones can
+// add to them independently
+func cloneLogfmtValue(v any) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	clone := make(map[string]any, len(m))
+	for k, v := range m {
+		clone[k] = cloneLogfmtValue(v)
+	}
+	return clone
+}
+
+// EncodeEntry writes the entry metadata, then the context and entry fields
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	var line []byte
+	add := func(key string, value any) {
+		if key != zapcore.OmitKey && key != "" {
+			line = sink.AppendLogfmt(line, key, value)
+		}
+	}
+
+	if e.cfg.EncodeTime != nil {
+		add(e.cfg.TimeKey, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeTime(ent.Time, enc) }))
+	} else {
+		add(e.cfg.TimeKey, ent.Time.Format(time.RFC3339Nano))
+	}
+	if e.cfg.EncodeLevel != nil {
+		add(e.cfg.LevelKey, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeLevel(ent.Level, enc) }))
+	} else {
+		add(e.cfg.LevelKey, LevelString(ent.Level))
+	}
+	if ent.LoggerName != "" {
+		add(e.cfg.NameKey, ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		if e.cfg.EncodeCaller != nil {
+			add(e.cfg.CallerKey, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeCaller(ent.Caller, enc) }))
+		} else {
+			add(e.cfg.CallerKey, ent.Caller.TrimmedPath())
+		}
+		if ent.Caller.Function != "" {
+			add(e.cfg.FunctionKey, ent.Caller.Function)
+		}
+	}
+	add(e.cfg.MessageKey, ent.Message)
+
+	// Context fields in key order, then entry fields in order, then the keys
+	// added by inline fields
+	ctx := e.Clone().(*logfmtEncoder)
+	for _, f := range fields {
+		f.AddTo(ctx)
+	}
+	keys := slices.Sorted(maps.Keys(e.Fields))
+	for _, f := range fields {
+		if _, ok := ctx.Fields[f.Key]; ok && !slices.Contains(keys, f.Key) {
+			keys = append(keys, f.Key)
+		}
+	}
+	var inline []string
+	for k := range ctx.Fields {
+		if !slices.Contains(keys, k) {
+			inline = append(inline, k)
+		}
+	}
+	slices.Sort(inline)
+	for _, k := range append(keys, inline...) {
+		add(k, ctx.Fields[k])
+	}
+
+	if ent.Stack != "" {
+		add(e.cfg.StacktraceKey, ent.Stack)
+	}
+
+	buf := logfmtPool.Get()
+	buf.Write(line)
+	lineEnding := e.cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	buf.AppendString(lineEnding)
+	return buf, nil
+}
+
+// encodePrimitive returns the value appended by an encoder function such as
+// EncoderConfig.EncodeTime
+func encodePrimitive(encode func(zapcore.PrimitiveArrayEncoder)) any {
+	m := zapcore.NewMapObjectEncoder()
+	_ = m.AddArray("v", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		encode(enc)
+		return nil
+	}))
+	if values, ok := m.Fields["v"].([]any); ok && len(values) > 0 {
+		return values[0]
+	}
+	return nil
+}
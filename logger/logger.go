@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"os"
 
 	"github.com/hsdfat/go-zlog/sink"
@@ -14,18 +15,30 @@ type LoggerI interface {
 	Errorw(msg string, args ...interface{})
 	Debugw(msg string, args ...interface{})
 	Fatalw(msg string, args ...interface{})
+	Tracew(msg string, args ...interface{})
+	Auditw(msg string, args ...interface{})
+
+	InfowCtx(ctx context.Context, msg string, args ...interface{})
+	WarnwCtx(ctx context.Context, msg string, args ...interface{})
+	ErrorwCtx(ctx context.Context, msg string, args ...interface{})
+	DebugwCtx(ctx context.Context, msg string, args ...interface{})
+	FatalwCtx(ctx context.Context, msg string, args ...interface{})
 
 	Infof(template string, args ...interface{})
 	Debugf(template string, args ...interface{})
 	Errorf(template string, args ...interface{})
 	Warnf(template string, args ...interface{})
 	Fatalf(template string, args ...interface{})
+	Tracef(template string, args ...interface{})
+	Auditf(template string, args ...interface{})
 
 	Info(args ...interface{})
 	Debug(args ...interface{})
 	Error(args ...interface{})
 	Warn(args ...interface{})
 	Fatal(args ...interface{})
+	Trace(args ...interface{})
+	Audit(args ...interface{})
 
 	Infoln(args ...interface{})
 	Debugln(args ...interface{})
@@ -33,7 +46,11 @@ type LoggerI interface {
 	Warnln(args ...interface{})
 	Fatalln(args ...interface{})
 
-	With(args ...any) any
+	// With returns a child logger adding args to every entry. The fields are
+	// encoded once, not on every call.
+	With(args ...any) LoggerI
+	// Named returns a named child logger (see Logger.Named)
+	Named(name string) LoggerI
 }
 
 var (
@@ -43,145 +60,376 @@ var (
 
 type Logger struct {
 	*zap.SugaredLogger
-	cores []zapcore.Core
+	sugar   *zap.SugaredLogger // SugaredLogger skipping the Logger method's frame
+	typed   *zap.Logger        // zap.Logger skipping the Logger method's frame, for typed fields
+	cores   []zapcore.Core
+	sampler *sampler // Sampling settings shared with children
+	level   zap.AtomicLevel
+	name    string
 }
 
 // LoggerConfig holds configuration for logger creation
 type LoggerConfig struct {
-	EnableConsole bool        // Enable console output (default: true)
-	RemoteSinks   []sink.Sink // Optional remote sinks (e.g., Loki, HTTP)
+	EnableConsole bool                        // Enable console output (default: true)
+	RemoteSinks   []sink.Sink                 // Optional remote sinks (e.g., Loki, HTTP)
+	Hooks         []func(zapcore.Entry) error // Optional hooks run for every logged entry (e.g., metrics)
 }
 
-// NewLogger creates a new logger with default configuration (console only)
-func NewLogger() *Logger {
-	return NewLoggerWithConfig(&LoggerConfig{
-		EnableConsole: true,
-		RemoteSinks:   nil,
-	})
+// baseEncoderConfig returns the default JSON entry layout
+func baseEncoderConfig() zapcore.EncoderConfig {
+	cfg := zap.NewProductionEncoderConfig()
+	cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	cfg.EncodeLevel = encodeLevel
+	return cfg
 }
 
-// NewLoggerWithConfig creates a new logger with custom configuration
-func NewLoggerWithConfig(config *LoggerConfig) *Logger {
-	if config == nil {
-		config = &LoggerConfig{EnableConsole: true}
+// NewLogger creates a new logger configured by opts (default: console only)
+func NewLogger(opts ...Option) *Logger {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
 	}
 
-	cfg := zap.NewProductionEncoderConfig()
-	cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	cfg := baseEncoderConfig()
+	if o.callerFunc {
+		cfg.FunctionKey = "function"
+	}
+	if o.encoderConfig != nil {
+		cfg, _ = o.encoderConfig.apply(cfg)
+	}
+	encoders := encoderConfigs{json: cfg, console: cfg, sink: cfg}
+	switch o.layout {
+	case layoutECS:
+		encoders.json = ecsEncoderConfig(cfg)
+	case layoutGCP:
+		encoders.json = gcpEncoderConfig(cfg)
+	}
+	if o.development {
+		encoders.console = developmentConsole(encoders.console)
+	}
 
 	// Create cores
 	cores := []zapcore.Core{}
+	console := make(map[int]bool) // Indexes of console cores
 
 	// Add console core if enabled
-	if config.EnableConsole {
+	if o.console {
+		enc := o.encoder
+		out := os.Stderr
+		if o.layout == layoutGCP {
+			// The logging agent parses JSON lines from stdout
+			out = os.Stdout
+		}
+		if enc == nil && o.logfmt {
+			enc = NewLogfmtEncoder(encoders.json)
+		} else if enc == nil && o.layout == layoutGCP {
+			enc = zapcore.NewJSONEncoder(encoders.json)
+		} else if enc == nil {
+			enc = zapcore.NewConsoleEncoder(encoders.console)
+		}
 		consoleCore := zapcore.NewCore(
-			zapcore.NewConsoleEncoder(cfg),
-			zapcore.AddSync(zapcore.Lock(zapcore.NewMultiWriteSyncer(os.Stderr))),
-			level,
+			enc,
+			zapcore.AddSync(zapcore.Lock(zapcore.NewMultiWriteSyncer(out))),
+			allLevels,
 		)
+		console[len(cores)] = true
 		cores = append(cores, consoleCore)
 	}
 
 	// Add remote sink cores
-	if config.RemoteSinks != nil {
-		remoteSinks = config.RemoteSinks
-		for _, s := range config.RemoteSinks {
-			sinkCore := newZapSinkCore(s, zapcore.NewJSONEncoder(cfg), level)
+	if o.sinks != nil {
+		remoteSinks = o.sinks
+		for _, s := range o.sinks {
+			sinkCore := newZapSinkCore(s, cfg, allLevels)
 			cores = append(cores, sinkCore)
 		}
 	}
 
+	// Add builder outputs
+	for _, out := range o.outputs {
+		c := out(encoders, allLevels)
+		if cc, ok := c.(consoleOutput); ok {
+			c, console[len(cores)] = cc.Core, true
+		}
+		cores = append(cores, c)
+	}
+
+	// Stamp service metadata onto entries sent to sinks, queue them when
+	// writes must not block, and collect the sinks flushed on panic and fatal
+	// entries
+	var sinks []sink.Sink
+	for _, c := range cores {
+		if sc, ok := c.(*zapSinkCore); ok {
+			if o.nonBlocking != nil {
+				sc.sink = o.nonBlocking.wrap(sc.sink)
+			}
+			sinks = append(sinks, sc.sink)
+			if !o.resource.IsZero() {
+				sc.setResource(o.resource)
+			}
+		}
+	}
+
+	// Restrict the fields shipped to remote sinks
+	if o.schema != nil {
+		for i, c := range cores {
+			if _, ok := c.(*zapSinkCore); ok {
+				cores[i] = o.schema.wrap(c)
+			}
+		}
+	}
+
+	// Rename fields to match the organization's schema
+	if o.encoderConfig != nil && len(o.encoderConfig.FieldKeys) > 0 {
+		for i, c := range cores {
+			cores[i] = &renameCore{Core: c, keys: o.encoderConfig.FieldKeys}
+		}
+	}
+
+	// Rename fields written by encoder outputs to match the layout
+	for i, c := range cores {
+		if _, ok := c.(*zapSinkCore); ok {
+			continue
+		}
+		switch o.layout {
+		case layoutECS:
+			cores[i] = (&ecsCore{Core: c}).With(ecsContext(o.resource))
+		case layoutGCP:
+			cores[i] = &gcpCore{Core: c, project: o.gcpProject}
+		}
+	}
+
+	// Trim captured stack traces
+	if o.stacktrace != nil && o.stacktrace.trims() {
+		for i, c := range cores {
+			cores[i] = o.stacktrace.wrap(c)
+		}
+	}
+
+	// Flatten or nest fields for every output alike
+	if o.shape != nil {
+		for i, c := range cores {
+			cores[i] = o.shape.wrap(c)
+		}
+	}
+
+	// Bound entry sizes; applied after redaction so truncation cannot hide
+	// sensitive values from the redaction patterns
+	if o.limits != nil {
+		for i, c := range cores {
+			cores[i] = o.limits.wrap(c)
+		}
+	}
+
+	// Expand error chains for every output alike; applied after redaction so
+	// redacted errors stay plain, and before limits so long stacks are cut
+	for i, c := range cores {
+		cores[i] = &errorCore{Core: c}
+	}
+
+	// Render message templates from the redacted fields
+	if o.templates {
+		for i, c := range cores {
+			cores[i] = &templateCore{Core: c}
+		}
+	}
+
+	// Redact sensitive data before it reaches any output
+	if o.redactor != nil {
+		sep := "."
+		if o.shape != nil {
+			sep = o.shape.Separator
+		}
+		for i, c := range cores {
+			cores[i] = o.redactor.wrap(c, sep)
+		}
+	}
+
+	// Enforce field types before redaction, so redacted values are not reported as
+	// violations
+	if o.fieldTypes != nil {
+		for i, c := range cores {
+			cores[i] = o.fieldTypes.wrap(c)
+		}
+	}
+
+	// Normalize keys before any other rule matches on them
+	if o.normalizer != nil {
+		for i, c := range cores {
+			cores[i] = o.normalizer.wrap(c)
+		}
+	}
+
+	// Let request tails buffer every output but the console (see NewTail)
+	for i, c := range cores {
+		if !console[i] {
+			cores[i] = &tailCore{Core: c}
+		}
+	}
+
 	// Create logger with multiple cores
-	core := zapcore.NewTee(cores...)
-	logger := zap.New(core, zap.AddCaller())
+	smp := &sampler{}
+	smp.set(o.sampling)
+	var core zapcore.Core = newFlushCore(newEntryCore(cores, o), sinks, o.flushTimeout)
+	if o.logMetrics != nil {
+		core = &metricsCore{Core: core, metrics: o.logMetrics}
+	}
+	core = &samplerCore{Core: core, sampler: smp}
+	if len(o.hooks) > 0 {
+		// Registered inside the level core so Named can replace the level
+		core = zapcore.RegisterHooks(core, o.hooks...)
+	}
+	core = withLevel(core, o.level)
+
+	// zap's default "no stacktrace" threshold is FatalLevel+1, which is
+	// below AuditLevel, so disable stack traces explicitly
+	zapOpts := []zap.Option{
+		zap.AddCaller(),
+		zap.AddStacktrace(noStacktrace),
+		zap.WithFatalHook(newFatalHook(o.exitFunc)),
+	}
+	if o.development {
+		zapOpts = append(zapOpts, zap.Development(), zap.AddStacktrace(devStacktrace))
+	}
+	if o.stacktrace != nil {
+		zapOpts = append(zapOpts, zap.AddStacktrace(o.stacktrace.enabler()))
+	}
+	if o.clock != nil {
+		zapOpts = append(zapOpts, zap.WithClock(zapClock{o.clock}))
+	}
+	if o.callerSkip != 0 {
+		zapOpts = append(zapOpts, zap.AddCallerSkip(o.callerSkip))
+	}
+	logger := zap.New(core, zapOpts...)
 
 	sugar := logger.Sugar()
+	if len(o.fields) > 0 {
+		sugar = sugar.With(o.fields...)
+	}
 
+	return newLogger(sugar, cores, smp, o.level, "")
+}
+
+// newLogger wraps sugar. Calls through Logger's methods go through one more
+// frame than calls on the embedded SugaredLogger, so they use a copy that
+// skips it to report the true call site.
+func newLogger(sugar *zap.SugaredLogger, cores []zapcore.Core, smp *sampler, level zap.AtomicLevel, name string) *Logger {
+	skipped := sugar.WithOptions(zap.AddCallerSkip(1))
 	return &Logger{
 		SugaredLogger: sugar,
+		sugar:         skipped,
+		typed:         skipped.Desugar(),
 		cores:         cores,
+		sampler:       smp,
+		level:         level,
+		name:          name,
+	}
+}
+
+// NewLoggerWithConfig creates a new logger with custom configuration
+func NewLoggerWithConfig(config *LoggerConfig) *Logger {
+	if config == nil {
+		config = &LoggerConfig{EnableConsole: true}
 	}
+
+	return NewLogger(
+		WithConsole(config.EnableConsole),
+		WithSink(config.RemoteSinks...),
+		WithHooks(config.Hooks...),
+	)
 }
 
 func (l *Logger) Infow(msg string, args ...interface{}) {
-	l.SugaredLogger.With(args...).Info(msg)
+	l.sugar.Infow(msg, args...)
 }
 
 func (l *Logger) Warnw(msg string, args ...interface{}) {
-	l.SugaredLogger.With(args...).Warn(msg)
+	l.sugar.Warnw(msg, args...)
 }
 
 func (l *Logger) Errorw(msg string, args ...interface{}) {
-	l.SugaredLogger.With(args...).Error(msg)
+	l.sugar.Errorw(msg, args...)
 }
 
 func (l *Logger) Debugw(msg string, args ...interface{}) {
-	l.SugaredLogger.With(args...).Debug(msg)
+	l.sugar.Debugw(msg, args...)
 }
 
 func (l *Logger) Fatalw(msg string, args ...interface{}) {
-	l.SugaredLogger.With(args...).Fatal(msg)
+	l.sugar.Fatalw(msg, args...)
 }
 func (l *Logger) Infof(template string, args ...interface{}) {
-	l.SugaredLogger.Infof(template, args...)
+	l.sugar.Infof(template, args...)
 }
 func (l *Logger) Debugf(template string, args ...interface{}) {
-	l.SugaredLogger.Debugf(template, args...)
+	l.sugar.Debugf(template, args...)
 }
 func (l *Logger) Errorf(template string, args ...interface{}) {
-	l.SugaredLogger.Errorf(template, args...)
+	l.sugar.Errorf(template, args...)
 }
 func (l *Logger) Warnf(template string, args ...interface{}) {
-	l.SugaredLogger.Warnf(template, args...)
+	l.sugar.Warnf(template, args...)
 }
 func (l *Logger) Fatalf(template string, args ...interface{}) {
-	l.SugaredLogger.Fatalf(template, args...)
+	l.sugar.Fatalf(template, args...)
 }
 
 func (l *Logger) Info(args ...interface{}) {
-	l.SugaredLogger.Info(args...)
+	l.sugar.Info(args...)
 }
 func (l *Logger) Debug(args ...interface{}) {
-	l.SugaredLogger.Debug(args...)
+	l.sugar.Debug(args...)
 }
 func (l *Logger) Error(args ...interface{}) {
-	l.SugaredLogger.Error(args...)
+	l.sugar.Error(args...)
 }
 func (l *Logger) Warn(args ...interface{}) {
-	l.SugaredLogger.Warn(args...)
+	l.sugar.Warn(args...)
 }
 func (l *Logger) Fatal(args ...interface{}) {
-	l.SugaredLogger.Fatal(args...)
+	l.sugar.Fatal(args...)
 }
 
 func (l *Logger) Infoln(args ...interface{}) {
-	l.SugaredLogger.Info(args...)
+	l.sugar.Info(args...)
 }
 func (l *Logger) Debugln(args ...interface{}) {
-	l.SugaredLogger.Debug(args...)
+	l.sugar.Debug(args...)
 }
 func (l *Logger) Errorln(args ...interface{}) {
-	l.SugaredLogger.Error(args...)
+	l.sugar.Error(args...)
 }
 func (l *Logger) Warnln(args ...interface{}) {
-	l.SugaredLogger.Warn(args...)
+	l.sugar.Warn(args...)
 }
 func (l *Logger) Fatalln(args ...interface{}) {
-	l.SugaredLogger.Fatal(args...)
+	l.sugar.Fatal(args...)
 }
 
-func (l *Logger) With(args ...any) any {
-	return &Logger{
-		SugaredLogger: l.SugaredLogger.With(args...),
+// With returns a child logger adding args to every entry
+func (l *Logger) With(args ...any) LoggerI {
+	return newLogger(l.SugaredLogger.With(args...), l.cores, l.sampler, l.level, l.name)
+}
+
+// SetLevel changes the minimum level of this logger and its children
+func (l *Logger) SetLevel(lvl string) {
+	zapLevel, err := ParseLevel(lvl)
+	if err != nil {
+		zapLevel = zapcore.InfoLevel
 	}
+	l.level.SetLevel(zapLevel)
 }
 
 var (
-	Log LoggerI = NewLogger()
+	// Log is the default logger. It forwards every call to L, so it follows
+	// ReplaceGlobals. Assigning it is deprecated: it races with concurrent
+	// users and the assigned logger is not used by L, FromContext or Named.
+	Log LoggerI = defaultLogger{}
 )
 
+// SetLevel changes the minimum level of the default Log
 func SetLevel(l string) {
-	zapLevel, err := zapcore.ParseLevel(l)
+	zapLevel, err := ParseLevel(l)
 	if err != nil {
 		zapLevel = zapcore.InfoLevel
 	}
@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// overflowValue replaces field values beyond LogMetricsConfig.MaxValues
+const overflowValue = "other"
+
+// LogMetricsConfig configures LogMetrics
+type LogMetricsConfig struct {
+	Field     string // Field whose value keys the counters, e.g. "error_code" ("" = none)
+	MaxValues int    // Distinct field values counted, later ones count as "other" (default: 100)
+
+	// OnEntry, if set, is called for every counted entry, e.g. to increment a
+	// metrics library's counter
+	OnEntry func(key LogMetricKey)
+}
+
+// LogMetricKey identifies a LogMetrics counter
+type LogMetricKey struct {
+	Level  string // Level name, e.g. "error"
+	Logger string // Dotted logger name ("" for root loggers)
+	Value  string // Value of the configured field ("" when absent)
+}
+
+// LogMetrics counts the entries written by a logger by level, logger name and
+// the value of a field, giving error-rate metrics without instrumentation.
+// Pass it to WithLogMetrics; the metrics package exports it to Prometheus.
+type LogMetrics struct {
+	config LogMetricsConfig
+
+	mu     sync.Mutex
+	counts map[LogMetricKey]uint64
+	values map[string]bool // Distinct field values seen, up to MaxValues
+}
+
+// NewLogMetrics returns counters configured by cfg
+func NewLogMetrics(cfg LogMetricsConfig) *LogMetrics {
+	if cfg.MaxValues <= 0 {
+		cfg.MaxValues = 100
+	}
+	return &LogMetrics{
+		config: cfg,
+		counts: make(map[LogMetricKey]uint64),
+		values: make(map[string]bool),
+	}
+}
+
+// Field returns the field whose value keys the counters
+func (m *LogMetrics) Field() string {
+	return m.config.Field
+}
+
+// Counts returns a copy of the counters
+func (m *LogMetrics) Counts() map[LogMetricKey]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[LogMetricKey]uint64, len(m.counts))
+	for k, v := range m.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// count increments the counter of an entry
+func (m *LogMetrics) count(ent zapcore.Entry, value string) {
+	key := LogMetricKey{Level: LevelString(ent.Level), Logger: ent.LoggerName, Value: value}
+
+	m.mu.Lock()
+	if value != "" && !m.values[value] {
+		if len(m.values) < m.config.MaxValues {
+			m.values[value] = true
+		} else {
+			key.Value = overflowValue
+		}
+	}
+	m.counts[key]++
+	m.mu.Unlock()
+
+	if m.config.OnEntry != nil {
+		m.config.OnEntry(key)
+	}
+}
+
+// WithLogMetrics counts every entry written by the logger in m
+func WithLogMetrics(m *LogMetrics) Option {
+	return func(o *options) {
+		o.logMetrics = m
+	}
+}
+
+// metricsCore counts entries once the outputs have accepted them
+type metricsCore struct {
+	zapcore.Core
+	metrics *LogMetrics
+	value   string // Value of the counted field added with With
+}
+
+// With adds structured context, remembering the counted field's value
+func (c *metricsCore) With(fields []zapcore.Field) zapcore.Core {
+	value := c.value
+	if v, ok := c.fieldValue(fields); ok {
+		value = v
+	}
+	return &metricsCore{Core: c.Core.With(fields), metrics: c.metrics, value: value}
+}
+
+// Check defers to the wrapped core, adding this core after it when an
+// output accepts the entry
+func (c *metricsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ce = c.Core.Check(ent, ce); ce != nil {
+		ce = ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write counts the entry; the entry itself was written by the wrapped core
+func (c *metricsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	value := c.value
+	if v, ok := c.fieldValue(fields); ok {
+		value = v
+	}
+	c.metrics.count(ent, value)
+	return nil
+}
+
+// fieldValue returns the text of the last counted field in fields
+func (c *metricsCore) fieldValue(fields []zapcore.Field) (string, bool) {
+	if c.metrics.config.Field == "" {
+		return "", false
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == c.metrics.config.Field {
+			return fmt.Sprint(fieldValue(fields[i])), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logrSink is a logr.LogSink writing through a Logger
+type logrSink struct {
+	logger    *Logger
+	z         *zap.Logger
+	callDepth int
+}
+
+// NewLogr returns a logr.Logger backed by l. V(0) logs at info, V(1) at debug and
+// V(2) and above at trace, names map to the Named logger hierarchy (and its per-name
+// levels), and keysAndValues become fields.
+func NewLogr(l *Logger) logr.Logger {
+	return logr.New(&logrSink{logger: l})
+}
+
+// Init receives runtime information from logr
+func (s *logrSink) Init(info logr.RuntimeInfo) {
+	s.callDepth = info.CallDepth
+	s.z = s.desugar()
+}
+
+// desugar returns the zap logger with the caller skip for logr frames
+func (s *logrSink) desugar() *zap.Logger {
+	return s.logger.Desugar().WithOptions(zap.AddCallerSkip(s.callDepth + 1))
+}
+
+// Enabled reports whether the V-level is enabled
+func (s *logrSink) Enabled(level int) bool {
+	return s.logger.Desugar().Core().Enabled(logrToZapLevel(level))
+}
+
+// Info logs a non-error message at the level mapped from the V-level
+func (s *logrSink) Info(level int, msg string, keysAndValues ...any) {
+	if ce := s.zap().Check(logrToZapLevel(level), msg); ce != nil {
+		ce.Write(sweetenFields(keysAndValues)...)
+	}
+}
+
+// Error logs an error message at error level
+func (s *logrSink) Error(err error, msg string, keysAndValues ...any) {
+	if ce := s.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		fields := sweetenFields(keysAndValues)
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+	}
+}
+
+// WithValues returns a sink whose entries include keysAndValues
+func (s *logrSink) WithValues(keysAndValues ...any) logr.LogSink {
+	child, _ := s.logger.With(keysAndValues...).(*Logger)
+	return s.derive(child)
+}
+
+// WithName returns a sink for the named child logger
+func (s *logrSink) WithName(name string) logr.LogSink {
+	return s.derive(s.logger.named(name))
+}
+
+// WithCallDepth returns a sink that skips depth additional caller frames
+func (s *logrSink) WithCallDepth(depth int) logr.LogSink {
+	clone := &logrSink{logger: s.logger, callDepth: s.callDepth + depth}
+	clone.z = clone.desugar()
+	return clone
+}
+
+// derive returns a sink for l with the same call depth
+func (s *logrSink) derive(l *Logger) *logrSink {
+	clone := &logrSink{logger: l, callDepth: s.callDepth}
+	clone.z = clone.desugar()
+	return clone
+}
+
+// zap returns the zap logger, initializing it if Init was not called
+func (s *logrSink) zap() *zap.Logger {
+	if s.z == nil {
+		s.z = s.desugar()
+	}
+	return s.z
+}
+
+// logrToZapLevel maps a logr V-level to a zap level
+func logrToZapLevel(level int) zapcore.Level {
+	if level > 1 {
+		return TraceLevel
+	}
+	if level > 0 {
+		return zapcore.DebugLevel
+	}
+	return zapcore.InfoLevel
+}
@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// namedLevels tracks the levels of named loggers and the patterns set with
+// SetLevelFor, so levels can be changed per subsystem at runtime
+var namedLevels = struct {
+	sync.Mutex
+	levels   map[string]namedLevelEntry
+	patterns []levelPattern
+}{levels: make(map[string]namedLevelEntry)}
+
+// namedLevelEntry is the shared level of a named logger
+type namedLevelEntry struct {
+	level    zap.AtomicLevel
+	fallback zapcore.Level // Level when no pattern matches
+}
+
+// levelPattern is a glob pattern and the level applied to matching names
+type levelPattern struct {
+	pattern string
+	level   zapcore.Level
+}
+
+// Named returns a child logger of the default logger with the given name
+func Named(name string) *Logger {
+	return L().named(name)
+}
+
+// Named returns a child logger whose name is appended to the parent's with a
+// dot (e.g. "grpc" then "server" gives "grpc.server"). Loggers with the same
+// name share a level, initially taken from the last matching SetLevelFor
+// pattern or else the parent's level. The child is a *Logger, which also
+// has SetLevel and Name.
+func (l *Logger) Named(name string) LoggerI {
+	return l.named(name)
+}
+
+// named returns the child logger of Named
+func (l *Logger) named(name string) *Logger {
+	full := name
+	if l.name != "" {
+		full = l.name + "." + name
+	}
+
+	lvl := namedLevel(full, l.level.Level())
+	sugar := l.SugaredLogger.Named(name).WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return withLevel(c, lvl)
+	}))
+
+	return newLogger(sugar, l.cores, l.sampler, lvl, full)
+}
+
+// Name returns the logger's dotted name ("" for root loggers)
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetLevelFor sets the level of every named logger matching the glob pattern,
+// now and for loggers created later. A pattern ending in ".*" also matches
+// all descendants, so "grpc.*" covers "grpc.server" and "grpc.server.stream".
+func SetLevelFor(pattern, level string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	zapLevel, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	namedLevels.Lock()
+	defer namedLevels.Unlock()
+
+	// Setting a pattern again moves it last, so repeated reloads don't
+	// accumulate patterns
+	namedLevels.patterns = slices.DeleteFunc(namedLevels.patterns, func(p levelPattern) bool {
+		return p.pattern == pattern
+	})
+	namedLevels.patterns = append(namedLevels.patterns, levelPattern{pattern: pattern, level: zapLevel})
+	for name, e := range namedLevels.levels {
+		if matchName(pattern, name) {
+			e.level.SetLevel(zapLevel)
+		}
+	}
+	return nil
+}
+
+// SetLevelPatterns replaces the patterns set with SetLevelFor with patterns,
+// applied in order so the last matching one wins, e.g. when reloading a
+// configuration. Named loggers matching none of them return to the level
+// they were created with. Nothing is changed if a pattern or level is
+// invalid.
+func SetLevelPatterns(patterns []PatternLevel) error {
+	parsed := make([]levelPattern, 0, len(patterns))
+	for _, p := range patterns {
+		if _, err := path.Match(p.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p.Pattern, err)
+		}
+		lvl, err := ParseLevel(p.Level)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, levelPattern{pattern: p.Pattern, level: lvl})
+	}
+
+	namedLevels.Lock()
+	defer namedLevels.Unlock()
+
+	namedLevels.patterns = parsed
+	for name, e := range namedLevels.levels {
+		e.level.SetLevel(patternLevel(parsed, name, e.fallback))
+	}
+	return nil
+}
+
+// namedLevel returns the shared level for name, creating it from the last
+// matching pattern or the fallback level
+func namedLevel(name string, fallback zapcore.Level) zap.AtomicLevel {
+	namedLevels.Lock()
+	defer namedLevels.Unlock()
+
+	if e, ok := namedLevels.levels[name]; ok {
+		return e.level
+	}
+
+	lvl := zap.NewAtomicLevelAt(patternLevel(namedLevels.patterns, name, fallback))
+	namedLevels.levels[name] = namedLevelEntry{level: lvl, fallback: fallback}
+	return lvl
+}
+
+// patternLevel returns the level of the last pattern matching name, or
+// fallback if none does
+func patternLevel(patterns []levelPattern, name string, fallback zapcore.Level) zapcore.Level {
+	lvl := fallback
+	for _, p := range patterns {
+		if matchName(p.pattern, name) {
+			lvl = p.level
+		}
+	}
+	return lvl
+}
+
+// matchName reports whether a logger name matches a glob pattern
+func matchName(pattern, name string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasSuffix(prefix, ".") {
+		return strings.HasPrefix(name, prefix)
+	}
+	return false
+}
+
+// namedLevelSnapshot returns the current level of every named logger and the
+// patterns set with SetLevelFor, in the order they apply
+func namedLevelSnapshot() (levels map[string]string, patterns []PatternLevel) {
+	namedLevels.Lock()
+	defer namedLevels.Unlock()
+
+	levels = make(map[string]string, len(namedLevels.levels))
+	for name, e := range namedLevels.levels {
+		levels[name] = LevelString(e.level.Level())
+	}
+	for _, p := range namedLevels.patterns {
+		patterns = append(patterns, PatternLevel{Pattern: p.pattern, Level: LevelString(p.level)})
+	}
+	return levels, patterns
+}
@@ -0,0 +1,277 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// NonBlockingConfig configures NonBlocking
+type NonBlockingConfig struct {
+	Size      int     // Entries queued per sink, rounded up to a power of two (default: 4096)
+	Watermark float64 // Fill ratio calling OnWatermark (default: 0.8)
+
+	// OnWatermark, if set, is called when a sink's queue fills past the
+	// watermark, once until it drains below it again. It runs on the
+	// logging goroutine, so it must not block or log through the logger.
+	OnWatermark func(queued, capacity int)
+
+	// OnError, if set, receives the errors of sink writes, which happen on a
+	// background goroutine (default: sink.HandleError). Errors are reported
+	// at most once per second, with the count of those suppressed.
+	OnError func(err error)
+}
+
+// NonBlocking queues the entries sent to sinks in lock-free rings drained by
+// a background goroutine per sink, so logging never waits on a slow sink.
+// When a ring is full, new entries are dropped and counted. Pass it to
+// WithNonBlocking; Sync and the flush on fatal entries drain the rings first.
+type NonBlocking struct {
+	config  NonBlockingConfig
+	dropped atomic.Uint64
+
+	mu    sync.Mutex
+	rings []*ringSink
+
+	reportMu   sync.Mutex
+	lastReport time.Time // When an error was last reported
+	suppressed int       // Errors not reported since then
+}
+
+// nonBlockingReportInterval is the minimum time between reported write errors
+const nonBlockingReportInterval = time.Second
+
+// NewNonBlocking returns queues configured by cfg
+func NewNonBlocking(cfg NonBlockingConfig) *NonBlocking {
+	if cfg.Size <= 0 {
+		cfg.Size = 4096
+	}
+	cfg.Size = 1 << bits.Len(uint(cfg.Size-1))
+	if cfg.Watermark <= 0 || cfg.Watermark > 1 {
+		cfg.Watermark = 0.8
+	}
+	return &NonBlocking{config: cfg}
+}
+
+// WithNonBlocking makes writes to sinks non-blocking (see NonBlocking).
+// Encoder outputs such as the console are still written synchronously.
+func WithNonBlocking(n *NonBlocking) Option {
+	return func(o *options) {
+		o.nonBlocking = n
+	}
+}
+
+// Dropped returns the number of entries dropped from full queues
+func (n *NonBlocking) Dropped() uint64 {
+	return n.dropped.Load()
+}
+
+// Queued returns the number of entries waiting in the queues
+func (n *NonBlocking) Queued() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	queued := 0
+	for _, r := range n.rings {
+		queued += r.len()
+	}
+	return queued
+}
+
+// Flush waits for the entries queued so far to be written, then flushes the
+// sinks, e.g. before closing them
+func (n *NonBlocking) Flush(ctx context.Context) error {
+	n.mu.Lock()
+	rings := slices.Clone(n.rings)
+	n.mu.Unlock()
+	var errs []error
+	for _, r := range rings {
+		if err := r.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// wrap returns s writing through a new queue
+func (n *NonBlocking) wrap(s sink.Sink) sink.Sink {
+	r := &ringSink{
+		Sink:  s,
+		owner: n,
+		slots: make([]ringSlot, n.config.Size),
+		mask:  uint64(n.config.Size - 1),
+		high:  max(1, int(float64(n.config.Size)*n.config.Watermark)),
+	}
+	for i := range r.slots {
+		r.slots[i].seq.Store(uint64(i))
+	}
+	n.mu.Lock()
+	n.rings = append(n.rings, r)
+	n.mu.Unlock()
+	return r
+}
+
+// ringSlot holds a queued entry; seq tells producers and the consumer whose
+// turn it is
+type ringSlot struct {
+	seq   atomic.Uint64
+	entry *sink.LogEntry
+	ctx   context.Context
+}
+
+// ringSink queues entries in a bounded multi-producer ring and writes them to
+// the wrapped sink from a goroutine started while entries are queued
+type ringSink struct {
+	sink.Sink
+	owner *NonBlocking
+	slots []ringSlot
+	mask  uint64
+	high  int // Queue length calling OnWatermark
+
+	head    atomic.Uint64 // Next position to enqueue
+	tail    atomic.Uint64 // Next position to dequeue (consumer only)
+	written atomic.Uint64 // Entries dequeued and written
+	running atomic.Bool   // A drain goroutine is running
+	above   atomic.Bool   // The queue is past the watermark
+}
+
+// Write queues entry without blocking, dropping it if the queue is full. The
+// entry is written later with ctx's values but not its deadline.
+func (r *ringSink) Write(ctx context.Context, entry *sink.LogEntry) error {
+	if !r.push(context.WithoutCancel(ctx), entry) {
+		r.owner.dropped.Add(1)
+		sink.ReleaseEntry(entry)
+		return nil
+	}
+	if n := r.len(); n >= r.high && !r.above.Swap(true) && r.owner.config.OnWatermark != nil {
+		r.owner.config.OnWatermark(n, len(r.slots))
+	}
+	if r.running.CompareAndSwap(false, true) {
+		go r.drain()
+	}
+	return nil
+}
+
+// push adds entry to the ring, reporting false if it is full
+func (r *ringSink) push(ctx context.Context, entry *sink.LogEntry) bool {
+	for {
+		pos := r.head.Load()
+		slot := &r.slots[pos&r.mask]
+		switch seq := slot.seq.Load(); {
+		case seq == pos:
+			if r.head.CompareAndSwap(pos, pos+1) {
+				slot.entry, slot.ctx = entry, ctx
+				slot.seq.Store(pos + 1)
+				return true
+			}
+		case seq < pos:
+			return false
+		}
+	}
+}
+
+// pop removes the oldest entry, reporting false if there is none ready
+func (r *ringSink) pop() (*sink.LogEntry, context.Context, bool) {
+	pos := r.tail.Load()
+	slot := &r.slots[pos&r.mask]
+	if slot.seq.Load() != pos+1 {
+		return nil, nil, false
+	}
+	entry, ctx := slot.entry, slot.ctx
+	slot.entry, slot.ctx = nil, nil
+	r.tail.Store(pos + 1)
+	slot.seq.Store(pos + r.mask + 1)
+	return entry, ctx, true
+}
+
+// len returns the number of queued entries. The tail is loaded first: the
+// head only moves forward, so it cannot be behind the loaded tail.
+func (r *ringSink) len() int {
+	tail := r.tail.Load()
+	return int(r.head.Load() - tail)
+}
+
+// drain writes queued entries until the queue is empty
+func (r *ringSink) drain() {
+	for {
+		for {
+			entry, ctx, ok := r.pop()
+			if !ok {
+				break
+			}
+			wctx, cancel, _ := writeContext(ctx)
+			if err := r.Sink.Write(wctx, entry); err != nil {
+				r.owner.report(err)
+			}
+			cancel()
+			r.written.Add(1)
+			if r.len() < r.high {
+				r.above.Store(false)
+			}
+		}
+		r.running.Store(false)
+		// An entry queued after the last pop may have seen the goroutine
+		// still running
+		if r.len() == 0 || !r.running.CompareAndSwap(false, true) {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+// report passes a write error to OnError or sink.HandleError, unless one was
+// reported within nonBlockingReportInterval, so an outage does not flood it
+func (n *NonBlocking) report(err error) {
+	n.reportMu.Lock()
+	now := time.Now()
+	if now.Sub(n.lastReport) < nonBlockingReportInterval {
+		n.suppressed++
+		n.reportMu.Unlock()
+		return
+	}
+	suppressed := n.suppressed
+	n.lastReport, n.suppressed = now, 0
+	n.reportMu.Unlock()
+
+	err = fmt.Errorf("non-blocking sink write: %w", err)
+	if suppressed > 0 {
+		err = fmt.Errorf("%w (%d more suppressed)", err, suppressed)
+	}
+	if n.config.OnError != nil {
+		n.config.OnError(err)
+		return
+	}
+	sink.HandleError(err)
+}
+
+// Flush waits for the entries queued so far to be written, then flushes the
+// wrapped sink
+func (r *ringSink) Flush(ctx context.Context) error {
+	target := r.head.Load()
+	for r.written.Load() < target {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return r.Sink.Flush(ctx)
+}
+
+// Close writes the queued entries, then closes the wrapped sink
+func (r *ringSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), sinkWriteTimeout)
+	defer cancel()
+	err := r.Flush(ctx)
+	if cerr := r.Sink.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+package logger
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxNormalizedKeys bounds the cache of normalized keys
+const maxNormalizedKeys = 4096
+
+// KeyCase is a field key naming convention
+type KeyCase string
+
+// Key naming conventions
+const (
+	KeySnakeCase KeyCase = "snake" // user_id
+	KeyCamelCase KeyCase = "camel" // userId
+	KeyLowerCase KeyCase = "lower" // userid
+)
+
+// ParseKeyCase parses a key naming convention name
+func ParseKeyCase(s string) (KeyCase, error) {
+	switch c := KeyCase(s); c {
+	case "", KeySnakeCase, KeyCamelCase, KeyLowerCase:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown key case %q", s)
+}
+
+// NormalizeConfig configures field key normalization
+type NormalizeConfig struct {
+	Case   KeyCase  // Naming convention of the keys ("" = unchanged)
+	Dedupe bool     // Keep only the last of fields whose keys differ only by case
+	Keep   []string // Keys left unchanged, in addition to the trace correlation keys
+}
+
+// WithKeyNormalization rewrites field keys to one naming convention before
+// entries reach any output, so entries from different teams and bridged
+// libraries land in a consistent schema: with KeySnakeCase, "userID",
+// "UserId" and "user-id" all become "user_id". Each segment of a dotted key
+// is converted separately. The keys sinks correlate on (trace_id, span_id,
+// trace_flags, log_id, tenant, msg_template) are never changed.
+//
+// With Dedupe, fields of one With or log call whose normalized keys differ
+// only by case are reduced to the last one.
+func WithKeyNormalization(cfg NormalizeConfig) Option {
+	return func(o *options) {
+		n := &normalizer{config: cfg, keep: map[string]bool{
+			sink.FieldTraceID:         true,
+			sink.FieldSpanID:          true,
+			sink.FieldTraceFlags:      true,
+			sink.FieldEntryID:         true,
+			sink.FieldTenant:          true,
+			sink.FieldMessageTemplate: true,
+		}}
+		for _, key := range cfg.Keep {
+			n.keep[key] = true
+		}
+		o.normalizer = n
+	}
+}
+
+// normalizer holds the compiled normalization settings
+type normalizer struct {
+	config NormalizeConfig
+	keep   map[string]bool
+	cache  sync.Map // Normalized key by key
+	cached atomic.Int64
+}
+
+// wrap returns core with the keys normalized
+func (n *normalizer) wrap(core zapcore.Core) zapcore.Core {
+	return &normalizeCore{Core: core, normalizer: n}
+}
+
+// key returns the normalized key
+func (n *normalizer) key(key string) string {
+	if n.config.Case == "" || n.keep[key] {
+		return key
+	}
+	if v, ok := n.cache.Load(key); ok {
+		return v.(string)
+	}
+	segments := strings.Split(key, ".")
+	for i, s := range segments {
+		segments[i] = convertCase(s, n.config.Case)
+	}
+	normalized := strings.Join(segments, ".")
+	if n.cached.Load() < maxNormalizedKeys {
+		if _, loaded := n.cache.LoadOrStore(key, normalized); !loaded {
+			n.cached.Add(1)
+		}
+	}
+	return normalized
+}
+
+// convertCase returns s in the naming convention
+func convertCase(s string, c KeyCase) string {
+	if c == KeyLowerCase {
+		return strings.ToLower(s)
+	}
+	words := splitWords(s)
+	if len(words) == 0 {
+		return s
+	}
+	var b strings.Builder
+	for i, w := range words {
+		w = strings.ToLower(w)
+		switch {
+		case c == KeyCamelCase && i > 0:
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		case c == KeySnakeCase && i > 0:
+			b.WriteByte('_')
+			fallthrough
+		default:
+			b.WriteString(w)
+		}
+	}
+	return b.String()
+}
+
+// splitWords splits s at separators and case changes: "HTTPServerID" is
+// HTTP, Server, ID and "user-id" is user, id
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i == len(runes) || runes[i] == '_' || runes[i] == '-' || runes[i] == ' ' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i > start && unicode.IsUpper(runes[i]) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	return words
+}
+
+// normalize returns fields with normalized keys, copying the slice only if
+// a key changes or a field is dropped
+func (n *normalizer) normalize(fields []zapcore.Field) []zapcore.Field {
+	out := fields
+	copied := false
+	for i, f := range fields {
+		key := n.key(f.Key)
+		if key == f.Key {
+			continue
+		}
+		if !copied {
+			out, copied = slices.Clone(fields), true
+		}
+		out[i].Key = key
+	}
+	if n.config.Dedupe {
+		out = n.dedupe(out, copied)
+	}
+	return out
+}
+
+// dedupe drops the fields whose key, ignoring case, is repeated later in the
+// same namespace
+func (n *normalizer) dedupe(fields []zapcore.Field, owned bool) []zapcore.Field {
+	var drop []int
+	seen := make(map[string]bool, len(fields))
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		switch {
+		case f.Type == zapcore.NamespaceType:
+			// Fields after a namespace are inside it
+			clear(seen)
+		case f.Type == zapcore.SkipType:
+		case seen[strings.ToLower(f.Key)]:
+			drop = append(drop, i)
+		default:
+			seen[strings.ToLower(f.Key)] = true
+		}
+	}
+	if len(drop) == 0 {
+		return fields
+	}
+	if !owned {
+		fields = slices.Clone(fields)
+	}
+	for _, i := range drop {
+		fields = slices.Delete(fields, i, i+1)
+	}
+	return fields
+}
+
+// normalizeCore normalizes field keys before they reach the wrapped core
+type normalizeCore struct {
+	zapcore.Core
+	normalizer *normalizer
+}
+
+// With adds structured context under the normalized keys
+func (c *normalizeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &normalizeCore{Core: c.Core.With(c.normalizer.normalize(fields)), normalizer: c.normalizer}
+}
+
+// Check defers to the wrapped core's level, adding this core
+func (c *normalizeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write normalizes the keys, then writes the fields to the wrapped core
+func (c *normalizeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.normalizer.normalize(fields))
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Option configures a Logger built by NewLogger
+type Option func(*options)
+
+// options holds the settings collected from Option values
+type options struct {
+	level         zap.AtomicLevel
+	console       bool
+	encoder       zapcore.Encoder
+	sinks         []sink.Sink
+	callerSkip    int
+	callerFunc    bool
+	development   bool
+	logfmt        bool
+	layout        string // layoutECS, layoutGCP or "" for zap's
+	gcpProject    string
+	encoderConfig *EncoderConfig
+	resource      sink.Resource
+	redactor      *Redactor
+	schema        *schema
+	fieldTypes    *FieldTypesConfig
+	shape         *ShapeConfig
+	normalizer    *normalizer
+	limits        *Limits
+	stacktrace    *StacktraceConfig
+	fields        []any
+	sampling      *SamplingConfig
+	hooks         []func(zapcore.Entry) error
+	goroutineID   bool
+	sequence      bool
+	entryIDs      bool
+	exitFunc      func(code int)
+	clock         sink.Clock
+	templates     bool
+	logMetrics    *LogMetrics
+	nonBlocking   *NonBlocking
+	flushTimeout  time.Duration
+	outputs       []output
+}
+
+// defaultOptions returns the settings used when no options are given
+func defaultOptions() *options {
+	return &options{
+		level:   zap.NewAtomicLevel(),
+		console: true,
+	}
+}
+
+// WithLevel sets the logger's initial minimum level (default: info)
+func WithLevel(l string) Option {
+	return func(o *options) {
+		o.level = parseLevel(l)
+	}
+}
+
+// WithAtomicLevel makes the logger use lvl, which may be shared with other
+// loggers so that changing it affects all of them
+func WithAtomicLevel(lvl zap.AtomicLevel) Option {
+	return func(o *options) {
+		o.level = lvl
+	}
+}
+
+// parseLevel creates an atomic level from a level name (default: info)
+func parseLevel(l string) zap.AtomicLevel {
+	zapLevel, err := ParseLevel(l)
+	if err != nil {
+		zapLevel = zapcore.InfoLevel
+	}
+	return zap.NewAtomicLevelAt(zapLevel)
+}
+
+// WithConsole enables or disables console output on stderr (default: enabled)
+func WithConsole(enabled bool) Option {
+	return func(o *options) {
+		o.console = enabled
+	}
+}
+
+// WithEncoder sets the encoder used for console output (default: console encoder)
+func WithEncoder(enc zapcore.Encoder) Option {
+	return func(o *options) {
+		o.encoder = enc
+	}
+}
+
+// WithSink adds remote sinks (e.g., Loki, HTTP) the logger writes to
+func WithSink(sinks ...sink.Sink) Option {
+	return func(o *options) {
+		o.sinks = append(o.sinks, sinks...)
+	}
+}
+
+// WithCallerSkip skips additional stack frames when reporting the caller
+func WithCallerSkip(skip int) Option {
+	return func(o *options) {
+		o.callerSkip += skip
+	}
+}
+
+// WithCallerFunction records the calling function's name alongside the
+// caller's file and line
+func WithCallerFunction() Option {
+	return func(o *options) {
+		o.callerFunc = true
+	}
+}
+
+// WithResource sets the service metadata (name, instance, environment,
+// version and attributes) stamped onto every entry sent to sinks
+func WithResource(r sink.Resource) Option {
+	return func(o *options) {
+		o.resource = r
+	}
+}
+
+// WithFields adds key-value pairs to every entry logged by the logger
+func WithFields(args ...any) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, args...)
+	}
+}
+
+// WithSampling logs the first entries with a given level and message each
+// tick, then every thereafter-th entry. Audit entries are never sampled and
+// the settings can be changed later with SetSampling.
+func WithSampling(tick time.Duration, first, thereafter int) Option {
+	return func(o *options) {
+		o.sampling = &SamplingConfig{Tick: tick, First: first, Thereafter: thereafter}
+	}
+}
+
+// WithHooks adds hooks run for every logged entry (e.g., metrics)
+func WithHooks(hooks ...func(zapcore.Entry) error) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// WithClock sets the time source of entry timestamps, and with them of
+// sampling windows, e.g. a sink.ManualClock for stable snapshot tests. Pass
+// the same clock in sink.Config.Clock to control sink flushing too.
+func WithClock(clock sink.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// zapClock adapts a sink.Clock to zapcore.Clock. zap only uses NewTicker for
+// its own sampler, which go-zlog does not use, so it ticks in real time.
+type zapClock struct {
+	sink.Clock
+}
+
+// NewTicker returns a real time ticker
+func (zapClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
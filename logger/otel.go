@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func init() {
+	RegisterContextExtractor(traceFields)
+}
+
+// traceFields returns trace_id, span_id and trace_flags for the active
+// OpenTelemetry span in ctx, if any
+func traceFields(ctx context.Context) []any {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []any{
+		sink.FieldTraceID, sc.TraceID().String(),
+		sink.FieldSpanID, sc.SpanID().String(),
+		sink.FieldTraceFlags, sc.TraceFlags().String(),
+	}
+}
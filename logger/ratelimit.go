@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// callsites holds per-callsite suppression state, keyed by program counter
+var callsites sync.Map
+
+// callsiteState tracks how often a callsite has logged
+type callsiteState struct {
+	mu          sync.Mutex
+	count       uint64
+	windowStart time.Time
+	windowCount int
+}
+
+// callsite returns the state for the caller of the public helper
+func callsite() *callsiteState {
+	pc, _, _, _ := runtime.Caller(2)
+	if st, ok := callsites.Load(pc); ok {
+		return st.(*callsiteState)
+	}
+	st, _ := callsites.LoadOrStore(pc, &callsiteState{})
+	return st.(*callsiteState)
+}
+
+// everyN reports whether this is the 1st, (n+1)th, (2n+1)th... call
+func (s *callsiteState) everyN(n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	return n <= 1 || (s.count-1)%uint64(n) == 0
+}
+
+// once reports whether this is the first call
+func (s *callsiteState) once() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	return s.count == 1
+}
+
+// allow reports whether fewer than perSecond calls were allowed in the
+// current one-second window
+func (s *callsiteState) allow(perSecond int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.windowStart) >= time.Second {
+		s.windowStart = now
+		s.windowCount = 0
+	}
+	if s.windowCount >= perSecond {
+		return false
+	}
+	s.windowCount++
+	return true
+}
+
+// DebugwEveryN logs the 1st and then every n-th call from this callsite
+func (l *Logger) DebugwEveryN(n int, msg string, args ...interface{}) {
+	if callsite().everyN(n) {
+		l.sugar.Debugw(msg, args...)
+	}
+}
+
+// InfowEveryN logs the 1st and then every n-th call from this callsite
+func (l *Logger) InfowEveryN(n int, msg string, args ...interface{}) {
+	if callsite().everyN(n) {
+		l.sugar.Infow(msg, args...)
+	}
+}
+
+// WarnwEveryN logs the 1st and then every n-th call from this callsite
+func (l *Logger) WarnwEveryN(n int, msg string, args ...interface{}) {
+	if callsite().everyN(n) {
+		l.sugar.Warnw(msg, args...)
+	}
+}
+
+// ErrorwEveryN logs the 1st and then every n-th call from this callsite
+func (l *Logger) ErrorwEveryN(n int, msg string, args ...interface{}) {
+	if callsite().everyN(n) {
+		l.sugar.Errorw(msg, args...)
+	}
+}
+
+// DebugwOnce logs only the first call from this callsite
+func (l *Logger) DebugwOnce(msg string, args ...interface{}) {
+	if callsite().once() {
+		l.sugar.Debugw(msg, args...)
+	}
+}
+
+// InfowOnce logs only the first call from this callsite
+func (l *Logger) InfowOnce(msg string, args ...interface{}) {
+	if callsite().once() {
+		l.sugar.Infow(msg, args...)
+	}
+}
+
+// WarnwOnce logs only the first call from this callsite
+func (l *Logger) WarnwOnce(msg string, args ...interface{}) {
+	if callsite().once() {
+		l.sugar.Warnw(msg, args...)
+	}
+}
+
+// ErrorwOnce logs only the first call from this callsite
+func (l *Logger) ErrorwOnce(msg string, args ...interface{}) {
+	if callsite().once() {
+		l.sugar.Errorw(msg, args...)
+	}
+}
+
+// DebugwRateLimited logs at most perSecond calls per second from this callsite
+func (l *Logger) DebugwRateLimited(perSecond int, msg string, args ...interface{}) {
+	if callsite().allow(perSecond) {
+		l.sugar.Debugw(msg, args...)
+	}
+}
+
+// InfowRateLimited logs at most perSecond calls per second from this callsite
+func (l *Logger) InfowRateLimited(perSecond int, msg string, args ...interface{}) {
+	if callsite().allow(perSecond) {
+		l.sugar.Infow(msg, args...)
+	}
+}
+
+// WarnwRateLimited logs at most perSecond calls per second from this callsite
+func (l *Logger) WarnwRateLimited(perSecond int, msg string, args ...interface{}) {
+	if callsite().allow(perSecond) {
+		l.sugar.Warnw(msg, args...)
+	}
+}
+
+// ErrorwRateLimited logs at most perSecond calls per second from this callsite
+func (l *Logger) ErrorwRateLimited(perSecond int, msg string, args ...interface{}) {
+	if callsite().allow(perSecond) {
+		l.sugar.Errorw(msg, args...)
+	}
+}
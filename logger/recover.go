@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// PanicAction selects what Recover does after logging a panic
+type PanicAction int32
+
+const (
+	PanicRepanic  PanicAction = iota // Re-raise the panic (default)
+	PanicExit                        // Exit the process with status 2
+	PanicContinue                    // Swallow the panic and continue
+)
+
+var (
+	panicAction   atomic.Int32
+	panicReporter atomic.Pointer[func(recovered any, stack []byte)]
+)
+
+// SetPanicAction sets what Recover does after logging a panic
+func SetPanicAction(action PanicAction) {
+	panicAction.Store(int32(action))
+}
+
+// SetPanicReporter installs a function (e.g. sending to Sentry) called with
+// every panic recovered by Recover, after it has been logged
+func SetPanicReporter(fn func(recovered any, stack []byte)) {
+	if fn == nil {
+		panicReporter.Store(nil)
+		return
+	}
+	panicReporter.Store(&fn)
+}
+
+// Recover logs a panic at panic level with its stack trace and goroutine ID,
+// reports it to the panic reporter, flushes the logger and then re-panics,
+// exits or continues per SetPanicAction. It must be deferred directly:
+//
+//	defer logger.Recover(l)
+func Recover(l *Logger) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	logPanic(l, r, stack)
+	if fn := panicReporter.Load(); fn != nil {
+		(*fn)(r, stack)
+	}
+	_ = l.Sync()
+
+	switch PanicAction(panicAction.Load()) {
+	case PanicExit:
+		os.Exit(2)
+	case PanicContinue:
+		return
+	default:
+		panic(r)
+	}
+}
+
+// Go runs fn in a new goroutine guarded by Recover
+func Go(l *Logger, fn func()) {
+	go func() {
+		defer Recover(l)
+		fn()
+	}()
+}
+
+// logPanic writes a panic-level entry directly to the core, so that logging
+// it does not itself panic
+func logPanic(l *Logger, r any, stack []byte) {
+	z := l.Desugar()
+	ent := zapcore.Entry{
+		Level:      zapcore.PanicLevel,
+		Time:       time.Now(),
+		LoggerName: l.name,
+		Message:    "recovered panic",
+		Stack:      string(stack),
+	}
+	if ce := z.Core().Check(ent, nil); ce != nil {
+		fields := []zapcore.Field{
+			zap.String("panic", fmt.Sprint(r)),
+			zap.Uint64("goroutine_id", goroutineID()),
+		}
+		if err, ok := r.(error); ok {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+	}
+}
+
+// goroutineID parses the current goroutine's ID from its stack header
+// ("goroutine 123 [running]:"), returning 0 if it cannot be determined
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
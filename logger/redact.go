@@ -0,0 +1,367 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactMode selects how redacted values are rewritten
+type RedactMode int
+
+const (
+	// RedactMask replaces sensitive values with "***"
+	RedactMask RedactMode = iota
+	// RedactRemove drops fields with sensitive keys or values
+	RedactRemove
+	// RedactHash replaces sensitive values with their hex HMAC-SHA256, so
+	// equal values can still be correlated
+	RedactHash
+)
+
+// redactedMask replaces sensitive values in RedactMask mode
+const redactedMask = "***"
+
+// DefaultRedactKeys are field keys commonly holding secrets
+var DefaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey",
+	"authorization", "cookie", "set-cookie", "private_key",
+}
+
+// Value patterns for common sensitive data, for use in RedactConfig.Values
+var (
+	RedactCreditCards  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	RedactEmails       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	RedactBearerTokens = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+)
+
+// RedactConfig configures a Redactor
+type RedactConfig struct {
+	Keys     []string         // Field keys to redact, case-insensitive (e.g., DefaultRedactKeys)
+	Patterns []string         // Glob patterns matched against lowercased keys (e.g., "*_token")
+	Values   []*regexp.Regexp // Patterns redacted inside string values and messages
+	Mode     RedactMode       // How redacted values are rewritten (default: RedactMask)
+	HashKey  []byte           // HMAC key used in RedactHash mode
+}
+
+// Redactor rewrites sensitive fields before entries reach any output. Its
+// rules can be replaced at runtime with Update.
+type Redactor struct {
+	rules atomic.Pointer[redactRules]
+}
+
+// redactRules are the compiled, immutable rules of a Redactor
+type redactRules struct {
+	keys     map[string]struct{}
+	patterns []string
+	values   []*regexp.Regexp
+	mode     RedactMode
+	hashKey  []byte
+}
+
+// NewRedactor creates a Redactor from cfg, validating its key patterns
+func NewRedactor(cfg RedactConfig) (*Redactor, error) {
+	r := &Redactor{}
+	if err := r.Update(cfg); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces the redaction rules, keeping the current rules if cfg is
+// invalid. Entries already being written use the rules they started with.
+func (r *Redactor) Update(cfg RedactConfig) error {
+	rules, err := compileRedactRules(cfg)
+	if err != nil {
+		return err
+	}
+	r.rules.Store(rules)
+	return nil
+}
+
+// compileRedactRules validates and compiles cfg
+func compileRedactRules(cfg RedactConfig) (*redactRules, error) {
+	r := &redactRules{
+		keys:    make(map[string]struct{}, len(cfg.Keys)),
+		values:  cfg.Values,
+		mode:    cfg.Mode,
+		hashKey: cfg.HashKey,
+	}
+	for _, key := range cfg.Keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+	}
+	for _, pattern := range cfg.Patterns {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	if r.mode == RedactHash && len(r.hashKey) == 0 {
+		return nil, fmt.Errorf("redaction hash mode requires a hash key")
+	}
+	return r, nil
+}
+
+// empty reports whether the rules redact nothing
+func (r *redactRules) empty() bool {
+	return len(r.keys) == 0 && len(r.patterns) == 0 && len(r.values) == 0
+}
+
+// WithRedactor redacts the fields and messages of every entry before it
+// reaches any output, including the console. Keys and values are also
+// matched inside errors, objects, arrays and maps, and dotted keys (e.g.
+// "user.password", split on the WithFieldShape separator) match on their
+// last segment, so redaction holds whether or not fields are flattened or
+// nested afterwards.
+func WithRedactor(r *Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// sensitiveKey reports whether key, or its last segment after sep, must be
+// redacted
+func (r *redactRules) sensitiveKey(key, sep string) bool {
+	key = strings.ToLower(key)
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	if i := strings.LastIndex(key, sep); sep != "" && i >= 0 {
+		return r.sensitiveKey(key[i+len(sep):], sep)
+	}
+	return false
+}
+
+// replace returns the redacted form of value
+func (r *redactRules) replace(value string) string {
+	if r.mode == RedactHash {
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	return redactedMask
+}
+
+// redactString redacts the value patterns in s, reporting whether any matched
+func (r *redactRules) redactString(s string) (string, bool) {
+	matched := false
+	for _, re := range r.values {
+		if !re.MatchString(s) {
+			continue
+		}
+		matched = true
+		s = re.ReplaceAllStringFunc(s, r.replace)
+	}
+	return s, matched
+}
+
+// redactField returns the redacted form of f, whether to keep it and
+// whether it was changed
+func (r *redactRules) redactField(f zapcore.Field, sep string) (zapcore.Field, bool, bool) {
+	if f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType {
+		return f, true, false
+	}
+	if r.sensitiveKey(f.Key, sep) {
+		if r.mode == RedactRemove {
+			return f, false, true
+		}
+		return zap.String(f.Key, r.replace(fieldString(f))), true, true
+	}
+
+	var s string
+	switch f.Type {
+	case zapcore.StringType:
+		s = f.String
+	case zapcore.ByteStringType:
+		s = string(f.Interface.([]byte))
+	case zapcore.ErrorType:
+		err, ok := f.Interface.(error)
+		if !ok || err == nil {
+			return f, true, false
+		}
+		redacted, matched := r.redactString(err.Error())
+		if !matched {
+			return f, true, false
+		}
+		if r.mode == RedactRemove {
+			return f, false, true
+		}
+		// A plain error, so outputs cannot expand the original causes
+		return zap.NamedError(f.Key, errors.New(redacted)), true, true
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType,
+		zapcore.StringerType, zapcore.ReflectType:
+		return r.redactNested(f, sep)
+	default:
+		return f, true, false
+	}
+	redacted, matched := r.redactString(s)
+	if !matched {
+		return f, true, false
+	}
+	if r.mode == RedactRemove {
+		return f, false, true
+	}
+	return zap.String(f.Key, redacted), true, true
+}
+
+// redactNested redacts the keys and string values inside f, rewriting it
+// only if any of them matched
+func (r *redactRules) redactNested(f zapcore.Field, sep string) (zapcore.Field, bool, bool) {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	if f.Type == zapcore.InlineMarshalerType {
+		// Inline objects add their keys next to the other fields
+		v, _, changed := r.redactValue(snapshotValue(enc.Fields), sep)
+		if !changed {
+			return f, true, false
+		}
+		return zap.Inline(redactedObject(v.(map[string]any))), true, true
+	}
+	v, keep, changed := r.redactValue(snapshotValue(enc.Fields[f.Key]), sep)
+	if !changed {
+		return f, true, false
+	}
+	return zap.Any(f.Key, v), keep, true
+}
+
+// redactValue redacts a value decoded from a nested field in place,
+// returning it, whether to keep it and whether it changed
+func (r *redactRules) redactValue(v any, sep string) (any, bool, bool) {
+	switch v := v.(type) {
+	case string:
+		redacted, matched := r.redactString(v)
+		return redacted, !matched || r.mode != RedactRemove, matched
+	case map[string]any:
+		changed := false
+		for k, val := range v {
+			if r.sensitiveKey(k, sep) {
+				if r.mode == RedactRemove {
+					delete(v, k)
+				} else {
+					v[k] = r.replace(fmt.Sprint(val))
+				}
+				changed = true
+				continue
+			}
+			redacted, keep, ok := r.redactValue(val, sep)
+			switch {
+			case !keep:
+				delete(v, k)
+			case ok:
+				v[k] = redacted
+			}
+			changed = changed || ok
+		}
+		return v, true, changed
+	case []any:
+		out, changed := v[:0], false
+		for _, val := range v {
+			redacted, keep, ok := r.redactValue(val, sep)
+			if keep {
+				out = append(out, redacted)
+			}
+			changed = changed || ok
+		}
+		return out, true, changed
+	}
+	return v, true, false
+}
+
+// redactedObject encodes the redacted keys of an inline object
+type redactedObject map[string]any
+
+// MarshalLogObject adds the keys in sorted order
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, k := range slices.Sorted(maps.Keys(o)) {
+		zap.Any(k, o[k]).AddTo(enc)
+	}
+	return nil
+}
+
+// fields redacts fields, returning the input slice when nothing changed
+func (r *redactRules) fields(fields []zapcore.Field, sep string) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		redacted, keep, changed := r.redactField(f, sep)
+		if changed && out == nil {
+			out = make([]zapcore.Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		if out != nil && keep {
+			out = append(out, redacted)
+		}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// wrap returns core with redaction applied to its fields and messages, with
+// dotted keys split on sep
+func (r *Redactor) wrap(core zapcore.Core, sep string) zapcore.Core {
+	return &redactCore{Core: core, redactor: r, sep: sep}
+}
+
+// fieldString formats a field's value for hashing
+func fieldString(f zapcore.Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+	return fmt.Sprint(fieldValue(f))
+}
+
+// redactCore redacts fields added with With and at the log site before
+// passing them to the wrapped output core
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+	sep      string // Separator of dotted keys
+}
+
+// With redacts the context fields once, when they are added, so context
+// fields keep the rules in force at that time
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	rules := c.redactor.rules.Load()
+	if !rules.empty() {
+		fields = rules.fields(fields, c.sep)
+	}
+	return &redactCore{Core: c.Core.With(fields), redactor: c.redactor, sep: c.sep}
+}
+
+// Check adds this core (not the wrapped one) so that Write redacts
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write redacts the message and fields, then writes to the wrapped core
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	rules := c.redactor.rules.Load()
+	if rules.empty() {
+		return c.Core.Write(ent, fields)
+	}
+	if msg, matched := rules.redactString(ent.Message); matched {
+		ent.Message = msg
+	}
+	return c.Core.Write(ent, rules.fields(fields, c.sep))
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig configures sampling: the first entries with a given level
+// and message are logged each tick, then every thereafter-th entry
+type SamplingConfig struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+}
+
+// Sampling bounds used to index the counters
+const (
+	sampledLevels    = int(zapcore.FatalLevel-TraceLevel) + 1
+	countersPerLevel = 4096
+)
+
+// sampler holds the sampling settings and counters shared by a logger and its
+// children. Unlike zap's sampler its settings can change at runtime, and
+// audit entries are never sampled.
+type sampler struct {
+	state atomic.Pointer[samplerState] // nil when sampling is disabled
+}
+
+// samplerState is an immutable sampling configuration with its counters
+type samplerState struct {
+	config SamplingConfig
+	counts [sampledLevels][countersPerLevel]sampleCounter
+}
+
+// sampleCounter counts entries within the current tick
+type sampleCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+// set replaces the sampling settings, resetting the counters; nil disables sampling
+func (s *sampler) set(cfg *SamplingConfig) {
+	if cfg == nil {
+		s.state.Store(nil)
+		return
+	}
+	s.state.Store(&samplerState{config: *cfg})
+}
+
+// config returns the current settings, or nil when sampling is disabled
+func (s *sampler) config() *SamplingConfig {
+	st := s.state.Load()
+	if st == nil {
+		return nil
+	}
+	cfg := st.config
+	return &cfg
+}
+
+// sample reports whether ent should be logged
+func (s *sampler) sample(ent zapcore.Entry) bool {
+	st := s.state.Load()
+	if st == nil || ent.Level < TraceLevel || ent.Level > zapcore.FatalLevel {
+		return true
+	}
+	counter := &st.counts[ent.Level-TraceLevel][fnv32a(ent.Message)%countersPerLevel]
+	n := counter.inc(ent.Time, st.config.Tick)
+	first, thereafter := uint64(st.config.First), uint64(st.config.Thereafter)
+	return n <= first || (thereafter > 0 && (n-first)%thereafter == 0)
+}
+
+// inc increments the counter, starting a new tick if the current one is over
+func (c *sampleCounter) inc(t time.Time, tick time.Duration) uint64 {
+	now := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.n.Add(1)
+	}
+	c.n.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick.Nanoseconds()) {
+		// Another goroutine started the tick
+		return c.n.Add(1)
+	}
+	return 1
+}
+
+// fnv32a hashes s with FNV-1a
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= prime32
+	}
+	return h
+}
+
+// samplerCore drops entries rejected by the sampler
+type samplerCore struct {
+	zapcore.Core
+	sampler *sampler
+}
+
+// With adds structured context, sharing the sampler's counters
+func (c *samplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplerCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+// Check samples enabled entries before deferring to the wrapped core
+func (c *samplerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) || !c.sampler.sample(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// SetSampling changes the sampling of this logger, its children and its
+// parent at runtime, resetting the counters; nil disables sampling
+func (l *Logger) SetSampling(cfg *SamplingConfig) {
+	l.sampler.set(cfg)
+}
+
+// Sampling returns the current sampling settings, or nil when disabled
+func (l *Logger) Sampling() *SamplingConfig {
+	return l.sampler.config()
+}
@@ -0,0 +1,49 @@
+// Package saramazlog adapts a go-zlog Logger to the Sarama Kafka client's
+// StdLogger interface (Print, Printf, Println), so its internal logs flow
+// through the structured pipeline:
+//
+//	sarama.Logger = saramazlog.New(l, "debug")
+package saramazlog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger implements sarama.StdLogger
+type Logger struct {
+	logger *zap.SugaredLogger
+	level  zapcore.Level
+}
+
+// New returns a Sarama logger writing through l at the given level
+// (default: debug) under the "sarama" logger name
+func New(l *logger.Logger, level string) *Logger {
+	lvl, err := logger.ParseLevel(level)
+	if err != nil {
+		lvl = zapcore.DebugLevel
+	}
+	return &Logger{
+		logger: l.Named("sarama").(*logger.Logger).Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar(),
+		level:  lvl,
+	}
+}
+
+// Print logs args formatted like fmt.Sprint
+func (s *Logger) Print(v ...any) {
+	s.logger.Logw(s.level, strings.TrimSuffix(fmt.Sprint(v...), "\n"))
+}
+
+// Printf logs args formatted like fmt.Sprintf
+func (s *Logger) Printf(format string, v ...any) {
+	s.logger.Logw(s.level, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// Println logs args formatted like fmt.Sprintln
+func (s *Logger) Println(v ...any) {
+	s.logger.Logw(s.level, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SchemaConfig configures strict schema mode for remote sinks
+type SchemaConfig struct {
+	Allow    []string // Field keys shipped to sinks; all other fields are dropped
+	CountKey string   // If set, the number of dropped fields is added under this key (e.g., "dropped_fields")
+}
+
+// WithStrictSchema ships only allowlisted fields to remote sinks, for
+// compliance environments. Console and file outputs still get every field.
+// Fields inside a namespace are shipped only if the namespace key is allowed.
+func WithStrictSchema(cfg SchemaConfig) Option {
+	return func(o *options) {
+		allow := make(map[string]struct{}, len(cfg.Allow))
+		for _, key := range cfg.Allow {
+			allow[key] = struct{}{}
+		}
+		o.schema = &schema{allow: allow, countKey: cfg.CountKey}
+	}
+}
+
+// schema holds the compiled strict schema settings
+type schema struct {
+	allow    map[string]struct{}
+	countKey string
+}
+
+// wrap returns core restricted to the schema
+func (s *schema) wrap(core zapcore.Core) zapcore.Core {
+	return &schemaCore{Core: core, schema: s}
+}
+
+// schemaCore drops fields outside the schema before passing them to the
+// wrapped sink core
+type schemaCore struct {
+	zapcore.Core
+	schema    *schema
+	dropped   int  // Fields dropped from the context added with With
+	inDropped bool // A dropped namespace was opened with With
+}
+
+// filter returns the allowed fields, the number dropped and whether a dropped
+// namespace is open after them
+func (c *schemaCore) filter(fields []zapcore.Field) ([]zapcore.Field, int, bool) {
+	out := make([]zapcore.Field, 0, len(fields))
+	dropped, inDropped := 0, c.inDropped
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			if isCarried(f) {
+				out = append(out, f)
+			}
+			continue
+		}
+		_, allowed := c.schema.allow[f.Key]
+		switch {
+		case inDropped:
+			dropped++
+		case f.Type == zapcore.NamespaceType && !allowed:
+			inDropped = true
+		case allowed || f.Type == zapcore.NamespaceType:
+			out = append(out, f)
+		default:
+			dropped++
+		}
+	}
+	return out, dropped, inDropped
+}
+
+// With filters the context fields once, when they are added
+func (c *schemaCore) With(fields []zapcore.Field) zapcore.Core {
+	allowed, dropped, inDropped := c.filter(fields)
+	return &schemaCore{
+		Core:      c.Core.With(allowed),
+		schema:    c.schema,
+		dropped:   c.dropped + dropped,
+		inDropped: inDropped,
+	}
+}
+
+// Check adds this core (not the wrapped one) so that Write filters
+func (c *schemaCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write filters the fields, then writes to the wrapped core
+func (c *schemaCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	allowed, dropped, _ := c.filter(fields)
+	if dropped += c.dropped; dropped > 0 && c.schema.countKey != "" {
+		allowed = append(allowed, zap.Int(c.schema.countKey, dropped))
+	}
+	return c.Core.Write(ent, allowed)
+}
@@ -0,0 +1,300 @@
+package logger
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ShapeMode selects how WithFieldShape reshapes fields
+type ShapeMode string
+
+// Shape modes
+const (
+	ShapeFlatten ShapeMode = "flatten" // Nested maps and namespaces become dotted keys
+	ShapeNest    ShapeMode = "nest"    // Dotted keys become nested objects
+)
+
+// ShapeConfig configures field flattening or nesting
+type ShapeConfig struct {
+	Mode      ShapeMode // ShapeFlatten or ShapeNest
+	Separator string    // Key separator (default: ".")
+	MaxDepth  int       // Maximum key segments; deeper structure is kept as a value (default: 10)
+}
+
+// WithFieldShape flattens nested fields into dotted keys
+// ({"http": {"request": {"method": "GET"}}} becomes "http.request.method")
+// or nests dotted keys into objects before entries reach any output, so
+// every encoder and sink sees the same layout. Flattening expands maps with
+// string keys, objects and namespaces; nesting merges the context fields
+// with the entry's, and a dotted key colliding with a plain one is kept as
+// logged. MaxDepth bounds the work done on deep or cyclic maps.
+func WithFieldShape(cfg ShapeConfig) Option {
+	return func(o *options) {
+		if cfg.Separator == "" {
+			cfg.Separator = "."
+		}
+		if cfg.MaxDepth <= 0 {
+			cfg.MaxDepth = 10
+		}
+		o.shape = &cfg
+	}
+}
+
+// wrap returns core with the fields reshaped
+func (s *ShapeConfig) wrap(core zapcore.Core) zapcore.Core {
+	switch s.Mode {
+	case ShapeFlatten:
+		return &flattenCore{Core: core, shape: s}
+	case ShapeNest:
+		return &nestCore{Core: core, shape: s}
+	}
+	return core
+}
+
+// flattenCore replaces nested fields with dotted keys before passing them to
+// the wrapped core
+type flattenCore struct {
+	zapcore.Core
+	shape  *ShapeConfig
+	prefix string // Key prefix of the namespaces opened with With
+	depth  int    // Segments in prefix
+}
+
+// key joins a prefix and a key
+func (c *flattenCore) key(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + c.shape.Separator + key
+}
+
+// flatten returns the flattened fields, and the prefix and depth of the
+// namespaces opened in them
+func (c *flattenCore) flatten(fields []zapcore.Field) ([]zapcore.Field, string, int) {
+	out := make([]zapcore.Field, 0, len(fields))
+	prefix, depth := c.prefix, c.depth
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.SkipType:
+			if isCarried(f) {
+				out = append(out, f)
+			}
+		case zapcore.NamespaceType:
+			prefix, depth = c.key(prefix, f.Key), depth+1
+		case zapcore.InlineMarshalerType:
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			out = c.flattenMap(out, prefix, enc.Fields, depth)
+		case zapcore.ObjectMarshalerType, zapcore.ReflectType:
+			out = c.flattenValue(out, c.key(prefix, f.Key), fieldValue(f), depth+1)
+		default:
+			f.Key = c.key(prefix, f.Key)
+			out = append(out, f)
+		}
+	}
+	return out, prefix, depth
+}
+
+// flattenValue appends the leaves of v under key, which has depth segments
+func (c *flattenCore) flattenValue(out []zapcore.Field, key string, v any, depth int) []zapcore.Field {
+	if m, ok := stringMap(v); ok && len(m) > 0 && depth < c.shape.MaxDepth {
+		return c.flattenMap(out, key, m, depth)
+	}
+	return append(out, zap.Any(key, v))
+}
+
+// flattenMap appends the leaves of m under prefix in key order
+func (c *flattenCore) flattenMap(out []zapcore.Field, prefix string, m map[string]any, depth int) []zapcore.Field {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		out = c.flattenValue(out, c.key(prefix, k), m[k], depth+1)
+	}
+	return out
+}
+
+// stringMap returns v as a map[string]any if it is a map with string keys
+func stringMap(v any) (map[string]any, bool) {
+	if m, ok := v.(map[string]any); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]any, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// With flattens the context fields once, when they are added
+func (c *flattenCore) With(fields []zapcore.Field) zapcore.Core {
+	flat, prefix, depth := c.flatten(fields)
+	return &flattenCore{Core: c.Core.With(flat), shape: c.shape, prefix: prefix, depth: depth}
+}
+
+// Check adds this core (not the wrapped one) so that Write flattens the fields
+func (c *flattenCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write flattens the fields, then writes to the wrapped core
+func (c *flattenCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	flat, _, _ := c.flatten(fields)
+	return c.Core.Write(ent, flat)
+}
+
+// nestCore replaces dotted keys with nested objects before passing them to
+// the wrapped core. Context fields are held back until an entry is written,
+// so that they nest together with the entry's fields.
+type nestCore struct {
+	zapcore.Core
+	shape   *ShapeConfig
+	pending []zapcore.Field // Context fields not yet passed to the wrapped core
+	opened  bool            // A namespace was opened with With; later fields are kept as logged
+}
+
+// nest returns fields with the dotted keys nested into objects, placed where
+// the first of their keys was
+func (c *nestCore) nest(fields []zapcore.Field) []zapcore.Field {
+	plain := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if !strings.Contains(f.Key, c.shape.Separator) {
+			plain[f.Key] = true
+		}
+	}
+
+	out := make([]zapcore.Field, 0, len(fields))
+	roots := make(map[string]*nestNode)
+	at := make(map[string]int) // Index in out of each root
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			if isCarried(f) {
+				out = append(out, f)
+			}
+			continue
+		}
+		parts := strings.SplitN(f.Key, c.shape.Separator, c.shape.MaxDepth)
+		if len(parts) == 1 || plain[parts[0]] {
+			out = append(out, f)
+			continue
+		}
+		root, ok := roots[parts[0]]
+		if !ok {
+			root = &nestNode{}
+		}
+		if !root.insert(parts[1:], fieldValue(f)) {
+			out = append(out, f)
+			continue
+		}
+		if !ok {
+			roots[parts[0]] = root
+			at[parts[0]] = len(out)
+			out = append(out, zap.Skip())
+		}
+	}
+	for key, i := range at {
+		out[i] = zap.Any(key, roots[key].object())
+	}
+	return out
+}
+
+// nestNode is an object built from dotted keys, or a value in one
+type nestNode struct {
+	children map[string]*nestNode // Object members, nil for values
+	value    any
+}
+
+// insert sets the value at the path below n, reporting false if a value
+// already occupies part of the path
+func (n *nestNode) insert(path []string, v any) bool {
+	if n.children == nil {
+		n.children = make(map[string]*nestNode)
+	}
+	child, ok := n.children[path[0]]
+	if len(path) == 1 {
+		if ok && child.children != nil {
+			return false
+		}
+		n.children[path[0]] = &nestNode{value: v}
+		return true
+	}
+	if !ok {
+		child = &nestNode{}
+		if !child.insert(path[1:], v) {
+			return false
+		}
+		n.children[path[0]] = child
+		return true
+	}
+	if child.children == nil {
+		return false
+	}
+	return child.insert(path[1:], v)
+}
+
+// object returns the map of an object node, or the value of a value node
+func (n *nestNode) object() any {
+	if n.children == nil {
+		return n.value
+	}
+	m := make(map[string]any, len(n.children))
+	for k, child := range n.children {
+		m[k] = child.object()
+	}
+	return m
+}
+
+// splitNamespace returns the fields before the first namespace and the rest
+func splitNamespace(fields []zapcore.Field) ([]zapcore.Field, []zapcore.Field) {
+	for i, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			return fields[:i], fields[i:]
+		}
+	}
+	return fields, nil
+}
+
+// With holds the context fields back until a namespace is opened, when they
+// are nested and passed to the wrapped core
+func (c *nestCore) With(fields []zapcore.Field) zapcore.Core {
+	if c.opened {
+		return &nestCore{Core: c.Core.With(fields), shape: c.shape, opened: true}
+	}
+	before, after := splitNamespace(fields)
+	pending := append(c.pending[:len(c.pending):len(c.pending)], before...)
+	if after == nil {
+		return &nestCore{Core: c.Core, shape: c.shape, pending: pending}
+	}
+	return &nestCore{Core: c.Core.With(append(c.nest(pending), after...)), shape: c.shape, opened: true}
+}
+
+// Check adds this core (not the wrapped one) so that Write nests the fields
+func (c *nestCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write nests the context and entry fields, then writes to the wrapped core
+func (c *nestCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.opened {
+		return c.Core.Write(ent, fields)
+	}
+	before, after := splitNamespace(fields)
+	all := append(c.pending[:len(c.pending):len(c.pending)], before...)
+	return c.Core.Write(ent, append(c.nest(all), after...))
+}
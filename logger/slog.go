@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler is a slog.Handler writing through a Logger
+type slogHandler struct {
+	logger *zap.Logger
+	prefix string // Dotted group prefix for attribute keys
+}
+
+// NewSlogHandler returns a slog.Handler backed by l. Levels map to the
+// nearest zap level, groups become dotted key prefixes, and attrs become
+// fields. Fields carried by the context (see WithContext) are included.
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l.Desugar()}
+}
+
+// Enabled reports whether the logger emits entries at the given level
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(slogToZapLevel(level))
+}
+
+// Handle writes the record with its attrs and context fields
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ce := h.logger.Check(slogToZapLevel(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		ce.Time = r.Time
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ce.Caller.Function = frame.Function
+	}
+
+	fields := sweetenFields(ContextFields(ctx))
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, attr)
+		return true
+	})
+
+	ce.Write(fields...)
+	return nil
+}
+
+// WithAttrs returns a handler whose entries include attrs
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendAttr(fields, h.prefix, attr)
+	}
+	return &slogHandler{logger: h.logger.With(fields...), prefix: h.prefix}
+}
+
+// WithGroup returns a handler that prefixes subsequent attr keys with name
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// appendAttr converts a slog attr to zap fields, flattening groups
+func appendAttr(fields []zapcore.Field, prefix string, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	key := prefix + attr.Key
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = key + "."
+		}
+		for _, a := range attr.Value.Group() {
+			fields = appendAttr(fields, groupPrefix, a)
+		}
+		return fields
+	case slog.KindString:
+		return append(fields, zap.String(key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(key, attr.Value.Time()))
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(key, err))
+		}
+		return append(fields, zap.Any(key, attr.Value.Any()))
+	}
+}
+
+// sweetenFields converts loosely typed key-value pairs to zap fields
+func sweetenFields(args []any) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+		fields = append(fields, zap.Any(key, args[i+1]))
+	}
+	return fields
+}
+
+// slogToZapLevel maps a slog level to the nearest zap level
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return TraceLevel
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StacktraceConfig selects the levels that capture stack traces and trims
+// the captured traces
+type StacktraceConfig struct {
+	// Level is the lowest level capturing stack traces, e.g. "error" in
+	// production or "warn" in staging ("" = none). Audit entries only
+	// capture them when Level is "audit".
+	Level string
+
+	MaxFrames     int      // Frames kept after trimming (0 = all)
+	SkipRuntime   bool     // Drop runtime.* frames, e.g. runtime.main and runtime.goexit
+	SkipVendor    bool     // Drop frames of files under a vendor directory
+	SkipFunctions []string // Drop frames whose function starts with one of these prefixes
+}
+
+// WithStacktrace sets the stack trace policy, replacing the defaults (none,
+// or error to fatal with WithDevelopment). An unknown level disables stack
+// traces.
+func WithStacktrace(cfg StacktraceConfig) Option {
+	return func(o *options) {
+		o.stacktrace = &cfg
+	}
+}
+
+// enabler returns the levels capturing stack traces
+func (s *StacktraceConfig) enabler() zapcore.LevelEnabler {
+	if s.Level == "" {
+		return noStacktrace
+	}
+	min, err := ParseLevel(s.Level)
+	if err != nil {
+		return noStacktrace
+	}
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= min && (l != AuditLevel || min == AuditLevel)
+	})
+}
+
+// trims reports whether captured stack traces are rewritten
+func (s *StacktraceConfig) trims() bool {
+	return s.MaxFrames > 0 || s.SkipRuntime || s.SkipVendor || len(s.SkipFunctions) > 0
+}
+
+// wrap returns core with stack traces trimmed
+func (s *StacktraceConfig) wrap(core zapcore.Core) zapcore.Core {
+	return &stackCore{Core: core, config: s}
+}
+
+// trim returns the frames of a zap stack trace ("function\n\tfile:line"
+// per frame) kept by the policy
+func (s *StacktraceConfig) trim(stack string) string {
+	lines := strings.Split(stack, "\n")
+	var b strings.Builder
+	kept := 0
+	for i := 0; i+1 < len(lines); i += 2 {
+		fn, file := lines[i], lines[i+1]
+		if s.skip(fn, file) {
+			continue
+		}
+		if s.MaxFrames > 0 && kept == s.MaxFrames {
+			break
+		}
+		if kept > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(fn)
+		b.WriteByte('\n')
+		b.WriteString(file)
+		kept++
+	}
+	return b.String()
+}
+
+// skip reports whether a frame is dropped
+func (s *StacktraceConfig) skip(fn, file string) bool {
+	if s.SkipRuntime && strings.HasPrefix(fn, "runtime.") {
+		return true
+	}
+	if s.SkipVendor && strings.Contains(file, "/vendor/") {
+		return true
+	}
+	for _, prefix := range s.SkipFunctions {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackCore trims entry stack traces before passing entries to the wrapped
+// core
+type stackCore struct {
+	zapcore.Core
+	config *StacktraceConfig
+}
+
+// With adds structured context to the wrapped core
+func (c *stackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stackCore{Core: c.Core.With(fields), config: c.config}
+}
+
+// Check defers to the wrapped core's level, adding this core
+func (c *stackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write trims the stack trace, then writes to the wrapped core
+func (c *stackCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Stack != "" {
+		ent.Stack = c.config.trim(ent.Stack)
+	}
+	return c.Core.Write(ent, fields)
+}
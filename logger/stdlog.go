@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stdLogCallerSkip skips log.Logger.Output, log.Logger.Print* and lineWriter.Write
+const stdLogCallerSkip = 3
+
+// StdLogAt returns a standard library *log.Logger that writes each line
+// through l at the given level (default: info), e.g. for http.Server.ErrorLog
+func StdLogAt(l *Logger, level string) *log.Logger {
+	w := &lineWriter{
+		logger: l.Desugar().WithOptions(zap.AddCallerSkip(stdLogCallerSkip)),
+		level:  parseLevel(level).Level(),
+	}
+	return log.New(w, "", 0)
+}
+
+// Writer returns an io.Writer logging each line written to it through the
+// default Log at the given level (default: info)
+func Writer(level string) io.Writer {
+	return FromContext(context.Background()).Writer(level)
+}
+
+// Writer returns an io.Writer logging each line written to it at the given
+// level (default: info)
+func (l *Logger) Writer(level string) io.Writer {
+	return &lineWriter{
+		logger: l.Desugar().WithOptions(zap.AddCallerSkip(1)),
+		level:  parseLevel(level).Level(),
+	}
+}
+
+// lineWriter logs every line written to it as a separate entry
+type lineWriter struct {
+	logger *zap.Logger
+	level  zapcore.Level
+}
+
+// Write logs each non-empty line in p
+func (w *lineWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) == 0 {
+			continue
+		}
+		if ce := w.logger.Check(w.level, string(line)); ce != nil {
+			ce.Write()
+		}
+	}
+	return len(p), nil
+}
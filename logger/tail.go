@@ -0,0 +1,255 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TailConfig configures request-scoped buffering (see StartTail)
+type TailConfig struct {
+	Capacity int           // Entries buffered per request and output, oldest dropped first (default: 256)
+	Latency  time.Duration // Keep the entries of requests slower than this (0 = only failed requests)
+	Level    string        // Entries at or above this level are written and keep the request's entries (default: error)
+}
+
+// Tail holds the buffered entries of one request until it ends. Entries below
+// the trigger level are buffered; an entry at or above it writes the buffered
+// entries and everything after it. When the request ends, its entries are
+// written if it failed or was slower than the latency threshold, otherwise
+// they are discarded. Console outputs are never buffered.
+type Tail struct {
+	capacity int
+	latency  time.Duration
+	trigger  zapcore.Level
+	start    time.Time
+
+	mu       sync.Mutex
+	entries  []tailEntry // Ring of buffered entries
+	next     int         // Index of the oldest entry once the ring is full
+	dropped  int         // Entries dropped from the full ring
+	done     bool        // Entries are no longer buffered
+	released bool        // Buffered entries were written
+}
+
+// tailEntry is a buffered entry and the output core it was logged through
+type tailEntry struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// StartTail buffers the entries logged through the context's logger (see
+// FromContext) for one request, so debug and info entries are only shipped
+// for requests that fail or are slow. Call End when the request completes:
+//
+//	ctx, tail := logger.StartTail(ctx, logger.TailConfig{Latency: time.Second})
+//	err := handle(ctx)
+//	tail.End(err != nil)
+func StartTail(ctx context.Context, cfg TailConfig) (context.Context, *Tail) {
+	l, t := NewTail(FromContext(ctx), cfg)
+	return NewContext(ctx, l), t
+}
+
+// NewTail returns a child of l whose entries are buffered by the returned
+// Tail. Console outputs still write every entry as it is logged, so local
+// debugging is unaffected. An invalid trigger level falls back to error.
+func NewTail(l *Logger, cfg TailConfig) (*Logger, *Tail) {
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = 256
+	}
+	trigger := zapcore.ErrorLevel
+	if lvl, err := ParseLevel(cfg.Level); err == nil && cfg.Level != "" {
+		trigger = lvl
+	}
+	t := &Tail{
+		capacity: cfg.Capacity,
+		latency:  cfg.Latency,
+		trigger:  trigger,
+		start:    time.Now(),
+	}
+	// The field reaches the tailCore of each buffered output through With
+	tf := zap.Field{Key: "tail", Type: zapcore.SkipType, Interface: t}
+	return newLogger(l.SugaredLogger.With(tf), l.cores, l.sampler, l.level, l.name), t
+}
+
+// End stops buffering, writing the buffered entries if failed is set or the
+// request took longer than the latency threshold, else discarding them.
+// Entries logged after End are written directly.
+func (t *Tail) End(failed bool) {
+	if failed || t.latency > 0 && time.Since(t.start) > t.latency {
+		t.Release()
+		return
+	}
+	t.Discard()
+}
+
+// Release writes the buffered entries and stops buffering
+func (t *Tail) Release() {
+	t.mu.Lock()
+	entries := t.drain()
+	t.done, t.released = true, true
+	t.mu.Unlock()
+
+	for _, e := range entries {
+		if ce := e.core.Check(e.ent, nil); ce != nil {
+			ce.Write(e.fields...)
+		}
+	}
+}
+
+// Discard drops the buffered entries and stops buffering
+func (t *Tail) Discard() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropped += len(t.entries)
+	t.entries, t.next = nil, 0
+	t.done = true
+}
+
+// Released reports whether the buffered entries were written
+func (t *Tail) Released() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.released
+}
+
+// Dropped returns the number of entries dropped from the full buffer or by
+// Discard
+func (t *Tail) Dropped() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// buffer stores an entry, reporting false if buffering has stopped
+func (t *Tail) buffer(e tailEntry) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return false
+	}
+	if len(t.entries) < t.capacity {
+		t.entries = append(t.entries, e)
+		return true
+	}
+	t.entries[t.next] = e
+	t.next = (t.next + 1) % t.capacity
+	t.dropped++
+	return true
+}
+
+// drain returns the buffered entries, oldest first, and empties the buffer
+func (t *Tail) drain() []tailEntry {
+	entries := append(t.entries[t.next:len(t.entries):len(t.entries)], t.entries[:t.next]...)
+	t.entries, t.next = nil, 0
+	return entries
+}
+
+// buffering reports whether entries are still buffered
+func (t *Tail) buffering() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.done
+}
+
+// tailCore wraps every output but the console, buffering the entries of a
+// request once a Tail field is added through With (see NewTail)
+type tailCore struct {
+	zapcore.Core
+	tail *Tail // Nil outside requests
+}
+
+// With adds structured context, starting to buffer if fields hold a Tail
+func (c *tailCore) With(fields []zapcore.Field) zapcore.Core {
+	t := c.tail
+	for _, f := range fields {
+		if ft, ok := f.Interface.(*Tail); ok && f.Type == zapcore.SkipType {
+			t = ft
+		}
+	}
+	return &tailCore{Core: c.Core.With(fields), tail: t}
+}
+
+// Check buffers entries below the trigger level; entries at or above it
+// release the buffer first. Audit entries are never buffered.
+func (c *tailCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.tail == nil || !c.Core.Enabled(ent.Level) || !c.tail.buffering() {
+		return c.Core.Check(ent, ce)
+	}
+	if ent.Level < c.tail.trigger {
+		return ce.AddCore(ent, c)
+	}
+	if ent.Level != AuditLevel {
+		c.tail.Release()
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// Write buffers the entry, or writes it if buffering stopped since Check.
+// Buffered fields are snapshotted, since their values may change before the
+// request ends.
+func (c *tailCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.tail == nil {
+		return c.Core.Write(ent, fields)
+	}
+	e := tailEntry{core: c.Core, ent: ent, fields: snapshotFields(fields)}
+	if c.tail.buffer(e) {
+		return nil
+	}
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// snapshotFields copies fields, resolving the values zap would otherwise read
+// when the entry is written: marshalers, stringers and reflected values
+// (including Lazy) are encoded now and kept as copies. Errors are kept as is
+// so outputs can still expand them.
+func snapshotFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType,
+			zapcore.StringerType, zapcore.ReflectType:
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			for _, k := range slices.Sorted(maps.Keys(enc.Fields)) {
+				out = append(out, zap.Any(k, snapshotValue(enc.Fields[k])))
+			}
+		default:
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// snapshotValue returns v, or a deep copy of it through JSON if it may hold
+// references (numbers keep their precision as json.Number)
+func snapshotValue(v any) any {
+	switch v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, complex64, complex128, time.Time, time.Duration:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var copied any
+	if err := dec.Decode(&copied); err != nil {
+		return string(b)
+	}
+	return copied
+}
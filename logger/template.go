@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithMessageTemplates renders {key} placeholders in messages with the
+// values of the entry's fields and keeps the raw template in the
+// msg_template field, so backends can group entries by template:
+//
+//	l.Warnw("user {user_id} failed login", "user_id", 42)
+//	// msg="user 42 failed login" msg_template="user {user_id} failed login" user_id=42
+//
+// Placeholders without a matching field are left as-is. Values are rendered
+// after redaction, so redacted fields stay redacted in the message.
+func WithMessageTemplates() Option {
+	return func(o *options) {
+		o.templates = true
+	}
+}
+
+// templateCore renders message templates before writing entries
+type templateCore struct {
+	zapcore.Core
+	context []zapcore.Field // Fields added with With, looked up after the entry's
+}
+
+// With adds structured context to the core
+func (c *templateCore) With(fields []zapcore.Field) zapcore.Core {
+	ctx := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	ctx = append(append(ctx, c.context...), fields...)
+	return &templateCore{Core: c.Core.With(fields), context: ctx}
+}
+
+// Check adds this core for enabled levels
+func (c *templateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write renders the message, adding the template field if any placeholder
+// was replaced
+func (c *templateCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if msg, ok := c.render(ent.Message, fields); ok {
+		fields = append(fields[:len(fields):len(fields)], zap.String(sink.FieldMessageTemplate, ent.Message))
+		ent.Message = msg
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// render replaces the {key} placeholders of msg, reporting whether any was
+// replaced
+func (c *templateCore) render(msg string, fields []zapcore.Field) (string, bool) {
+	if !strings.Contains(msg, "{") {
+		return msg, false
+	}
+	var b strings.Builder
+	replaced := false
+	for {
+		open := strings.IndexByte(msg, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(msg[open:], '}')
+		if end < 0 {
+			break
+		}
+		end += open
+		value, ok := c.lookup(msg[open+1:end], fields)
+		if !ok {
+			b.WriteString(msg[:open+1])
+			msg = msg[open+1:]
+			continue
+		}
+		b.WriteString(msg[:open])
+		b.WriteString(value)
+		msg = msg[end+1:]
+		replaced = true
+	}
+	if !replaced {
+		return "", false
+	}
+	b.WriteString(msg)
+	return b.String(), true
+}
+
+// lookup returns the text of the field named key, preferring the entry's
+// latest field over context fields
+func (c *templateCore) lookup(key string, fields []zapcore.Field) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for _, list := range [][]zapcore.Field{fields, c.context} {
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].Key == key {
+				return fmt.Sprint(fieldValue(list[i])), true
+			}
+		}
+	}
+	return "", false
+}
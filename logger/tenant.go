@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TenantConfig configures the logger of one tenant
+type TenantConfig struct {
+	Level     string         // Minimum level ("" = the base logger's level)
+	RateLimit int            // Entries per second, audit entries exempt (0 = unlimited)
+	Fields    map[string]any // Fields added to every entry, e.g. the tenant's plan
+}
+
+// TenantManager hands out per-tenant loggers that share the outputs and sink
+// connections of a base logger. Every entry of a tenant's logger carries the
+// tenant field, so a Loki sink with MultiTenant set pushes it under the
+// tenant's X-Scope-OrgID, and each tenant has its own level and rate limit:
+//
+//	tenants := logger.NewTenantManager(base, logger.TenantConfig{RateLimit: 100})
+//	tenants.Configure("acme", logger.TenantConfig{Level: "debug"})
+//	tenants.Logger("acme").Infow("invoice sent", "invoice_id", 42)
+type TenantManager struct {
+	base     *Logger
+	defaults TenantConfig
+
+	mu      sync.Mutex
+	tenants map[string]*tenant
+}
+
+// tenant holds the logger of a tenant and the state shared by its cores
+type tenant struct {
+	logger  *Logger
+	level   zap.AtomicLevel
+	rate    atomic.Int64  // Entries per second (0 = unlimited)
+	window  callsiteState // Entries allowed in the current second
+	dropped atomic.Uint64 // Entries dropped by the rate limit
+}
+
+// NewTenantManager returns a manager creating tenant loggers from base, with
+// defaults applied to tenants that are not configured. An invalid default
+// level falls back to the base logger's level.
+func NewTenantManager(base *Logger, defaults TenantConfig) *TenantManager {
+	return &TenantManager{
+		base:     base,
+		defaults: defaults,
+		tenants:  make(map[string]*tenant),
+	}
+}
+
+// Logger returns the logger of the tenant, creating it with the defaults if
+// needed
+func (m *TenantManager) Logger(id string) *Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tenants[id]
+	if !ok {
+		lvl := m.base.level.Level()
+		if l, err := ParseLevel(m.defaults.Level); err == nil && m.defaults.Level != "" {
+			lvl = l
+		}
+		t = m.newTenant(id, m.defaults, lvl)
+		m.tenants[id] = t
+	}
+	return t.logger
+}
+
+// Configure sets the level, rate limit and fields of the tenant. Level and
+// rate limit changes apply to loggers already handed out; field changes only
+// to loggers returned by later Logger calls.
+func (m *TenantManager) Configure(id string, cfg TenantConfig) error {
+	lvl := m.base.level.Level()
+	if cfg.Level != "" {
+		var err error
+		if lvl, err = ParseLevel(cfg.Level); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tenants[id]
+	if !ok {
+		m.tenants[id] = m.newTenant(id, cfg, lvl)
+		return nil
+	}
+	t.level.SetLevel(lvl)
+	t.rate.Store(int64(cfg.RateLimit))
+	t.logger = m.tenantLogger(id, t, cfg.Fields)
+	return nil
+}
+
+// Dropped returns the number of entries of the tenant dropped by its rate
+// limit
+func (m *TenantManager) Dropped(id string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tenants[id]; ok {
+		return t.dropped.Load()
+	}
+	return 0
+}
+
+// Tenants returns the IDs of the known tenants, sorted
+func (m *TenantManager) Tenants() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.tenants))
+	for id := range m.tenants {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Remove forgets the tenant; its loggers keep working with their last
+// settings
+func (m *TenantManager) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tenants, id)
+}
+
+// newTenant creates the state and logger of a tenant
+func (m *TenantManager) newTenant(id string, cfg TenantConfig, lvl zapcore.Level) *tenant {
+	t := &tenant{level: zap.NewAtomicLevelAt(lvl)}
+	t.rate.Store(int64(cfg.RateLimit))
+	t.logger = m.tenantLogger(id, t, cfg.Fields)
+	return t
+}
+
+// tenantLogger builds a logger of the tenant carrying its ID and fields
+func (m *TenantManager) tenantLogger(id string, t *tenant, fields map[string]any) *Logger {
+	args := make([]any, 0, 2+2*len(fields))
+	args = append(args, sink.FieldTenant, id)
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		args = append(args, k, fields[k])
+	}
+	sugar := m.base.SugaredLogger.With(args...).WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return &tenantCore{Core: withLevel(c, t.level), tenant: t}
+	}))
+	return newLogger(sugar, m.base.cores, m.base.sampler, t.level, m.base.name)
+}
+
+// tenantCore enforces the rate limit of a tenant
+type tenantCore struct {
+	zapcore.Core
+	tenant *tenant
+}
+
+// With adds structured context while keeping the tenant's limit
+func (c *tenantCore) With(fields []zapcore.Field) zapcore.Core {
+	return &tenantCore{Core: c.Core.With(fields), tenant: c.tenant}
+}
+
+// Check drops entries over the tenant's rate limit, except audit entries
+func (c *tenantCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	if rate := c.tenant.rate.Load(); rate > 0 && ent.Level != AuditLevel && !c.tenant.window.allow(int(rate)) {
+		c.tenant.dropped.Add(1)
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
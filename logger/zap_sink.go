@@ -1,11 +1,18 @@
 package logger
 
 import (
+	"bytes"
 	"context"
+	"maps"
+	"math"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hsdfat/go-zlog/sink"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -16,12 +23,28 @@ type zapSinkCore struct {
 	enc        zapcore.Encoder
 	hostname   string
 	fields     map[string]any
+	resource   sink.Resource     // Service metadata stamped onto every entry
+	labels     map[string]string // Labels added with With, shared by the entries
+	ctx        context.Context   // Caller context added with With (see Context)
+	namespace  []string          // Namespaces opened by With, nesting later fields
 	callerSkip int
+	callerFunc bool // Record the caller's function name
+	preEncode  bool // Serialize entries once with enc instead of building Fields
 }
 
-// newZapSinkCore creates a new zapcore.Core that writes to a Sink
-func newZapSinkCore(s sink.Sink, enc zapcore.Encoder, enab zapcore.LevelEnabler) zapcore.Core {
+// NewSinkCore creates a zapcore.Core that writes entries to a Sink at the
+// levels enabled by enab, so zap loggers built elsewhere can tee into go-zlog
+// sinks (see WrapSink). enc serializes the lines of sinks accepting
+// pre-encoded entries, such as Loki with PreEncoded set.
+func NewSinkCore(s sink.Sink, enc zapcore.Encoder, enab zapcore.LevelEnabler) zapcore.Core {
 	hostname, _ := os.Hostname()
+	if lf, ok := s.(sink.LevelFilter); ok && lf.MinLevel() != "" {
+		// Skip building entries the sink would discard
+		if min, err := ParseLevel(lf.MinLevel()); err == nil {
+			enab = &minLevelEnabler{LevelEnabler: enab, min: min}
+		}
+	}
+	pe, ok := s.(sink.PreEncoder)
 	return &zapSinkCore{
 		LevelEnabler: enab,
 		sink:         s,
@@ -29,6 +52,58 @@ func newZapSinkCore(s sink.Sink, enc zapcore.Encoder, enab zapcore.LevelEnabler)
 		hostname:     hostname,
 		fields:       make(map[string]any),
 		callerSkip:   0,
+		preEncode:    ok && pe.AcceptsEncoded(),
+	}
+}
+
+// WrapSink returns a zap option teeing the logger's core into s at the levels
+// enabled by enab, with go-zlog's JSON layout for pre-encoded lines:
+//
+//	zl := zap.New(core, logger.WrapSink(s, zapcore.InfoLevel))
+func WrapSink(s sink.Sink, enab zapcore.LevelEnabler) zap.Option {
+	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, NewSinkCore(s, zapcore.NewJSONEncoder(baseEncoderConfig()), enab))
+	})
+}
+
+// newZapSinkCore creates a new zapcore.Core that writes JSON-encoded entries
+// to a Sink. The caller's function name is recorded when cfg has a FunctionKey.
+func newZapSinkCore(s sink.Sink, cfg zapcore.EncoderConfig, enab zapcore.LevelEnabler) zapcore.Core {
+	c := NewSinkCore(s, zapcore.NewJSONEncoder(cfg), enab).(*zapSinkCore)
+	c.callerFunc = cfg.FunctionKey != zapcore.OmitKey
+	return c
+}
+
+// minLevelEnabler enables the levels of the wrapped enabler at or above min
+type minLevelEnabler struct {
+	zapcore.LevelEnabler
+	min zapcore.Level
+}
+
+// Enabled reports whether l is at least min and enabled by the wrapped enabler
+func (e *minLevelEnabler) Enabled(l zapcore.Level) bool {
+	return l >= e.min && e.LevelEnabler.Enabled(l)
+}
+
+// setResource sets the service metadata stamped onto every entry. Pre-encoded
+// lines carry it as fields since they bypass the entry's metadata.
+func (c *zapSinkCore) setResource(r sink.Resource) {
+	c.resource = r
+	if !c.preEncode {
+		return
+	}
+	for _, kv := range [][2]string{
+		{sink.FieldService, r.ServiceName},
+		{sink.FieldInstance, r.InstanceID},
+		{sink.FieldEnvironment, r.Environment},
+		{sink.FieldVersion, r.Version},
+	} {
+		if kv[1] != "" {
+			c.enc.AddString(kv[0], kv[1])
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(r.Attributes)) {
+		c.enc.AddString(key, r.Attributes[key])
 	}
 }
 
@@ -40,7 +115,19 @@ func (c *zapSinkCore) With(fields []zapcore.Field) zapcore.Core {
 		enc:          c.enc.Clone(),
 		hostname:     c.hostname,
 		fields:       make(map[string]any, len(c.fields)+len(fields)),
+		resource:     c.resource,
+		labels:       mergeLabels(c.labels, fields),
+		ctx:          callerContext(c.ctx, fields),
 		callerSkip:   c.callerSkip,
+		callerFunc:   c.callerFunc,
+		preEncode:    c.preEncode,
+	}
+
+	// Add context fields to the encoder for pre-encoded output
+	if c.preEncode {
+		for _, field := range fields {
+			field.AddTo(clone.enc)
+		}
 	}
 
 	// Copy existing fields
@@ -48,10 +135,9 @@ func (c *zapSinkCore) With(fields []zapcore.Field) zapcore.Core {
 		clone.fields[k] = v
 	}
 
-	// Add new fields
-	for _, field := range fields {
-		clone.fields[field.Key] = fieldValue(field)
-	}
+	// Add new fields inside the current namespace
+	opened := addFields(namespace(clone.fields, c.namespace), fields)
+	clone.namespace = append(slices.Clip(c.namespace), opened...)
 
 	return clone
 }
@@ -66,27 +152,45 @@ func (c *zapSinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcor
 
 // Write serializes the Entry and any Fields supplied at the log site and writes them to the Sink
 func (c *zapSinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
-	// Merge fields
-	allFields := make(map[string]any, len(c.fields)+len(fields))
-	for k, v := range c.fields {
-		allFields[k] = v
-	}
-	for _, field := range fields {
-		allFields[field.Key] = fieldValue(field)
-	}
+	// Build log entry from the shared pool (the sink takes ownership on Write)
+	entry := sink.AcquireEntry()
+	entry.Timestamp = ent.Time
+	entry.Level = levelToString(ent.Level)
+	entry.Severity = sink.LevelSeverity(entry.Level)
+	entry.Message = ent.Message
+	entry.Hostname = c.hostname
+	c.resource.Apply(entry)
+	entry.Labels = mergeLabels(c.labels, fields)
+
+	if c.preEncode {
+		// Serialize the line exactly once; the sink sends it as-is. The
+		// line carries the numeric severity like the sinks' own encoding.
+		buf, err := c.enc.EncodeEntry(ent, append([]zapcore.Field{zap.Int(sink.FieldSeverity, entry.Severity)}, fields...))
+		if err != nil {
+			sink.ReleaseEntry(entry)
+			return err
+		}
+		entry.Encoded = append(entry.Encoded, bytes.TrimRight(buf.Bytes(), "\n")...)
+		buf.Free()
 
-	// Build log entry
-	entry := &sink.LogEntry{
-		Timestamp: ent.Time,
-		Level:     levelToString(ent.Level),
-		Message:   ent.Message,
-		Fields:    allFields,
-		Hostname:  c.hostname,
+		// Keep trace correlation fields available to the sink
+		c.copyTraceFields(entry, fields)
+	} else {
+		// Merge fields
+		for k, v := range c.fields {
+			entry.Fields[k] = v
+		}
+		addFields(namespace(entry.Fields, c.namespace), fields)
 	}
 
-	// Add caller information if present
+	c.setTraceContext(entry, fields)
+
+	// Add caller information (package/file:line) if present
 	if ent.Caller.Defined {
-		entry.Caller = ent.Caller.String()
+		entry.Caller = ent.Caller.TrimmedPath()
+		if c.callerFunc {
+			entry.Function = ent.Caller.Function
+		}
 	}
 
 	// Add stack trace if present
@@ -94,13 +198,62 @@ func (c *zapSinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 		entry.StackTrace = ent.Stack
 	}
 
-	// Write to sink asynchronously
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Write to sink within the caller's deadline, if any
+	ctx, cancel, deadline := writeContext(callerContext(c.ctx, fields))
 	defer cancel()
+	entry.Deadline = deadline
 
 	return c.sink.Write(ctx, entry)
 }
 
+// copyTraceFields copies trace correlation, entry ID and tenant fields into
+// entry.Fields
+func (c *zapSinkCore) copyTraceFields(entry *sink.LogEntry, fields []zapcore.Field) {
+	for _, key := range []string{sink.FieldTraceID, sink.FieldSpanID, sink.FieldEntryID, sink.FieldTenant} {
+		if v, ok := c.fields[key]; ok {
+			entry.Fields[key] = v
+		}
+	}
+	for _, field := range fields {
+		switch field.Key {
+		case sink.FieldTraceID, sink.FieldSpanID, sink.FieldEntryID, sink.FieldTenant:
+			entry.Fields[field.Key] = fieldValue(field)
+		}
+	}
+}
+
+// setTraceContext sets the entry's trace context from the trace correlation
+// fields, the entry's overriding the context's
+func (c *zapSinkCore) setTraceContext(entry *sink.LogEntry, fields []zapcore.Field) {
+	set := func(key string, v any) {
+		switch key {
+		case sink.FieldTraceID:
+			if s, ok := v.(string); ok {
+				entry.TraceID = s
+			}
+		case sink.FieldSpanID:
+			if s, ok := v.(string); ok {
+				entry.SpanID = s
+			}
+		case sink.FieldTraceFlags:
+			if flags, ok := sink.ParseTraceFlags(v); ok {
+				entry.TraceFlags = flags
+			}
+		}
+	}
+	for _, key := range []string{sink.FieldTraceID, sink.FieldSpanID, sink.FieldTraceFlags} {
+		if v, ok := c.fields[key]; ok {
+			set(key, v)
+		}
+	}
+	for _, field := range fields {
+		switch field.Key {
+		case sink.FieldTraceID, sink.FieldSpanID, sink.FieldTraceFlags:
+			set(field.Key, fieldValue(field))
+		}
+	}
+}
+
 // Sync flushes buffered logs
 func (c *zapSinkCore) Sync() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -108,6 +261,68 @@ func (c *zapSinkCore) Sync() error {
 	return c.sink.Flush(ctx)
 }
 
+// addFields stores fields in dst in order. A Namespace field nests all the
+// fields after it under its key; the keys of the namespaces opened are
+// returned so that cores created by With keep nesting later fields.
+func addFields(dst map[string]any, fields []zapcore.Field) (opened []string) {
+	cur := dst
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			ns := make(map[string]any)
+			cur[f.Key] = ns
+			cur = ns
+			opened = append(opened, f.Key)
+			continue
+		}
+		addField(cur, f)
+	}
+	return opened
+}
+
+// namespace returns the map for the namespace path in dst, copying the nested
+// maps along the way so that dst can be written without affecting the maps
+// it was copied from
+func namespace(dst map[string]any, path []string) map[string]any {
+	cur := dst
+	for _, key := range path {
+		ns := make(map[string]any)
+		if m, ok := cur[key].(map[string]any); ok {
+			for k, v := range m {
+				ns[k] = v
+			}
+		}
+		cur[key] = ns
+		cur = ns
+	}
+	return cur
+}
+
+// addField stores a field in dst, expanding errors into their message, type,
+// wrapped causes and stack trace
+func addField(dst map[string]any, f zapcore.Field) {
+	switch f.Type {
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			for k, v := range expandError(f.Key, err) {
+				dst[k] = v
+			}
+			return
+		}
+	case zapcore.SkipType:
+		return
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType, zapcore.StringerType:
+		// Let zap marshal the value; this also records marshaling errors
+		// under key+"Error" and merges inline objects into dst
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		for k, v := range enc.Fields {
+			dst[k] = v
+		}
+		return
+	}
+	dst[f.Key] = fieldValue(f)
+}
+
 // fieldValue extracts the value from a zapcore.Field
 func fieldValue(f zapcore.Field) any {
 	switch f.Type {
@@ -115,32 +330,46 @@ func fieldValue(f zapcore.Field) any {
 		return f.Integer == 1
 	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
 		return f.Integer
-	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
 		return uint64(f.Integer)
 	case zapcore.Float64Type:
-		return float64(f.Integer)
+		return math.Float64frombits(uint64(f.Integer))
 	case zapcore.Float32Type:
-		return float32(f.Integer)
+		return math.Float32frombits(uint32(f.Integer))
+	case zapcore.Complex128Type:
+		return formatComplex(f.Interface.(complex128), 128)
+	case zapcore.Complex64Type:
+		return formatComplex(complex128(f.Interface.(complex64)), 64)
 	case zapcore.StringType:
 		return f.String
+	case zapcore.ByteStringType:
+		return string(f.Interface.([]byte))
+	case zapcore.BinaryType:
+		return f.Interface
 	case zapcore.TimeType:
-		if f.Interface != nil {
-			if t, ok := f.Interface.(time.Time); ok {
-				return t
-			}
-			// Return as string if not time.Time
-			return f.Interface
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			t = t.In(loc)
 		}
-		return time.Unix(0, f.Integer)
+		return t
+	case zapcore.TimeFullType:
+		return f.Interface
 	case zapcore.DurationType:
 		return time.Duration(f.Integer)
 	case zapcore.ErrorType:
-		if f.Interface != nil {
-			return f.Interface
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return err.Error()
 		}
 		return nil
 	case zapcore.ReflectType:
+		if lv, ok := f.Interface.(*lazyValue); ok {
+			return lv.value()
+		}
 		return f.Interface
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.StringerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		return enc.Fields[f.Key]
 	default:
 		// For any other type, just return the interface value
 		if f.Interface != nil {
@@ -150,9 +379,18 @@ func fieldValue(f zapcore.Field) any {
 	}
 }
 
+// formatComplex formats c the way zap's JSON encoder does, e.g. "1+2i"
+func formatComplex(c complex128, bitSize int) string {
+	return strings.Trim(strconv.FormatComplex(c, 'g', -1, bitSize), "()")
+}
+
 // levelToString converts zapcore.Level to string
 func levelToString(level zapcore.Level) string {
 	switch level {
+	case TraceLevel:
+		return "trace"
+	case AuditLevel:
+		return "audit"
 	case zapcore.DebugLevel:
 		return "debug"
 	case zapcore.InfoLevel:
@@ -0,0 +1,65 @@
+// Package zlogtest provides go-zlog loggers for tests. It is kept out of the
+// logger package so that the testing package only enters test binaries.
+package zlogtest
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewLogger creates a logger writing console-formatted entries at every
+// level to t.Log, so they only show for failing or verbose tests. opts may
+// change the level or add outputs; Strict fails the test on error entries.
+// Entries logged after the test has finished go to stderr.
+func NewLogger(t testing.TB, opts ...logger.Option) *logger.Logger {
+	w := &testWriter{t: t, done: finished(t)}
+	return logger.NewBuilder().
+		ConsoleTo(w, "").
+		Options(append([]logger.Option{logger.WithLevel("trace")}, opts...)...).
+		Build()
+}
+
+// Strict returns an option failing t on error, panic and fatal entries, so
+// unexpected errors logged by the code under test surface:
+//
+//	l := zlogtest.NewLogger(t, zlogtest.Strict(t))
+func Strict(t testing.TB) logger.Option {
+	done := finished(t)
+	return logger.WithHooks(func(ent zapcore.Entry) error {
+		if !done.Load() && ent.Level >= zapcore.ErrorLevel && ent.Level <= zapcore.FatalLevel {
+			t.Errorf("unexpected %s entry: %s", logger.LevelString(ent.Level), ent.Message)
+		}
+		return nil
+	})
+}
+
+// finished returns a flag set once t has finished, after which t.Log panics
+func finished(t testing.TB) *atomic.Bool {
+	done := &atomic.Bool{}
+	t.Cleanup(func() { done.Store(true) })
+	return done
+}
+
+// testWriter writes encoded entries to a test's log
+type testWriter struct {
+	t    testing.TB
+	done *atomic.Bool
+}
+
+// Write logs one encoded entry
+func (w *testWriter) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\n")
+	if w.done.Load() {
+		if _, err := os.Stderr.WriteString(w.t.Name() + ": " + line + "\n"); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	w.t.Log(line)
+	return len(p), nil
+}
@@ -0,0 +1,371 @@
+// Package lokiquery reads logs back from Grafana Loki: range queries, label
+// and series lookups, and live tailing. It shares the URL, tenant,
+// authentication and TLS settings of sink.LokiSinkConfig, so services can
+// read their own shipped logs for admin endpoints and smoke tests:
+//
+//	c, _ := lokiquery.New(lokiquery.FromSinkConfig(lokiCfg))
+//	res, err := c.QueryRange(ctx, lokiquery.QueryRange{Query: `{service="checkout"} |= "timeout"`, Start: time.Now().Add(-time.Hour)})
+package lokiquery
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// pushPath is the path of Loki's push API, stripped from sink URLs
+const pushPath = "/loki/api/v1/push"
+
+// Config configures a Client
+type Config struct {
+	URL         string          // Loki base URL, e.g. http://loki:3100
+	TenantID    string          // Optional X-Scope-OrgID
+	BearerToken string          // Optional bearer token for authentication
+	BasicAuth   *sink.BasicAuth // Optional basic authentication
+	TLSConfig   *tls.Config     // Optional TLS settings
+	Timeout     time.Duration   // Timeout of each query (default: 30s; tails are not limited)
+}
+
+// FromSinkConfig returns the query settings matching a Loki sink's
+func FromSinkConfig(cfg *sink.LokiSinkConfig) Config {
+	return Config{
+		URL:         strings.TrimSuffix(cfg.URL, pushPath),
+		TenantID:    cfg.TenantID,
+		BearerToken: cfg.BearerToken,
+		BasicAuth:   cfg.BasicAuth,
+		TLSConfig:   cfg.TLSConfig,
+	}
+}
+
+// Client queries a Loki server
+type Client struct {
+	config Config
+	base   *url.URL
+	client *http.Client
+}
+
+// New creates a client
+func New(cfg Config) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("URL is required")
+	}
+	base, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Client{
+		config: cfg,
+		base:   base,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: &http.Transport{TLSClientConfig: cfg.TLSConfig},
+		},
+	}, nil
+}
+
+// Direction is the order of the entries returned by QueryRange
+type Direction string
+
+// Query directions
+const (
+	Backward Direction = "backward" // Newest first (Loki's default)
+	Forward  Direction = "forward"  // Oldest first
+)
+
+// QueryRange is a LogQL query over a time range
+type QueryRange struct {
+	Query     string        // LogQL query
+	Start     time.Time     // Start of the range (zero = one hour before End)
+	End       time.Time     // End of the range (zero = now)
+	Limit     int           // Maximum entries returned (0 = Loki's default of 100)
+	Direction Direction     // Order of the entries ("" = Backward)
+	Step      time.Duration // Resolution of metric queries (0 = Loki's default)
+}
+
+// Result is the result of a query: streams for log queries, series for
+// metric queries
+type Result struct {
+	Type    string   // "streams" or "matrix"
+	Streams []Stream // Log query results
+	Matrix  []Series // Metric query results
+}
+
+// Stream is a set of log entries sharing labels
+type Stream struct {
+	Labels  map[string]string
+	Entries []Entry
+}
+
+// Entry is a log line
+type Entry struct {
+	Timestamp time.Time
+	Line      string
+	Metadata  map[string]string // Structured metadata, if any
+}
+
+// Series is the samples of a metric query for one label set
+type Series struct {
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// Sample is a metric query value
+type Sample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// QueryRange runs a LogQL query over a time range
+func (c *Client) QueryRange(ctx context.Context, q QueryRange) (*Result, error) {
+	end := q.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := q.Start
+	if start.IsZero() {
+		start = end.Add(-time.Hour)
+	}
+	params := url.Values{
+		"query": {q.Query},
+		"start": {nanos(start)},
+		"end":   {nanos(end)},
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Direction != "" {
+		params.Set("direction", string(q.Direction))
+	}
+	if q.Step > 0 {
+		params.Set("step", strconv.FormatFloat(q.Step.Seconds(), 'f', -1, 64))
+	}
+
+	var data struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	}
+	if err := c.get(ctx, "/loki/api/v1/query_range", params, &data); err != nil {
+		return nil, err
+	}
+	res := &Result{Type: data.ResultType}
+	var err error
+	switch data.ResultType {
+	case "streams":
+		res.Streams, err = decodeStreams(data.Result)
+	case "matrix":
+		res.Matrix, err = decodeMatrix(data.Result)
+	default:
+		err = fmt.Errorf("unsupported result type %q", data.ResultType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query_range: %w", err)
+	}
+	return res, nil
+}
+
+// Labels returns the label names seen between start and end (zero = Loki's
+// default range)
+func (c *Client) Labels(ctx context.Context, start, end time.Time) ([]string, error) {
+	var names []string
+	err := c.get(ctx, "/loki/api/v1/labels", rangeParams(start, end), &names)
+	return names, err
+}
+
+// LabelValues returns the values of a label seen between start and end
+func (c *Client) LabelValues(ctx context.Context, name string, start, end time.Time) ([]string, error) {
+	var values []string
+	err := c.get(ctx, "/loki/api/v1/label/"+url.PathEscape(name)+"/values", rangeParams(start, end), &values)
+	return values, err
+}
+
+// Series returns the label sets of the streams matching any of the stream
+// selectors, e.g. `{service="checkout"}`, between start and end
+func (c *Client) Series(ctx context.Context, matchers []string, start, end time.Time) ([]map[string]string, error) {
+	params := rangeParams(start, end)
+	params["match[]"] = matchers
+	var series []map[string]string
+	err := c.get(ctx, "/loki/api/v1/series", params, &series)
+	return series, err
+}
+
+// get calls a query API, decoding the data of a successful response into dst
+func (c *Client) get(ctx context.Context, path string, params url.Values, dst any) error {
+	u := c.url(path, params)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req.Header)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	var envelope struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if envelope.Status != "success" {
+		return fmt.Errorf("query failed with status %q", envelope.Status)
+	}
+	return json.Unmarshal(envelope.Data, dst)
+}
+
+// url returns the URL of an API path with the query parameters
+func (c *Client) url(path string, params url.Values) *url.URL {
+	u := *c.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = params.Encode()
+	return &u
+}
+
+// setHeaders sets the tenant and authentication headers
+func (c *Client) setHeaders(h http.Header) {
+	if c.config.TenantID != "" {
+		h.Set("X-Scope-OrgID", c.config.TenantID)
+	}
+	if c.config.BearerToken != "" {
+		h.Set("Authorization", "Bearer "+c.config.BearerToken)
+	} else if auth := c.config.BasicAuth; auth != nil {
+		h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password)))
+	}
+}
+
+// StatusError is returned for non-2xx responses
+type StatusError struct {
+	StatusCode int
+	Body       string // Error message returned by Loki
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("loki returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// rangeParams returns the start and end parameters of the non-zero times
+func rangeParams(start, end time.Time) url.Values {
+	params := url.Values{}
+	if !start.IsZero() {
+		params.Set("start", nanos(start))
+	}
+	if !end.IsZero() {
+		params.Set("end", nanos(end))
+	}
+	return params
+}
+
+// nanos formats t as nanoseconds since the Unix epoch
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// rawStream is a stream in Loki's JSON responses
+type rawStream struct {
+	Stream map[string]string   `json:"stream"`
+	Values [][]json.RawMessage `json:"values"`
+}
+
+// decodeStreams decodes a streams result
+func decodeStreams(data json.RawMessage) ([]Stream, error) {
+	var raw []rawStream
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	streams := make([]Stream, 0, len(raw))
+	for _, r := range raw {
+		s, err := r.decode()
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, s)
+	}
+	return streams, nil
+}
+
+// decode decodes the [timestamp_ns, line, optional metadata] values
+func (r rawStream) decode() (Stream, error) {
+	s := Stream{Labels: r.Stream, Entries: make([]Entry, 0, len(r.Values))}
+	for _, v := range r.Values {
+		if len(v) < 2 {
+			return s, fmt.Errorf("value has %d elements", len(v))
+		}
+		var ts string
+		if err := json.Unmarshal(v[0], &ts); err != nil {
+			return s, err
+		}
+		ns, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return s, err
+		}
+		e := Entry{Timestamp: time.Unix(0, ns)}
+		if err := json.Unmarshal(v[1], &e.Line); err != nil {
+			return s, err
+		}
+		if len(v) > 2 {
+			if err := json.Unmarshal(v[2], &e.Metadata); err != nil {
+				return s, err
+			}
+		}
+		s.Entries = append(s.Entries, e)
+	}
+	return s, nil
+}
+
+// decodeMatrix decodes a matrix result of [unix_seconds, "value"] samples
+func decodeMatrix(data json.RawMessage) ([]Series, error) {
+	var raw []struct {
+		Metric map[string]string   `json:"metric"`
+		Values [][]json.RawMessage `json:"values"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	matrix := make([]Series, 0, len(raw))
+	for _, r := range raw {
+		series := Series{Labels: r.Metric, Samples: make([]Sample, 0, len(r.Values))}
+		for _, v := range r.Values {
+			if len(v) != 2 {
+				return nil, fmt.Errorf("sample has %d elements", len(v))
+			}
+			var secs float64
+			var value string
+			if err := json.Unmarshal(v[0], &secs); err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(v[1], &value); err != nil {
+				return nil, err
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, err
+			}
+			series.Samples = append(series.Samples, Sample{Timestamp: time.Unix(0, int64(secs*float64(time.Second))), Value: f})
+		}
+		matrix = append(matrix, series)
+	}
+	return matrix, nil
+}
@@ -0,0 +1,93 @@
+package lokiquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Tail is a live query streaming new entries
+type Tail struct {
+	Query    string        // LogQL log query
+	Start    time.Time     // Also send entries since Start (zero = Loki's default of one hour ago)
+	Limit    int           // Maximum entries sent from before the tail started (0 = Loki's default)
+	DelayFor time.Duration // Delay to wait for late entries, at most 5s
+}
+
+// TailResponse is a batch of tailed entries
+type TailResponse struct {
+	Streams []Stream
+	Dropped int // Entries Loki dropped because the client was too slow
+}
+
+// Tail streams the entries matching the query over a WebSocket, calling fn
+// with each batch, until ctx is done, fn returns an error or the connection
+// fails. It returns ctx.Err() once ctx is done.
+func (c *Client) Tail(ctx context.Context, t Tail, fn func(TailResponse) error) error {
+	params := url.Values{"query": {t.Query}}
+	if !t.Start.IsZero() {
+		params.Set("start", nanos(t.Start))
+	}
+	if t.Limit > 0 {
+		params.Set("limit", strconv.Itoa(t.Limit))
+	}
+	if t.DelayFor > 0 {
+		params.Set("delay_for", strconv.Itoa(int(t.DelayFor/time.Second)))
+	}
+	location := c.url("/loki/api/v1/tail", params)
+	origin := *location
+	origin.Path, origin.RawQuery = "", ""
+	switch location.Scheme {
+	case "https":
+		location.Scheme = "wss"
+	default:
+		location.Scheme = "ws"
+	}
+
+	cfg, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return err
+	}
+	cfg.TlsConfig = c.config.TLSConfig
+	cfg.Header = http.Header{}
+	c.setHeaders(cfg.Header)
+	conn, err := cfg.DialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("tail: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read below when ctx is done
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		var msg struct {
+			Streams        []rawStream       `json:"streams"`
+			DroppedEntries []json.RawMessage `json:"dropped_entries"`
+		}
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("tail: %w", err)
+		}
+		resp := TailResponse{Streams: make([]Stream, 0, len(msg.Streams)), Dropped: len(msg.DroppedEntries)}
+		for _, r := range msg.Streams {
+			s, err := r.decode()
+			if err != nil {
+				return fmt.Errorf("tail: %w", err)
+			}
+			resp.Streams = append(resp.Streams, s)
+		}
+		if err := fn(resp); err != nil {
+			return err
+		}
+	}
+}
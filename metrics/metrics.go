@@ -0,0 +1,198 @@
+// Package metrics exposes go-zlog sink and logger statistics as Prometheus
+// collectors. It is a separate module, so the Prometheus client library only
+// enters builds importing it.
+package metrics
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"github.com/hsdfat/go-zlog/sink"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+// statsProvider is implemented by sinks that report buffering statistics
+// (e.g. sink.BufferedSink). Stats is called on every scrape, so it must not
+// wait for flushes in progress.
+type statsProvider interface {
+	Stats() sink.Stats
+}
+
+// Collector is a prometheus.Collector reporting per-sink statistics labelled
+// by sink name, plus logger entry counts by level
+type Collector struct {
+	mu    sync.RWMutex
+	sinks map[string]sink.Sink
+
+	entries    *prometheus.CounterVec
+	namespace  string
+	logMetrics []logMetrics
+
+	sent          *prometheus.Desc
+	dropped       *prometheus.Desc
+	bytesSent     *prometheus.Desc
+	retries       *prometheus.Desc
+	failedBatches *prometheus.Desc
+	buffered      *prometheus.Desc
+	healthy       *prometheus.Desc
+	flushDuration *prometheus.Desc
+}
+
+// NewCollector creates a collector with metric names under the given namespace
+// (default: "zlog")
+func NewCollector(namespace string) *Collector {
+	if namespace == "" {
+		namespace = "zlog"
+	}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "sink", name), help, []string{"sink"}, nil)
+	}
+
+	return &Collector{
+		sinks:     make(map[string]sink.Sink),
+		namespace: namespace,
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "logger",
+			Name:      "entries_total",
+			Help:      "Log entries written by the logger, by level.",
+		}, []string{"level"}),
+		sent:          desc("entries_sent_total", "Log entries successfully sent by the sink."),
+		dropped:       desc("entries_dropped_total", "Log entries dropped by the sink."),
+		bytesSent:     desc("bytes_sent_total", "Approximate bytes of log entries sent by the sink."),
+		retries:       desc("retries_total", "Retry attempts made by the sink."),
+		failedBatches: desc("failed_batches_total", "Batches that failed after all retries."),
+		buffered:      desc("buffered_entries", "Log entries currently buffered by the sink."),
+		healthy:       desc("healthy", "Whether the sink is healthy (1) or not (0)."),
+		flushDuration: desc("flush_duration_seconds", "Time taken to send a batch, over recent batches."),
+	}
+}
+
+// AddSink registers a sink under the given name, replacing any existing one
+func (c *Collector) AddSink(name string, s sink.Sink) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sinks[name] = s
+}
+
+// RemoveSink unregisters the sink with the given name
+func (c *Collector) RemoveSink(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sinks, name)
+}
+
+// logMetrics is a registered logger.LogMetrics and its metric description
+type logMetrics struct {
+	metrics *logger.LogMetrics
+	desc    *prometheus.Desc
+}
+
+// AddLogMetrics exports the counters of m as <namespace>_logger_field_entries_total,
+// labelled by level, logger name and the counted field (with characters not
+// allowed in label names replaced by underscores, and "field_" prepended to
+// "level" and "logger"). Register it before
+// registering the collector.
+func (c *Collector) AddLogMetrics(m *logger.LogMetrics) {
+	labels := []string{"level", "logger"}
+	if m.Field() != "" {
+		labels = append(labels, labelName(m.Field()))
+	}
+	desc := prometheus.NewDesc(prometheus.BuildFQName(c.namespace, "logger", "field_entries_total"),
+		"Log entries written by the logger, by level, logger name and field value.", labels, nil)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logMetrics = append(c.logMetrics, logMetrics{metrics: m, desc: desc})
+}
+
+// labelName turns a field key into a valid Prometheus label name
+func labelName(field string) string {
+	b := []byte(field)
+	for i, ch := range b {
+		if !(ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 0 && ch >= '0' && ch <= '9') {
+			b[i] = '_'
+		}
+	}
+	if name := string(b); name != "level" && name != "logger" {
+		return name
+	}
+	return "field_" + string(b)
+}
+
+// EntryHook counts logged entries by level. Pass it in LoggerConfig.Hooks.
+func (c *Collector) EntryHook(ent zapcore.Entry) error {
+	c.entries.WithLabelValues(logger.LevelString(ent.Level)).Inc()
+	return nil
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.entries.Describe(ch)
+	ch <- c.sent
+	ch <- c.dropped
+	ch <- c.bytesSent
+	ch <- c.retries
+	ch <- c.failedBatches
+	ch <- c.buffered
+	ch <- c.healthy
+	ch <- c.flushDuration
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, lm := range c.logMetrics {
+		ch <- lm.desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.entries.Collect(ch)
+
+	// Copy the registrations so a slow sink does not block AddSink and RemoveSink
+	c.mu.RLock()
+	logMetrics := slices.Clone(c.logMetrics)
+	sinks := maps.Clone(c.sinks)
+	c.mu.RUnlock()
+
+	for _, lm := range logMetrics {
+		for key, n := range lm.metrics.Counts() {
+			labels := []string{key.Level, key.Logger}
+			if lm.metrics.Field() != "" {
+				labels = append(labels, key.Value)
+			}
+			ch <- prometheus.MustNewConstMetric(lm.desc, prometheus.CounterValue, float64(n), labels...)
+		}
+	}
+
+	for name, s := range sinks {
+		healthy := 0.0
+		if s.IsHealthy() {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.healthy, prometheus.GaugeValue, healthy, name)
+
+		sp, ok := s.(statsProvider)
+		if !ok {
+			continue
+		}
+		stats := sp.Stats()
+
+		ch <- prometheus.MustNewConstMetric(c.sent, prometheus.CounterValue, float64(stats.Sent), name)
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped), name)
+		ch <- prometheus.MustNewConstMetric(c.bytesSent, prometheus.CounterValue, float64(stats.BytesSent), name)
+		ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, float64(stats.Retries), name)
+		ch <- prometheus.MustNewConstMetric(c.failedBatches, prometheus.CounterValue, float64(stats.FailedBatches), name)
+		ch <- prometheus.MustNewConstMetric(c.buffered, prometheus.GaugeValue, float64(stats.Buffered), name)
+		ch <- prometheus.MustNewConstSummary(c.flushDuration, stats.Batches, stats.FlushTime.Seconds(),
+			map[float64]float64{
+				0.5:  stats.FlushLatency.P50.Seconds(),
+				0.9:  stats.FlushLatency.P90.Seconds(),
+				0.99: stats.FlushLatency.P99.Seconds(),
+			}, name)
+	}
+}
@@ -0,0 +1,227 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Fields added to the summary entries of AggregatingSink
+const (
+	FieldAggregateCount  = "aggregate_count"  // Occurrences of the message in the window
+	FieldAggregateWindow = "aggregate_window" // Length of the window, e.g. "1m0s"
+)
+
+// AggregateRule configures the aggregation of one level
+type AggregateRule struct {
+	Window      time.Duration // Period summarized by each summary entry (default: 60s)
+	PassThrough int           // Entries per message written as-is each window before aggregating
+}
+
+// AggregateConfig configures an AggregatingSink
+type AggregateConfig struct {
+	Levels  map[string]AggregateRule // Rules by level; entries of other levels pass through
+	MaxKeys int                      // Messages tracked at once; others pass through (default: 10000)
+	Clock   Clock                    // Time source for windows (nil = SystemClock)
+}
+
+// AggregatingSink collapses repeated entries with the same level, message
+// (or message template) and tenant into periodic summary entries, e.g.
+// `connection reset (occurred 532 times in the last 1m0s)` carrying the
+// fields of the first occurrence, to cut the ingestion cost of noisy logs. Wrap a BufferedSink with it so aggregated
+// entries never take buffer space.
+type AggregatingSink struct {
+	Sink
+	config AggregateConfig
+	clock  Clock
+
+	mu     sync.Mutex
+	groups map[aggregateKey]*aggregateGroup
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// aggregateKey identifies the entries aggregated together
+type aggregateKey struct {
+	level   string
+	message string // Message template, if any, else message
+	tenant  string // FieldTenant field, so tenants are summarized apart
+}
+
+// aggregateGroup counts the entries of a key in the current window
+type aggregateGroup struct {
+	sample *LogEntry // Copy of the first entry of the window
+	count  int       // Entries in the window
+	end    time.Time // End of the window
+	window time.Duration
+}
+
+// NewAggregatingSink wraps s, aggregating entries as configured by cfg
+func NewAggregatingSink(s Sink, cfg AggregateConfig) *AggregatingSink {
+	if cfg.MaxKeys <= 0 {
+		cfg.MaxKeys = 10000
+	}
+	levels := make(map[string]AggregateRule, len(cfg.Levels))
+	tick := time.Second
+	for level, rule := range cfg.Levels {
+		if rule.Window <= 0 {
+			rule.Window = 60 * time.Second
+		}
+		levels[level] = rule
+		tick = min(tick, rule.Window)
+	}
+	cfg.Levels = levels
+
+	a := &AggregatingSink{
+		Sink:   s,
+		config: cfg,
+		clock:  clockOrSystem(cfg.Clock),
+		groups: make(map[aggregateKey]*aggregateGroup),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go a.run(a.clock.NewTicker(tick))
+	return a
+}
+
+// Write writes entry unless it is aggregated, releasing it if so
+func (a *AggregatingSink) Write(ctx context.Context, entry *LogEntry) error {
+	if a.aggregate(entry) {
+		ReleaseEntry(entry)
+		return nil
+	}
+	return a.Sink.Write(ctx, entry)
+}
+
+// WriteBatch writes the entries that are not aggregated
+func (a *AggregatingSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
+	kept := make([]*LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !a.aggregate(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return a.Sink.WriteBatch(ctx, kept)
+}
+
+// aggregate counts entry, reporting whether it is summarized rather than
+// written
+func (a *AggregatingSink) aggregate(entry *LogEntry) bool {
+	rule, ok := a.config.Levels[entry.Level]
+	if !ok {
+		return false
+	}
+	key := aggregateKey{level: entry.Level, message: entry.Message}
+	if tmpl, ok := entry.Fields[FieldMessageTemplate].(string); ok {
+		key.message = tmpl
+	}
+	if tenant, ok := entry.Fields[FieldTenant]; ok {
+		key.tenant = fmt.Sprint(tenant)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	g := a.groups[key]
+	if g == nil {
+		if len(a.groups) >= a.config.MaxKeys {
+			return false
+		}
+		sample := *entry
+		sample.Fields = maps.Clone(entry.Fields)
+		sample.Encoded = nil
+		sample.pooled = false
+		sample.Message = key.message
+		g = &aggregateGroup{sample: &sample, end: a.clock.Now().Add(rule.Window), window: rule.Window}
+		a.groups[key] = g
+	}
+	g.count++
+	return g.count > rule.PassThrough
+}
+
+// run emits the summaries of ended windows every tick until stopped
+func (a *AggregatingSink) run(ticker Ticker) {
+	defer close(a.done)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := a.emit(ctx, false); err != nil {
+				handleError(fmt.Errorf("aggregate: %w", err))
+			}
+			cancel()
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// emit writes the summaries of the windows that ended, or of all windows if
+// all is set, and forgets them
+func (a *AggregatingSink) emit(ctx context.Context, all bool) error {
+	now := a.clock.Now()
+	var summaries []*LogEntry
+	a.mu.Lock()
+	for key, g := range a.groups {
+		if !all && now.Before(g.end) {
+			continue
+		}
+		delete(a.groups, key)
+		if rule := a.config.Levels[key.level]; g.count > rule.PassThrough {
+			summaries = append(summaries, g.summary(now))
+		}
+	}
+	a.mu.Unlock()
+
+	if len(summaries) == 0 {
+		return nil
+	}
+	return a.Sink.WriteBatch(ctx, summaries)
+}
+
+// summary returns the summary entry of the group's window
+func (g *aggregateGroup) summary(now time.Time) *LogEntry {
+	entry := g.sample
+	window := g.window
+	if start := g.end.Add(-g.window); now.Before(g.end) {
+		window = now.Sub(start).Round(time.Second) // Emitted early by Flush or Close
+	}
+	entry.Timestamp = now
+	entry.Message = fmt.Sprintf("%s (occurred %d times in the last %s)", entry.Message, g.count, window)
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]any, 2)
+	}
+	entry.Fields[FieldAggregateCount] = g.count
+	entry.Fields[FieldAggregateWindow] = window.String()
+	return entry
+}
+
+// MinLevel returns the minimum level of the wrapped sink, if it filters levels
+func (a *AggregatingSink) MinLevel() string {
+	if lf, ok := a.Sink.(LevelFilter); ok {
+		return lf.MinLevel()
+	}
+	return ""
+}
+
+// Flush writes the summaries of all windows, then flushes the wrapped sink
+func (a *AggregatingSink) Flush(ctx context.Context) error {
+	return errors.Join(a.emit(ctx, true), a.Sink.Flush(ctx))
+}
+
+// Close writes the summaries of all windows and closes the wrapped sink
+func (a *AggregatingSink) Close() error {
+	a.once.Do(func() { close(a.stop) })
+	<-a.done
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return errors.Join(a.emit(ctx, true), a.Sink.Close())
+}
@@ -0,0 +1,88 @@
+package sink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Request headers carrying BatchInfo
+const (
+	HeaderBatchID      = "X-Batch-ID"
+	HeaderBatchCount   = "X-Batch-Count"
+	HeaderBatchFirst   = "X-Batch-First-Timestamp"
+	HeaderBatchLast    = "X-Batch-Last-Timestamp"
+	HeaderBatchAttempt = "X-Batch-Attempt"
+)
+
+// BatchInfo describes a batch flushed by BufferedSink when
+// Config.BatchMetadata is set
+type BatchInfo struct {
+	ID      string    // Random ID, the same on every attempt so receivers can dedupe retries
+	Count   int       // Entries in the batch
+	First   time.Time // Timestamp of the oldest entry
+	Last    time.Time // Timestamp of the newest entry
+	Attempt int       // Delivery attempt, from 1
+}
+
+// batchInfoKey is the context key of the BatchInfo
+type batchInfoKey struct{}
+
+// WithBatchInfo returns a context carrying info to the sink's WriteBatch
+func WithBatchInfo(ctx context.Context, info BatchInfo) context.Context {
+	return context.WithValue(ctx, batchInfoKey{}, info)
+}
+
+// BatchInfoFromContext returns the BatchInfo of the batch being written, if
+// any
+func BatchInfoFromContext(ctx context.Context) (BatchInfo, bool) {
+	info, ok := ctx.Value(batchInfoKey{}).(BatchInfo)
+	return info, ok
+}
+
+// newBatchInfo returns the metadata of a batch with a new ID
+func newBatchInfo(batch []*LogEntry) BatchInfo {
+	var id [16]byte
+	rand.Read(id[:])
+	info := BatchInfo{ID: hex.EncodeToString(id[:]), Count: len(batch)}
+	for _, entry := range batch {
+		if info.First.IsZero() || entry.Timestamp.Before(info.First) {
+			info.First = entry.Timestamp
+		}
+		if entry.Timestamp.After(info.Last) {
+			info.Last = entry.Timestamp
+		}
+	}
+	return info
+}
+
+// SetHeaders sets the X-Batch-* headers of the batch
+func (b BatchInfo) SetHeaders(h http.Header) {
+	h.Set(HeaderBatchID, b.ID)
+	h.Set(HeaderBatchCount, strconv.Itoa(b.Count))
+	if !b.First.IsZero() {
+		h.Set(HeaderBatchFirst, b.First.UTC().Format(time.RFC3339Nano))
+		h.Set(HeaderBatchLast, b.Last.UTC().Format(time.RFC3339Nano))
+	}
+	if b.Attempt > 0 {
+		h.Set(HeaderBatchAttempt, strconv.Itoa(b.Attempt))
+	}
+}
+
+// setBatchHeaders sets the batch metadata and custom batch headers of the
+// batch written with ctx, if any
+func setBatchHeaders(ctx context.Context, h http.Header, cfg *Config) {
+	info, ok := BatchInfoFromContext(ctx)
+	if !ok {
+		return
+	}
+	info.SetHeaders(h)
+	if cfg != nil && cfg.BatchHeaders != nil {
+		for k, v := range cfg.BatchHeaders(info) {
+			h.Set(k, v)
+		}
+	}
+}
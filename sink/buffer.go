@@ -2,21 +2,81 @@ package sink
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Approximate per-entry and per-field memory overhead used for buffer accounting
+const (
+	entryOverhead = 128
+	fieldOverhead = 16
+)
+
+// flusherRestartDelay is the pause before restarting a panicked flusher
+const flusherRestartDelay = 100 * time.Millisecond
+
+// ErrDraining is returned by Write when the sink is draining or closed
+var ErrDraining = errors.New("buffered sink is draining")
+
+// DrainReport summarizes the outcome of a Drain call
+type DrainReport struct {
+	Delivered int           // Entries successfully sent during the drain
+	Abandoned int           // Entries dropped or left unsent when the drain ended
+	Duration  time.Duration // Time spent draining
+}
+
 // BufferedSink wraps a Sink with buffering and batching capabilities
 type BufferedSink struct {
-	sink          Sink
-	config        *Config
-	buffer        []*LogEntry
-	bufferMu      sync.Mutex
-	flushTicker   *time.Ticker
-	stopChan      chan struct{}
-	wg            sync.WaitGroup
+	sink        Sink
+	config      *Config
+	buffer      []*LogEntry
+	bufferBytes int // Approximate memory held by buffered entries
+	bufferMu    sync.Mutex
+	flushTicker Ticker
+	clock       Clock
+	stopChan    chan struct{}
+	stopOnce    sync.Once
+	flushNow    chan struct{}
+	draining    atomic.Bool
+	wg          sync.WaitGroup
+	buffered    atomic.Int64  // len(buffer), readable without bufferMu
+	bufferedMem atomic.Int64  // bufferBytes, readable without bufferMu
+	retryCount  atomic.Uint64 // Updated by flush workers
+
+	// Statistics, guarded by statsMu rather than bufferMu so that Stats and
+	// health checks do not wait for a flush retrying against a down backend
+	statsMu       sync.Mutex
 	droppedCount  uint64
 	sentCount     uint64
+	failedBatches uint64
+	deadLettered  uint64
+	lateCount     uint64
+	bytesSent     uint64
+	flushCount    uint64
+	flushTime     time.Duration
+	lastFlush     time.Time
+	lastError     error
+	latency       latencyWindow
+
+	// Current batching parameters (fixed unless AdaptiveBatching is enabled)
+	batchSize     int
+	batchHint     atomic.Int64 // Copy of batchSize readable without bufferMu
+	flushInterval time.Duration
+	intervalHint  atomic.Int64 // Copy of flushInterval readable without bufferMu
+	recentCount   atomic.Int64 // Entries written since the last adaptive adjustment
+
+	// Producer-side shards (nil unless Config.Shards > 1)
+	shards      []*bufferShard
+	nextShard   atomic.Uint64
+	staged      atomic.Int64 // Entries held in shards
+	stagedBytes atomic.Int64 // Approximate memory held in shards
 }
 
 // NewBufferedSink creates a new buffered sink wrapper
@@ -25,44 +85,123 @@ func NewBufferedSink(sink Sink, config *Config) *BufferedSink {
 		config = DefaultConfig()
 	}
 
+	batchSize, flushInterval := config.MaxBatchSize, config.FlushInterval
+	if config.AdaptiveBatching {
+		// Start small for low latency and grow under load
+		batchSize = clampInt(config.MinBatchSize, 1, config.MaxBatchSize)
+		flushInterval = clampDuration(config.FlushInterval, config.MinFlushInterval, config.MaxFlushInterval)
+	}
+
+	clock := clockOrSystem(config.Clock)
 	bs := &BufferedSink{
-		sink:        sink,
-		config:      config,
-		buffer:      make([]*LogEntry, 0, config.BufferSize),
-		flushTicker: time.NewTicker(config.FlushInterval),
-		stopChan:    make(chan struct{}),
+		sink:          sink,
+		config:        config,
+		buffer:        make([]*LogEntry, 0, config.BufferSize),
+		flushTicker:   clock.NewTicker(flushInterval),
+		clock:         clock,
+		stopChan:      make(chan struct{}),
+		flushNow:      make(chan struct{}, 1),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+	}
+	bs.batchHint.Store(int64(batchSize))
+	bs.intervalHint.Store(int64(flushInterval))
+	if config.Shards > 1 {
+		bs.shards = newShards(config.Shards)
 	}
 
 	// Start background flusher
 	bs.wg.Add(1)
 	go bs.backgroundFlusher()
 
+	register(bs)
+
 	return bs
 }
 
-// Write adds a log entry to the buffer
+// Write adds a log entry to the buffer. Flushes it triggers run under the
+// sink's WriteTimeout rather than ctx, since they send other callers' entries.
 func (bs *BufferedSink) Write(ctx context.Context, entry *LogEntry) error {
+	if bs.draining.Load() {
+		ReleaseEntry(entry)
+		return ErrDraining
+	}
+	if !LevelEnabled(entry.Level, bs.config.MinLevel) {
+		ReleaseEntry(entry)
+		return nil
+	}
+
+	if bs.shards != nil {
+		return bs.writeSharded(entry)
+	}
+
 	bs.bufferMu.Lock()
 	defer bs.bufferMu.Unlock()
+	return bs.writeLocked(entry)
+}
 
-	// Check if buffer is full
-	if len(bs.buffer) >= bs.config.BufferSize {
-		if bs.config.DropOnFull {
-			bs.droppedCount++
-			return nil // Drop the log
+// storeBuffered publishes the size of the main buffer to readers not holding
+// bufferMu (must be called with lock held)
+func (bs *BufferedSink) storeBuffered() {
+	bs.buffered.Store(int64(len(bs.buffer)))
+	bs.bufferedMem.Store(int64(bs.bufferBytes))
+}
+
+// MinLevel returns the configured minimum level
+func (bs *BufferedSink) MinLevel() string {
+	return bs.config.MinLevel
+}
+
+// writeLocked adds a log entry to the main buffer (must be called with lock held)
+func (bs *BufferedSink) writeLocked(entry *LogEntry) error {
+	// Drain may have started while waiting for the lock; its final collection
+	// would miss the entry
+	if bs.draining.Load() {
+		ReleaseEntry(entry)
+		return ErrDraining
+	}
+	bs.collectShards()
+
+	// Check if buffer is full by entry count or memory
+	size := approxEntrySize(entry)
+	for bs.isFull(size) {
+		if !bs.config.DropOnFull {
+			// Flush synchronously if buffer is full and not dropping
+			if err := bs.flushShared(); err != nil {
+				return err
+			}
+			break
 		}
-		// Flush synchronously if buffer is full and not dropping
-		if err := bs.flushBuffer(ctx); err != nil {
-			return err
+
+		// Evict a lower priority entry to make room, otherwise drop the new one
+		idx := evictionIndex(bs.buffer, levelPriority(entry.Level))
+		if idx < 0 {
+			bs.recordDrop(1, DropReasonBufferFull)
+			ReleaseEntry(entry)
+			return nil // Drop the log
 		}
+		evicted := bs.buffer[idx]
+		bs.bufferBytes -= approxEntrySize(evicted)
+		bs.buffer = append(bs.buffer[:idx], bs.buffer[idx+1:]...)
+		bs.storeBuffered()
+		bs.recordDrop(1, DropReasonEvicted)
+		ReleaseEntry(evicted)
 	}
 
 	// Add to buffer
 	bs.buffer = append(bs.buffer, entry)
+	bs.bufferBytes += size
+	bs.storeBuffered()
+	bs.recentCount.Add(1)
+
+	// Ask the background flusher to send error-and-above entries early
+	if levelPriority(entry.Level) == priorityHigh {
+		bs.wakeFlusher()
+	}
 
-	// Flush immediately if buffer reaches max batch size
-	if len(bs.buffer) >= bs.config.MaxBatchSize {
-		return bs.flushBuffer(ctx)
+	// Flush immediately if buffer reaches the batch size
+	if len(bs.buffer) >= bs.batchSize {
+		return bs.flushShared()
 	}
 
 	return nil
@@ -85,67 +224,311 @@ func (bs *BufferedSink) Flush(ctx context.Context) error {
 	return bs.flushBuffer(ctx)
 }
 
+// wakeFlusher asks the background flusher to flush without waiting for the ticker
+func (bs *BufferedSink) wakeFlusher() {
+	select {
+	case bs.flushNow <- struct{}{}:
+	default:
+	}
+}
+
+// batchSizeHint returns the current batch size without taking bufferMu
+func (bs *BufferedSink) batchSizeHint() int {
+	return int(bs.batchHint.Load())
+}
+
+// flushShared flushes on behalf of a writer under the sink's own timeout:
+// the batch holds other callers' entries, so one caller's deadline must not
+// fail it (must be called with lock held)
+func (bs *BufferedSink) flushShared() error {
+	ctx, cancel := context.WithTimeout(context.Background(), bs.config.WriteTimeout)
+	defer cancel()
+	return bs.flushBuffer(ctx)
+}
+
 // flushBuffer sends buffered logs to the underlying sink (must be called with lock held)
 func (bs *BufferedSink) flushBuffer(ctx context.Context) error {
+	bs.collectShards()
 	if len(bs.buffer) == 0 {
 		return nil
 	}
 
-	// Create a copy of the buffer to send
-	toSend := make([]*LogEntry, len(bs.buffer))
-	copy(toSend, bs.buffer)
+	// Take the buffered entries; entries not in ordered streams are sent
+	// highest priority first
+	ordered := bs.config.OrderedDelivery
+	var toSend []*LogEntry
+	if ordered {
+		toSend = slices.Clone(bs.buffer)
+	} else {
+		toSend = prioritize(bs.buffer)
+	}
 
 	// Clear the buffer immediately
 	bs.buffer = bs.buffer[:0]
+	bs.bufferBytes = 0
+	bs.storeBuffered()
 
-	// Send in batches
-	for i := 0; i < len(toSend); i += bs.config.MaxBatchSize {
-		end := i + bs.config.MaxBatchSize
-		if end > len(toSend) {
-			end = len(toSend)
+	if ordered || bs.config.WorkerPoolSize > 1 {
+		return bs.flushStreams(ctx, bs.groupByStream(toSend, ordered))
+	}
+	return bs.sendEntries(ctx, toSend)
+}
+
+// sendEntries sends entries in batches. When a batch fails after its
+// retries, it and the unsent entries are buffered again, or dropped with
+// DropOnFull. A batch failing permanently is dead-lettered and the rest are
+// still sent (must be called with lock held).
+func (bs *BufferedSink) sendEntries(ctx context.Context, toSend []*LogEntry) error {
+	var errs []error
+	for i := 0; i < len(toSend); {
+		r := bs.sendBatch(ctx, toSend, i)
+		if err := bs.record(r); err != nil {
+			errs = append(errs, err)
+			if !IsPermanent(err) {
+				break
+			}
 		}
+		i = r.end
+	}
 
-		batch := toSend[i:end]
-		if err := bs.retryWriteBatch(ctx, batch); err != nil {
-			// Re-add failed logs to buffer if not dropping
-			if !bs.config.DropOnFull {
-				bs.buffer = append(bs.buffer, batch...)
-			} else {
-				bs.droppedCount += uint64(len(batch))
+	return errors.Join(errs...)
+}
+
+// batchResult is the outcome of sending one batch
+type batchResult struct {
+	entries []*LogEntry // Entries being sent, of which the batch is entries[start:end]
+	start   int
+	end     int
+	size    int       // Approximate serialized size of the batch
+	began   time.Time // When the first attempt started
+	done    time.Time // When the batch was sent or given up
+	err     error
+}
+
+// sendBatch sends the batch of entries starting at i with retries. It only
+// reads the sink's state, so workers may call it while the flushing
+// goroutine holds the lock.
+func (bs *BufferedSink) sendBatch(ctx context.Context, entries []*LogEntry, i int) batchResult {
+	end, size := bs.batchEnd(entries, i)
+	batch := entries[i:end]
+	r := batchResult{entries: entries, start: i, end: end, size: size, began: bs.clock.Now()}
+	batchCtx := ctx
+	if bs.config.BatchMetadata {
+		batchCtx = WithBatchInfo(ctx, newBatchInfo(batch))
+	}
+	r.err = bs.retryWriteBatch(batchCtx, batch)
+	r.done = bs.clock.Now()
+	return r
+}
+
+// record updates the statistics with a batch result. A batch failing
+// permanently is dead-lettered. A batch failing after its retries and the
+// entries after it are buffered again, or dead-lettered with DropOnFull. The
+// batch's error is returned (must be called with lock held).
+func (bs *BufferedSink) record(r batchResult) error {
+	batch := r.entries[r.start:r.end]
+	if r.err != nil {
+		bs.statsMu.Lock()
+		bs.failedBatches++
+		bs.lastError = r.err
+		bs.statsMu.Unlock()
+		permanent := IsPermanent(r.err)
+		if permanent {
+			handleError(fmt.Errorf("%s: batch of %d entries failed permanently: %w", bs.Name(), len(batch), r.err))
+		} else {
+			handleError(fmt.Errorf("%s: batch of %d entries failed after retries: %w", bs.Name(), len(batch), r.err))
+		}
+		if bs.config.OnError != nil {
+			bs.config.OnError(r.err, batch)
+		}
+		if permanent {
+			bs.deadLetter(batch, DropReasonRejected)
+			return r.err
+		}
+		// Re-add failed and unsent logs to buffer if not dropping
+		rest := r.entries[r.start:]
+		if !bs.config.DropOnFull {
+			bs.buffer = append(bs.buffer, rest...)
+			for _, entry := range rest {
+				bs.bufferBytes += approxEntrySize(entry)
 			}
-			return err
+			bs.storeBuffered()
+		} else {
+			bs.deadLetter(rest, DropReasonSendFailed)
 		}
-		bs.sentCount += uint64(len(batch))
+		return r.err
 	}
+	late := 0
+	for _, entry := range batch {
+		if !entry.Deadline.IsZero() && r.done.After(entry.Deadline) {
+			late++
+		}
+	}
+	releaseEntries(batch)
 
+	bs.statsMu.Lock()
+	defer bs.statsMu.Unlock()
+	bs.sentCount += uint64(len(batch))
+	bs.lateCount += uint64(late)
+	bs.bytesSent += uint64(r.size)
+	bs.lastFlush = r.done
+	bs.flushCount++
+	bs.flushTime += r.done.Sub(r.began)
+	bs.latency.observe(r.done.Sub(r.began))
 	return nil
 }
 
-// retryWriteBatch attempts to write a batch with retry logic
+// isFull reports whether adding an entry of the given size would exceed
+// BufferSize or MaxBufferBytes (must be called with lock held). An empty
+// buffer always accepts one entry, however large.
+func (bs *BufferedSink) isFull(size int) bool {
+	if len(bs.buffer) == 0 {
+		return false
+	}
+	if len(bs.buffer) >= bs.config.BufferSize {
+		return true
+	}
+	return bs.config.MaxBufferBytes > 0 && bs.bufferBytes+size > bs.config.MaxBufferBytes
+}
+
+// recordDrop counts dropped entries and notifies the OnDrop hook (must be called with lock held)
+func (bs *BufferedSink) recordDrop(count int, reason string) {
+	bs.statsMu.Lock()
+	bs.droppedCount += uint64(count)
+	bs.statsMu.Unlock()
+	handleError(fmt.Errorf("%s: dropped %d log entries (%s)", bs.Name(), count, reason))
+	if bs.config.OnDrop != nil {
+		bs.config.OnDrop(count, reason)
+	}
+}
+
+// deadLetter hands entries that will not be delivered to Config.DeadLetter,
+// dropping them for reason if there is none or it fails (must be called with
+// lock held)
+func (bs *BufferedSink) deadLetter(entries []*LogEntry, reason string) {
+	if bs.config.DeadLetter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), bs.config.WriteTimeout)
+		err := bs.config.DeadLetter.WriteBatch(ctx, entries)
+		cancel()
+		if err == nil {
+			bs.statsMu.Lock()
+			bs.deadLettered += uint64(len(entries))
+			bs.statsMu.Unlock()
+			releaseEntries(entries)
+			return
+		}
+		handleError(fmt.Errorf("%s: dead-letter sink failed: %w", bs.Name(), err))
+	}
+	bs.recordDrop(len(entries), reason)
+	releaseEntries(entries)
+}
+
+// batchEnd returns the end index and approximate byte size of the batch
+// starting at start, bounded by the current batch size and MaxBatchBytes of
+// serialized size. A batch always holds at least one entry, even if that
+// entry alone exceeds MaxBatchBytes.
+func (bs *BufferedSink) batchEnd(entries []*LogEntry, start int) (int, int) {
+	end := start + bs.batchSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	size := 0
+	if bs.config.MaxBatchBytes <= 0 {
+		for i := start; i < end; i++ {
+			size += approxEntrySize(entries[i])
+		}
+		return end, size
+	}
+
+	for i := start; i < end; i++ {
+		n := entrySize(entries[i])
+		if size+n > bs.config.MaxBatchBytes && i > start {
+			return i, size
+		}
+		size += n
+	}
+	return end, size
+}
+
+// approxEntrySize cheaply approximates the memory held by a log entry,
+// without serializing it
+func approxEntrySize(entry *LogEntry) int {
+	size := len(entry.Level) + len(entry.Message) + len(entry.ServiceName) +
+		len(entry.InstanceID) + len(entry.Environment) + len(entry.Version) + len(entry.Hostname) +
+		len(entry.Caller) + len(entry.Function) + len(entry.StackTrace) + len(entry.Encoded) + entryOverhead
+	for k, v := range entry.Fields {
+		size += len(k) + approxValueSize(v)
+	}
+	return size
+}
+
+// approxValueSize approximates the memory held by a field value
+func approxValueSize(v any) int {
+	switch val := v.(type) {
+	case string:
+		return len(val)
+	case []byte:
+		return len(val)
+	case error:
+		return len(val.Error())
+	default:
+		return fieldOverhead
+	}
+}
+
+// entrySize estimates the serialized size of a log entry in bytes
+func entrySize(entry *LogEntry) int {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		data, err = json.Marshal(sanitizeEntries([]*LogEntry{entry})[0])
+	}
+	if err != nil {
+		return len(entry.Encoded)
+	}
+	return len(data) + len(entry.Encoded)
+}
+
+// retryWriteBatch attempts to write a batch with retry logic. Retries use
+// jittered exponential backoff and stop once RetryTimeout has elapsed.
 func (bs *BufferedSink) retryWriteBatch(ctx context.Context, batch []*LogEntry) error {
 	var lastErr error
 	retryInterval := bs.config.RetryInterval
 
+	var deadline time.Time
+	if bs.config.RetryTimeout > 0 {
+		deadline = bs.clock.Now().Add(bs.config.RetryTimeout)
+	}
+
 	for attempt := 0; attempt <= bs.config.MaxRetries; attempt++ {
 		if attempt > 0 {
-			// Exponential backoff
+			// Exponential backoff with jitter, bounded by the retry budget
+			wait := jitter(retryInterval)
+			if !deadline.IsZero() && bs.clock.Now().Add(wait).After(deadline) {
+				return lastErr
+			}
+			bs.retryCount.Add(1)
 			select {
-			case <-time.After(retryInterval):
+			case <-bs.clock.After(wait):
 			case <-ctx.Done():
 				return ctx.Err()
 			case <-bs.stopChan:
-				return nil
+				return lastErr
 			}
 			retryInterval *= 2
 		}
 
 		// Create timeout context for this attempt
 		writeCtx, cancel := context.WithTimeout(ctx, bs.config.WriteTimeout)
-		err := bs.sink.WriteBatch(writeCtx, batch)
+		if info, ok := BatchInfoFromContext(ctx); ok {
+			info.Attempt = attempt + 1
+			writeCtx = WithBatchInfo(writeCtx, info)
+		}
+		err := bs.safeWriteBatch(writeCtx, batch)
 		cancel()
 
-		if err == nil {
-			return nil
+		if err == nil || IsPermanent(err) {
+			return err
 		}
 
 		lastErr = err
@@ -154,43 +537,266 @@ func (bs *BufferedSink) retryWriteBatch(ctx context.Context, batch []*LogEntry)
 	return lastErr
 }
 
-// backgroundFlusher periodically flushes the buffer
+// safeWriteBatch writes a batch to the underlying sink, converting a panic
+// inside the sink into an error so buffered entries are not lost
+func (bs *BufferedSink) safeWriteBatch(ctx context.Context, batch []*LogEntry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sink panicked in WriteBatch: %v", r)
+		}
+	}()
+	return bs.sink.WriteBatch(ctx, batch)
+}
+
+// jitter returns a random duration in [d/2, d) so that many instances
+// backing off at the same time do not retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(d-half)))
+}
+
+// backgroundFlusher periodically flushes the buffer, restarting the flush
+// loop if it panics so that logging never silently stops
 func (bs *BufferedSink) backgroundFlusher() {
 	defer bs.wg.Done()
 
+	for !bs.runFlusher() {
+		// Pause briefly before restarting to avoid a hot panic loop
+		select {
+		case <-bs.clock.After(flusherRestartDelay):
+		case <-bs.stopChan:
+			bs.runFinalFlush()
+			return
+		}
+	}
+}
+
+// runFlusher runs the flush loop. It returns true on shutdown and false if
+// the loop recovered from a panic and should be restarted.
+func (bs *BufferedSink) runFlusher() (stopped bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			handleError(fmt.Errorf("%s: flusher panicked, restarting: %v\n%s", bs.Name(), r, debug.Stack()))
+			stopped = false
+		}
+	}()
+
 	for {
 		select {
-		case <-bs.flushTicker.C:
+		case <-bs.flushTicker.C():
 			ctx, cancel := context.WithTimeout(context.Background(), bs.config.WriteTimeout)
-			_ = bs.Flush(ctx) // Ignore errors in background flush
+			_ = bs.Flush(ctx) // Failures are reported via the error handler
 			cancel()
 
-		case <-bs.stopChan:
-			// Final flush on shutdown
-			ctx, cancel := context.WithTimeout(context.Background(), bs.config.WriteTimeout*2)
+			if bs.config.AdaptiveBatching {
+				if interval, changed := bs.adapt(); changed {
+					bs.flushTicker.Reset(interval)
+				}
+			}
+
+		case <-bs.flushNow:
+			// High priority entry buffered, flush without waiting for the ticker
+			ctx, cancel := context.WithTimeout(context.Background(), bs.config.WriteTimeout)
 			_ = bs.Flush(ctx)
 			cancel()
-			return
+
+		case <-bs.stopChan:
+			bs.runFinalFlush()
+			return true
+		}
+	}
+}
+
+// runFinalFlush performs the final flush on shutdown
+func (bs *BufferedSink) runFinalFlush() {
+	defer func() {
+		if r := recover(); r != nil {
+			handleError(fmt.Errorf("%s: final flush panicked: %v\n%s", bs.Name(), r, debug.Stack()))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), bs.config.WriteTimeout*2)
+	defer cancel()
+	_ = bs.Flush(ctx)
+}
+
+// adapt adjusts batch size and flush interval based on the traffic seen since
+// the last adjustment. Sustained load (a full batch or more per interval)
+// doubles both; light load (under a quarter batch) halves both.
+func (bs *BufferedSink) adapt() (time.Duration, bool) {
+	bs.bufferMu.Lock()
+	defer bs.bufferMu.Unlock()
+
+	count := int(bs.recentCount.Swap(0))
+
+	batchSize, interval := bs.batchSize, bs.flushInterval
+	switch {
+	case count >= bs.batchSize:
+		batchSize *= 2
+		interval *= 2
+	case count < bs.batchSize/4:
+		batchSize /= 2
+		interval /= 2
+	default:
+		return bs.flushInterval, false
+	}
+
+	bs.batchSize = clampInt(batchSize, clampInt(bs.config.MinBatchSize, 1, bs.config.MaxBatchSize), bs.config.MaxBatchSize)
+	bs.batchHint.Store(int64(bs.batchSize))
+	interval = clampDuration(interval, bs.config.MinFlushInterval, bs.config.MaxFlushInterval)
+	if interval <= 0 || interval == bs.flushInterval {
+		return bs.flushInterval, false
+	}
+	bs.flushInterval = interval
+	bs.intervalHint.Store(int64(interval))
+	return interval, true
+}
+
+// Drain stops accepting writes and flushes buffered entries until the buffer
+// is empty or ctx expires. Entries still buffered at that point are abandoned.
+// The underlying sink is left open; call Close to release it.
+func (bs *BufferedSink) Drain(ctx context.Context) (DrainReport, error) {
+	start := bs.clock.Now()
+
+	bs.draining.Store(true)
+	bs.statsMu.Lock()
+	sentBefore, droppedBefore := bs.sentCount, bs.droppedCount
+	bs.statsMu.Unlock()
+
+	var err error
+	for {
+		bs.bufferMu.Lock()
+		err = bs.flushBuffer(ctx)
+		remaining := len(bs.buffer)
+		bs.bufferMu.Unlock()
+
+		if remaining == 0 {
+			break
+		}
+
+		// Back off between failed attempts until the deadline
+		select {
+		case <-bs.clock.After(bs.config.RetryInterval):
+			continue
+		case <-ctx.Done():
+			err = ctx.Err()
 		}
+		break
 	}
+
+	bs.bufferMu.Lock()
+	bs.collectShards()
+	if n := len(bs.buffer); n > 0 {
+		bs.recordDrop(n, DropReasonAbandoned)
+		releaseEntries(bs.buffer)
+		bs.buffer = bs.buffer[:0]
+		bs.bufferBytes = 0
+		bs.storeBuffered()
+	}
+	bs.bufferMu.Unlock()
+
+	bs.statsMu.Lock()
+	report := DrainReport{
+		Delivered: int(bs.sentCount - sentBefore),
+		Abandoned: int(bs.droppedCount - droppedBefore),
+	}
+	bs.statsMu.Unlock()
+
+	bs.stop()
+	report.Duration = bs.clock.Now().Sub(start)
+	return report, err
+}
+
+// stop terminates the background flusher, performing its final flush once
+func (bs *BufferedSink) stop() {
+	bs.stopOnce.Do(func() {
+		unregister(bs)
+		close(bs.stopChan)
+		bs.flushTicker.Stop()
+	})
+	bs.wg.Wait()
 }
 
 // Close gracefully shuts down the buffered sink
 func (bs *BufferedSink) Close() error {
-	close(bs.stopChan)
-	bs.flushTicker.Stop()
-	bs.wg.Wait()
+	bs.draining.Store(true)
+	bs.stop()
 	return bs.sink.Close()
 }
 
+// AcceptsEncoded reports whether the underlying sink consumes LogEntry.Encoded
+func (bs *BufferedSink) AcceptsEncoded() bool {
+	pe, ok := bs.sink.(PreEncoder)
+	return ok && pe.AcceptsEncoded()
+}
+
 // IsHealthy checks if the underlying sink is healthy
 func (bs *BufferedSink) IsHealthy() bool {
 	return bs.sink.IsHealthy()
 }
 
-// Stats returns buffering statistics
-func (bs *BufferedSink) Stats() (sent, dropped, buffered uint64) {
-	bs.bufferMu.Lock()
-	defer bs.bufferMu.Unlock()
-	return bs.sentCount, bs.droppedCount, uint64(len(bs.buffer))
+// LastError returns the last error from the underlying sink, if any
+func (bs *BufferedSink) LastError() error {
+	bs.statsMu.Lock()
+	defer bs.statsMu.Unlock()
+	return bs.lastError
+}
+
+// Validate checks the wrapped sink against its backend (see Validator)
+func (bs *BufferedSink) Validate(ctx context.Context) error {
+	return Validate(ctx, bs.sink)
+}
+
+// Reconfigure changes the endpoint of the wrapped sink (see Reconfigurable).
+// Entries already buffered are sent to the new endpoint.
+func (bs *BufferedSink) Reconfigure(update func(ep *Endpoint)) error {
+	return Reconfigure(bs.sink, update)
+}
+
+// Stats returns buffering statistics. It does not wait for flushes in
+// progress, so entries being sent count as neither buffered nor sent.
+func (bs *BufferedSink) Stats() Stats {
+	buffered := uint64(bs.buffered.Load() + bs.staged.Load())
+	bs.statsMu.Lock()
+	defer bs.statsMu.Unlock()
+	return Stats{
+		Sent:          bs.sentCount,
+		Dropped:       bs.droppedCount,
+		Buffered:      buffered,
+		Retries:       bs.retryCount.Load(),
+		FailedBatches: bs.failedBatches,
+		DeadLettered:  bs.deadLettered,
+		Late:          bs.lateCount,
+		BytesSent:     bs.bytesSent,
+		Batches:       bs.flushCount,
+		FlushTime:     bs.flushTime,
+		LastFlush:     bs.lastFlush,
+		LastError:     bs.lastError,
+		FlushLatency:  bs.latency.summary(),
+	}
+}
+
+// clampInt bounds v to [lo, hi]
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// clampDuration bounds d to [lo, hi], ignoring unset (zero) bounds
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if lo > 0 && d < lo {
+		return lo
+	}
+	if hi > 0 && d > hi {
+		return hi
+	}
+	return d
 }
@@ -0,0 +1,88 @@
+package sink
+
+import "net/http"
+
+// RetryableError marks a sink failure that may succeed if the batch is sent
+// again, e.g. a timeout or a 503 response
+type RetryableError struct {
+	Err error
+}
+
+// Error returns the wrapped error's message
+func (e *RetryableError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the wrapped error
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// PermanentError marks a sink failure that sending the batch again cannot
+// fix, e.g. rejected credentials or a 400 response. BufferedSink does not
+// retry it and hands the batch to Config.DeadLetter.
+type PermanentError struct {
+	Err error
+}
+
+// Error returns the wrapped error's message
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the wrapped error
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Retryable returns err marked as retryable, or nil if err is nil
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err}
+}
+
+// Permanent returns err marked as permanent, or nil if err is nil
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err is marked permanent. The outermost mark
+// wins, and a joined error is permanent only if all of its errors are, so a
+// batch is still retried when any part of it may succeed. Unmarked errors
+// are not permanent.
+func IsPermanent(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *PermanentError:
+		return true
+	case *RetryableError:
+		return false
+	case interface{ Unwrap() []error }:
+		errs := e.Unwrap()
+		for _, err := range errs {
+			if !IsPermanent(err) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	case interface{ Unwrap() error }:
+		return IsPermanent(e.Unwrap())
+	}
+	return false
+}
+
+// IsRetryable reports whether BufferedSink retries err, which is every
+// error not marked permanent
+func IsRetryable(err error) bool {
+	return err != nil && !IsPermanent(err)
+}
+
+// StatusError returns err classified by the HTTP status code of the
+// response that caused it: 408, 425, 429 and 5xx are retryable and other
+// codes permanent
+func StatusError(code int, err error) error {
+	switch {
+	case code == http.StatusRequestTimeout, code == http.StatusTooEarly,
+		code == http.StatusTooManyRequests, code >= 500:
+		return Retryable(err)
+	}
+	return Permanent(err)
+}
@@ -0,0 +1,138 @@
+package sink
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DebugInfo is a snapshot of a buffered sink's internals
+type DebugInfo struct {
+	Name          string      `json:"name"`
+	Healthy       bool        `json:"healthy"`
+	Draining      bool        `json:"draining"`
+	Stats         DebugStats  `json:"stats"`
+	BatchSize     int         `json:"batch_size"`
+	FlushInterval string      `json:"flush_interval"`
+	Config        DebugConfig `json:"config"`
+}
+
+// DebugStats is the JSON form of Stats
+type DebugStats struct {
+	Sent          uint64    `json:"sent"`
+	Dropped       uint64    `json:"dropped"`
+	Buffered      uint64    `json:"buffered"`
+	Retries       uint64    `json:"retries"`
+	FailedBatches uint64    `json:"failed_batches"`
+	BytesSent     uint64    `json:"bytes_sent"`
+	LastFlush     time.Time `json:"last_flush,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	LatencyP50    string    `json:"latency_p50"`
+	LatencyP99    string    `json:"latency_p99"`
+}
+
+// DebugConfig is a snapshot of the non-sensitive sink configuration
+type DebugConfig struct {
+	ServiceName      string `json:"service_name"`
+	Environment      string `json:"environment"`
+	BufferSize       int    `json:"buffer_size"`
+	MaxBufferBytes   int    `json:"max_buffer_bytes"`
+	MaxBatchSize     int    `json:"max_batch_size"`
+	MaxBatchBytes    int    `json:"max_batch_bytes"`
+	MaxRetries       int    `json:"max_retries"`
+	RetryInterval    string `json:"retry_interval"`
+	RetryTimeout     string `json:"retry_timeout"`
+	AdaptiveBatching bool   `json:"adaptive_batching"`
+	Shards           int    `json:"shards"`
+	DropOnFull       bool   `json:"drop_on_full"`
+}
+
+// Name returns the sink name from Config.Name, or a generated one
+func (bs *BufferedSink) Name() string {
+	if bs.config.Name != "" {
+		return bs.config.Name
+	}
+	return fmt.Sprintf("%T", bs.sink)
+}
+
+// DebugInfo returns a snapshot of the sink internals
+func (bs *BufferedSink) DebugInfo() DebugInfo {
+	stats := bs.Stats()
+
+	batchSize, flushInterval := bs.batchSizeHint(), time.Duration(bs.intervalHint.Load())
+
+	info := DebugInfo{
+		Name:          bs.Name(),
+		Healthy:       bs.IsHealthy(),
+		Draining:      bs.draining.Load(),
+		BatchSize:     batchSize,
+		FlushInterval: flushInterval.String(),
+		Stats: DebugStats{
+			Sent:          stats.Sent,
+			Dropped:       stats.Dropped,
+			Buffered:      stats.Buffered,
+			Retries:       stats.Retries,
+			FailedBatches: stats.FailedBatches,
+			BytesSent:     stats.BytesSent,
+			LastFlush:     stats.LastFlush,
+			LatencyP50:    stats.FlushLatency.P50.String(),
+			LatencyP99:    stats.FlushLatency.P99.String(),
+		},
+		Config: DebugConfig{
+			ServiceName:      bs.config.ServiceName,
+			Environment:      bs.config.Environment,
+			BufferSize:       bs.config.BufferSize,
+			MaxBufferBytes:   bs.config.MaxBufferBytes,
+			MaxBatchSize:     bs.config.MaxBatchSize,
+			MaxBatchBytes:    bs.config.MaxBatchBytes,
+			MaxRetries:       bs.config.MaxRetries,
+			RetryInterval:    bs.config.RetryInterval.String(),
+			RetryTimeout:     bs.config.RetryTimeout.String(),
+			AdaptiveBatching: bs.config.AdaptiveBatching,
+			Shards:           bs.config.Shards,
+			DropOnFull:       bs.config.DropOnFull,
+		},
+	}
+	if stats.LastError != nil {
+		info.Stats.LastError = stats.LastError.Error()
+	}
+	return info
+}
+
+// Snapshot returns debug snapshots of every live buffered sink, sorted by name
+func Snapshot() []DebugInfo {
+	sinks := registered()
+	infos := make([]DebugInfo, 0, len(sinks))
+	for _, bs := range sinks {
+		infos = append(infos, bs.DebugInfo())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+var publishOnce sync.Once
+
+// PublishExpvar publishes sink snapshots under the given expvar name
+// (default: "zlog_sinks"). Only the first call has any effect.
+func PublishExpvar(name string) {
+	if name == "" {
+		name = "zlog_sinks"
+	}
+	publishOnce.Do(func() {
+		expvar.Publish(name, expvar.Func(func() any { return Snapshot() }))
+	})
+}
+
+// DebugHandler returns an http.Handler rendering sink snapshots as JSON
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(map[string]any{"sinks": Snapshot()})
+	})
+}
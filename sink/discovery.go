@@ -0,0 +1,260 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Resolver returns the addresses serving host, as "host:port" or as bare
+// hosts using the port of the sink URL
+type Resolver func(ctx context.Context, host string) ([]string, error)
+
+// SRVResolver returns a Resolver looking up the DNS SRV records of
+// _service._proto.host, or of host itself if service and proto are empty
+// (e.g. loki.service.consul). Only the targets of the lowest priority are
+// returned; their weights only affect their order.
+func SRVResolver(service, proto string) Resolver {
+	return func(ctx context.Context, host string) ([]string, error) {
+		_, srvs, err := net.DefaultResolver.LookupSRV(ctx, service, proto, host)
+		if err != nil {
+			return nil, err
+		}
+		var addrs []string
+		for _, srv := range srvs {
+			if srv.Priority != srvs[0].Priority {
+				break
+			}
+			target := strings.TrimSuffix(srv.Target, ".")
+			addrs = append(addrs, net.JoinHostPort(target, strconv.Itoa(int(srv.Port))))
+		}
+		return addrs, nil
+	}
+}
+
+// Discovery resolves the host of a remote sink's URL to a set of addresses
+// and spreads requests across them round-robin, so the sink follows backends
+// as they come and go. Requests keep the URL's host in their Host header
+// and TLS server name. A failed request marks the addresses stale, so the
+// retry of the batch goes to the next address and the host is resolved
+// again.
+type Discovery struct {
+	Resolver Resolver      // (default: SRVResolver("", ""))
+	Interval time.Duration // Re-resolution interval (default: 30s)
+}
+
+// discoveredHost holds the resolved addresses of a host
+type discoveredHost struct {
+	addrs    []string
+	resolved time.Time // Zero when stale
+	pending  bool      // A background resolution is running
+}
+
+// balancer is the http.RoundTripper of sinks with a Discovery
+type balancer struct {
+	discovery Discovery
+	transport *http.Transport
+	tls       *tls.Config
+	next      atomic.Uint64
+
+	mu          sync.Mutex
+	hosts       map[string]*discoveredHost // By URL host name
+	serverNames map[string]string          // URL host name by resolved address
+}
+
+// newTransport returns the HTTP transport of a remote sink, balancing its
+// requests when d is set
+func newTransport(tlsConfig *tls.Config, d *Discovery) http.RoundTripper {
+	t := &http.Transport{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 5,
+		IdleConnTimeout:     90 * time.Second,
+		TLSClientConfig:     tlsConfig,
+		ForceAttemptHTTP2:   true, // Kept with the custom TLS dialer of Discovery
+	}
+	if d == nil {
+		return t
+	}
+	b := &balancer{
+		discovery:   *d,
+		transport:   t,
+		tls:         tlsConfig,
+		hosts:       make(map[string]*discoveredHost),
+		serverNames: make(map[string]string),
+	}
+	if b.discovery.Resolver == nil {
+		b.discovery.Resolver = SRVResolver("", "")
+	}
+	if b.discovery.Interval <= 0 {
+		b.discovery.Interval = 30 * time.Second
+	}
+	t.DialTLSContext = b.dialTLS
+	return b
+}
+
+// RoundTrip sends req to the next address of its host
+func (b *balancer) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	addrs, err := b.addresses(req.Context(), host)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", host, err)
+	}
+	addr := addrs[b.next.Add(1)%uint64(len(addrs))]
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, urlPort(req))
+	}
+
+	out := req.Clone(req.Context())
+	if out.Host == "" {
+		out.Host = req.URL.Host
+	}
+	out.URL.Host = addr
+	b.mu.Lock()
+	b.serverNames[addr] = host
+	b.mu.Unlock()
+
+	resp, err := b.transport.RoundTrip(out)
+	if err != nil {
+		b.stale(host)
+	}
+	return resp, err
+}
+
+// CloseIdleConnections closes the idle connections of the transport
+func (b *balancer) CloseIdleConnections() {
+	b.transport.CloseIdleConnections()
+}
+
+// addresses returns the addresses of host, resolving it on first use and
+// again in the background once they are older than the interval
+func (b *balancer) addresses(ctx context.Context, host string) ([]string, error) {
+	b.mu.Lock()
+	h := b.hosts[host]
+	if h == nil {
+		h = &discoveredHost{}
+		b.hosts[host] = h
+	}
+	addrs := h.addrs
+	refresh := time.Since(h.resolved) >= b.discovery.Interval && !h.pending
+	if refresh && len(addrs) > 0 {
+		h.pending = true
+	}
+	b.mu.Unlock()
+
+	switch {
+	case len(addrs) == 0:
+		return b.resolve(ctx, host)
+	case refresh:
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), b.discovery.Interval)
+			defer cancel()
+			b.resolve(ctx, host)
+		}()
+	}
+	return addrs, nil
+}
+
+// resolve looks up host and stores its addresses, keeping the previous ones
+// if the lookup fails or returns none
+func (b *balancer) resolve(ctx context.Context, host string) ([]string, error) {
+	addrs, err := b.discovery.Resolver(ctx, host)
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("no addresses")
+	}
+
+	b.mu.Lock()
+	h := b.hosts[host]
+	h.pending = false
+	if err != nil {
+		b.mu.Unlock()
+		return nil, err
+	}
+	slices.Sort(addrs)
+	changed := !slices.Equal(h.addrs, addrs)
+	h.addrs, h.resolved = addrs, time.Now()
+	if changed {
+		// Forget the server names of removed addresses
+		for addr, name := range b.serverNames {
+			if name == host && !resolvedAddr(addrs, addr) {
+				delete(b.serverNames, addr)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	if changed {
+		// Pooled connections may go to removed backends
+		b.transport.CloseIdleConnections()
+	}
+	return addrs, nil
+}
+
+// resolvedAddr reports whether addr, a "host:port" dialed by RoundTrip, is
+// one of addrs, given with or without a port
+func resolvedAddr(addrs []string, addr string) bool {
+	if slices.Contains(addrs, addr) {
+		return true
+	}
+	host, _, err := net.SplitHostPort(addr)
+	return err == nil && slices.Contains(addrs, host)
+}
+
+// stale makes the next request of host resolve it again
+func (b *balancer) stale(host string) {
+	b.mu.Lock()
+	if h := b.hosts[host]; h != nil {
+		h.resolved = time.Time{}
+	}
+	b.mu.Unlock()
+}
+
+// dialTLS connects to a resolved address, verifying the certificate of the
+// URL's host rather than of the address. It offers HTTP/2 through ALPN unless
+// the TLS config sets NextProtos.
+func (b *balancer) dialTLS(ctx context.Context, network, addr string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{}
+	if b.tls != nil {
+		cfg = b.tls.Clone()
+	}
+	if len(cfg.NextProtos) == 0 {
+		cfg.NextProtos = []string{"h2", "http/1.1"}
+	}
+	if cfg.ServerName == "" {
+		b.mu.Lock()
+		cfg.ServerName = b.serverNames[addr]
+		b.mu.Unlock()
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+	}
+	tc := tls.Client(conn, cfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tc, nil
+}
+
+// urlPort returns the port of req's URL, or the default port of its scheme
+func urlPort(req *http.Request) string {
+	if port := req.URL.Port(); port != "" {
+		return port
+	}
+	if req.URL.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
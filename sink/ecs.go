@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatECS selects the Elastic Common Schema layout (see ECSDocument).
+// There is no Elasticsearch sink; ship ECS documents through an HTTPSink to a
+// collector that indexes them, such as Logstash.
+const FormatECS = "ecs"
+
+// ECSVersion is the ECS version entries are written against
+const ECSVersion = "8.11.0"
+
+// ECS field names
+const (
+	ECSTimestamp       = "@timestamp"
+	ECSLevel           = "log.level"
+	ECSSeverity        = "event.severity"
+	ECSLogger          = "log.logger"
+	ECSMessage         = "message"
+	ECSOriginFile      = "log.origin.file.name"
+	ECSOriginLine      = "log.origin.file.line"
+	ECSOriginFunction  = "log.origin.function"
+	ECSErrorMessage    = "error.message"
+	ECSErrorStackTrace = "error.stack_trace"
+	ECSTraceID         = "trace.id"
+	ECSSpanID          = "span.id"
+	ECSServiceName     = "service.name"
+	ECSServiceNode     = "service.node.name"
+	ECSServiceEnv      = "service.environment"
+	ECSServiceVersion  = "service.version"
+	ECSHostName        = "host.name"
+	ECSVersionField    = "ecs.version"
+)
+
+// ecsLabelsPrefix prefixes resource attributes in ECS documents
+const ecsLabelsPrefix = "labels."
+
+// ecsFieldNames maps well-known field keys to their ECS names
+var ecsFieldNames = map[string]string{
+	"error":          ECSErrorMessage,
+	"stack_trace":    ECSErrorStackTrace,
+	"hostname":       ECSHostName,
+	FieldTraceID:     ECSTraceID,
+	FieldSpanID:      ECSSpanID,
+	FieldService:     ECSServiceName,
+	FieldInstance:    ECSServiceNode,
+	FieldEnvironment: ECSServiceEnv,
+	FieldVersion:     ECSServiceVersion,
+}
+
+// ECSFieldName returns the ECS name of a field key, or the key itself if it
+// has no ECS equivalent
+func ECSFieldName(key string) string {
+	if name, ok := ecsFieldNames[key]; ok {
+		return name
+	}
+	return key
+}
+
+// ECSDocument converts entry into an ECS document with dotted keys, which
+// Elasticsearch expands into objects. Well-known fields are renamed (see
+// ECSFieldName) and resource attributes and entry labels are written as
+// labels.
+func ECSDocument(entry *LogEntry) map[string]any {
+	doc := make(map[string]any, len(entry.Fields)+12)
+	for k, v := range entry.Fields {
+		doc[ECSFieldName(k)] = v
+	}
+
+	doc[ECSTimestamp] = entry.Timestamp.UTC().Format(time.RFC3339Nano)
+	doc[ECSLevel] = entry.Level
+	if severity := entry.SeverityNumber(); severity != 0 {
+		doc[ECSSeverity] = severity
+	}
+	doc[ECSMessage] = entry.Message
+	doc[ECSVersionField] = ECSVersion
+
+	setECS := func(key, value string) {
+		if value != "" {
+			doc[key] = value
+		}
+	}
+	setECS(ECSServiceName, entry.ServiceName)
+	setECS(ECSServiceNode, entry.InstanceID)
+	setECS(ECSServiceEnv, entry.Environment)
+	setECS(ECSServiceVersion, entry.Version)
+	setECS(ECSHostName, entry.Hostname)
+	setECS(ECSOriginFunction, entry.Function)
+	setECS(ECSErrorStackTrace, entry.StackTrace)
+	setECS(ECSTraceID, entry.TraceID)
+	setECS(ECSSpanID, entry.SpanID)
+	for k, v := range entry.Resource {
+		doc[ecsLabelsPrefix+k] = v
+	}
+	for k, v := range entry.Labels {
+		doc[ecsLabelsPrefix+k] = v
+	}
+
+	if entry.Caller != "" {
+		doc[ECSOriginFile] = entry.Caller
+		if i := strings.LastIndexByte(entry.Caller, ':'); i >= 0 {
+			if line, err := strconv.Atoi(entry.Caller[i+1:]); err == nil {
+				doc[ECSOriginFile] = entry.Caller[:i]
+				doc[ECSOriginLine] = line
+			}
+		}
+	}
+	return doc
+}
@@ -0,0 +1,218 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Encoder serializes log entries for sinks that send bytes (HTTPSink bodies,
+// Loki log lines, SocketSink lines), so new wire formats don't require new
+// sinks
+type Encoder interface {
+	Encode(entry *LogEntry) ([]byte, error)
+}
+
+// BatchEncoder is implemented by encoders with their own batch framing.
+// Batches of other encoders are sent as newline-separated entries.
+type BatchEncoder interface {
+	EncodeBatch(entries []*LogEntry) ([]byte, error)
+}
+
+// ContentTyper is implemented by encoders to set the HTTP Content-Type
+type ContentTyper interface {
+	ContentType() string
+}
+
+// encodeBatch encodes entries with enc's batch framing, or one per line
+func encodeBatch(enc Encoder, entries []*LogEntry) ([]byte, error) {
+	if be, ok := enc.(BatchEncoder); ok {
+		return be.EncodeBatch(entries)
+	}
+	var buf bytes.Buffer
+	for i, entry := range entries {
+		data, err := enc.Encode(entry)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// JSONEncoder encodes entries as LogEntry JSON objects, and batches as
+// {"logs": [...]}
+type JSONEncoder struct{}
+
+// Encode encodes entry as JSON
+func (JSONEncoder) Encode(entry *LogEntry) ([]byte, error) {
+	return json.Marshal(entry)
+}
+
+// EncodeBatch encodes entries as {"logs": [...]}
+func (JSONEncoder) EncodeBatch(entries []*LogEntry) ([]byte, error) {
+	return json.Marshal(map[string]any{"logs": entries})
+}
+
+// ContentType returns application/json
+func (JSONEncoder) ContentType() string {
+	return "application/json"
+}
+
+// ECSEncoder encodes entries as Elastic Common Schema documents (see
+// ECSDocument), and batches as {"logs": [...]}
+type ECSEncoder struct{}
+
+// Encode encodes entry as an ECS document
+func (ECSEncoder) Encode(entry *LogEntry) ([]byte, error) {
+	return json.Marshal(ECSDocument(entry))
+}
+
+// EncodeBatch encodes entries as {"logs": [...]} of ECS documents
+func (ECSEncoder) EncodeBatch(entries []*LogEntry) ([]byte, error) {
+	docs := make([]map[string]any, len(entries))
+	for i, entry := range entries {
+		docs[i] = ECSDocument(entry)
+	}
+	return json.Marshal(map[string]any{"logs": docs})
+}
+
+// ContentType returns application/json
+func (ECSEncoder) ContentType() string {
+	return "application/json"
+}
+
+// LogfmtEncoder encodes entries as logfmt lines: time, level and msg first,
+// then the metadata and fields in key order
+type LogfmtEncoder struct{}
+
+// Encode encodes entry as a logfmt line
+func (LogfmtEncoder) Encode(entry *LogEntry) ([]byte, error) {
+	buf := AppendLogfmt(nil, "time", entry.Timestamp)
+	buf = AppendLogfmt(buf, "level", entry.Level)
+	if severity := entry.SeverityNumber(); severity != 0 {
+		buf = AppendLogfmt(buf, FieldSeverity, severity)
+	}
+	buf = AppendLogfmt(buf, "msg", entry.Message)
+
+	data := make(map[string]any, len(entry.Fields)+len(entry.Resource)+len(entry.Labels)+8)
+	for k, v := range entry.Fields {
+		data[k] = v
+	}
+	for k, v := range entry.Resource {
+		data[k] = v
+	}
+	for k, v := range entry.Labels {
+		data[k] = v
+	}
+	for k, v := range map[string]string{
+		FieldService:     entry.ServiceName,
+		FieldInstance:    entry.InstanceID,
+		FieldEnvironment: entry.Environment,
+		FieldVersion:     entry.Version,
+		"hostname":       entry.Hostname,
+		"caller":         entry.Caller,
+		"function":       entry.Function,
+		"stack_trace":    entry.StackTrace,
+		FieldTraceID:     entry.TraceID,
+		FieldSpanID:      entry.SpanID,
+	} {
+		if v != "" {
+			data[k] = v
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		buf = AppendLogfmt(buf, k, data[k])
+	}
+	return buf, nil
+}
+
+// ContentType returns text/plain
+func (LogfmtEncoder) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// msgpackHandle encodes MessagePack using the current spec, with str8,
+// bin and the timestamp extension
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// MsgpackEncoder encodes entries as MessagePack maps with the LogEntry JSON
+// keys, and batches as arrays. Field values are sanitized first since the
+// codec would encode errors as empty maps and read from channels.
+type MsgpackEncoder struct{}
+
+// Encode encodes entry as a MessagePack map
+func (MsgpackEncoder) Encode(entry *LogEntry) ([]byte, error) {
+	return encodeCodec(msgpackHandle, sanitizeEntries([]*LogEntry{entry})[0])
+}
+
+// EncodeBatch encodes entries as a MessagePack array
+func (MsgpackEncoder) EncodeBatch(entries []*LogEntry) ([]byte, error) {
+	return encodeCodec(msgpackHandle, sanitizeEntries(entries))
+}
+
+// ContentType returns application/msgpack
+func (MsgpackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+// encodeCodec encodes v with a ugorji codec handle
+func encodeCodec(h codec.Handle, v any) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, h).Encode(v)
+	return data, err
+}
+
+// cborHandle encodes CBOR with times as epoch seconds (tag 1)
+var cborHandle = &codec.CborHandle{}
+
+// CBOREncoder encodes entries as CBOR maps with the LogEntry JSON keys, and
+// batches as arrays. Field values are sanitized first, as for MsgpackEncoder.
+type CBOREncoder struct{}
+
+// Encode encodes entry as a CBOR map
+func (CBOREncoder) Encode(entry *LogEntry) ([]byte, error) {
+	return encodeCodec(cborHandle, sanitizeEntries([]*LogEntry{entry})[0])
+}
+
+// EncodeBatch encodes entries as a CBOR array
+func (CBOREncoder) EncodeBatch(entries []*LogEntry) ([]byte, error) {
+	return encodeCodec(cborHandle, sanitizeEntries(entries))
+}
+
+// ContentType returns application/cbor
+func (CBOREncoder) ContentType() string {
+	return "application/cbor"
+}
+
+// NewEncoder returns the encoder named json, ecs, logfmt, msgpack, cbor,
+// protobuf, cef or leef; the CEF and LEEF encoders use the default SIEMConfig
+func NewEncoder(name string) (Encoder, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return JSONEncoder{}, nil
+	case FormatECS:
+		return ECSEncoder{}, nil
+	case LineFormatLogfmt:
+		return LogfmtEncoder{}, nil
+	case "msgpack":
+		return MsgpackEncoder{}, nil
+	case "cbor":
+		return CBOREncoder{}, nil
+	case "protobuf":
+		return ProtobufEncoder{}, nil
+	case "cef":
+		return NewCEFEncoder(SIEMConfig{}), nil
+	case "leef":
+		return NewLEEFEncoder(SIEMConfig{}), nil
+	}
+	return nil, fmt.Errorf("unknown encoder %q", name)
+}
@@ -0,0 +1,76 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errorReportInterval is the minimum interval between reports written by the
+// default error handler
+const errorReportInterval = time.Second
+
+// errorHandler receives internal pipeline errors (nil means default)
+var errorHandler atomic.Pointer[func(error)]
+
+// SetErrorHandler installs a handler for internal errors of the logging
+// pipeline itself: marshal failures, dropped entries, retry exhaustion and
+// recovered panics. Passing nil restores the default, which writes to stderr
+// at most once per second. The handler may be called concurrently and while
+// sink locks are held, so it must not block or log through the pipeline.
+func SetErrorHandler(h func(error)) {
+	if h == nil {
+		errorHandler.Store(nil)
+		return
+	}
+	errorHandler.Store(&h)
+}
+
+// HandleError reports an internal error of a sink wrapper outside this
+// package, e.g. a failed background write, to the handler installed with
+// SetErrorHandler
+func HandleError(err error) {
+	handleError(err)
+}
+
+// handleError forwards an internal error to the installed handler
+func handleError(err error) {
+	if err == nil {
+		return
+	}
+	if h := errorHandler.Load(); h != nil {
+		(*h)(err)
+		return
+	}
+	defaultErrorHandler(err)
+}
+
+// defaultReporter rate-limits stderr output of the default error handler
+var defaultReporter struct {
+	sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// defaultErrorHandler writes err to stderr unless another error was reported
+// within errorReportInterval, in which case it is counted and suppressed
+func defaultErrorHandler(err error) {
+	defaultReporter.Lock()
+	defer defaultReporter.Unlock()
+
+	now := time.Now()
+	if now.Sub(defaultReporter.last) < errorReportInterval {
+		defaultReporter.suppressed++
+		return
+	}
+
+	if defaultReporter.suppressed > 0 {
+		fmt.Fprintf(os.Stderr, "zlog: %v (%d similar errors suppressed)\n", err, defaultReporter.suppressed)
+	} else {
+		fmt.Fprintf(os.Stderr, "zlog: %v\n", err)
+	}
+	defaultReporter.last = now
+	defaultReporter.suppressed = 0
+}
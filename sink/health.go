@@ -0,0 +1,69 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HealthStatus describes the health of a single sink
+type HealthStatus struct {
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// HealthReport is the aggregated health of the log pipeline
+type HealthReport struct {
+	Healthy bool           `json:"healthy"`
+	Sinks   []HealthStatus `json:"sinks"`
+}
+
+// lastErrorer is implemented by sinks that remember their last error
+type lastErrorer interface {
+	LastError() error
+}
+
+// CheckHealth aggregates the health of every live buffered sink plus any
+// additional (e.g. unbuffered) sinks given
+func CheckHealth(extra ...Sink) HealthReport {
+	report := HealthReport{Healthy: true}
+	for _, info := range Snapshot() {
+		report.add(HealthStatus{Name: info.Name, Healthy: info.Healthy, LastError: info.Stats.LastError})
+	}
+	for _, s := range extra {
+		status := HealthStatus{Name: fmt.Sprintf("%T", s), Healthy: s.IsHealthy()}
+		if le, ok := s.(lastErrorer); ok {
+			if err := le.LastError(); err != nil {
+				status.LastError = err.Error()
+			}
+		}
+		report.add(status)
+	}
+	return report
+}
+
+// add appends a sink status, marking the report unhealthy if needed
+func (r *HealthReport) add(status HealthStatus) {
+	r.Sinks = append(r.Sinks, status)
+	if !status.Healthy {
+		r.Healthy = false
+	}
+}
+
+// HealthHandler returns an http.Handler suitable for readiness probes. It
+// responds 200 when every sink is healthy and 503 otherwise, with a JSON
+// HealthReport body.
+func HealthHandler(extra ...Sink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := CheckHealth(extra...)
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Healthy {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -3,12 +3,13 @@ package sink
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"strings"
+	"sync"
 	"sync/atomic"
-	"time"
 )
 
 // HTTPSinkConfig holds HTTP-specific configuration
@@ -17,9 +18,17 @@ type HTTPSinkConfig struct {
 	URL         string            // HTTP endpoint URL
 	Method      string            // HTTP method (default: POST)
 	Headers     map[string]string // Additional HTTP headers
-	ContentType string            // Content-Type header (default: application/json)
+	ContentType string            // Content-Type header (default: from Encoder, else application/json)
 	BearerToken string            // Optional bearer token for authentication
 	BasicAuth   *BasicAuth        // Optional basic authentication
+	Discovery   *Discovery        // Optional resolution of the URL's host
+	Format      string            // Entry layout: "" for LogEntry JSON or FormatECS
+	Encoder     Encoder           // Body encoder (default: JSONEncoder, or ECSEncoder for FormatECS)
+
+	// FallbackEncoders are tried in order when the endpoint answers 415
+	// Unsupported Media Type, skipping those not listed in the response's
+	// Accept header. The negotiated encoder is kept for later batches.
+	FallbackEncoders []Encoder
 }
 
 // BasicAuth holds basic authentication credentials
@@ -28,12 +37,24 @@ type BasicAuth struct {
 	Password string
 }
 
+// authorize sets the bearer token or basic credentials of req, if any
+func authorize(req *http.Request, bearerToken string, basic *BasicAuth) {
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	} else if basic != nil {
+		req.SetBasicAuth(basic.Username, basic.Password)
+	}
+}
+
 // HTTPSink sends logs to an HTTP endpoint
 type HTTPSink struct {
-	config     *HTTPSinkConfig
+	config     atomic.Pointer[HTTPSinkConfig] // Current settings, loaded once per request
+	reconfigMu sync.Mutex                     // Serializes Reconfigure
 	client     *http.Client
+	encoders   []Encoder    // Encoder followed by FallbackEncoders
+	encoder    atomic.Int32 // Index of the negotiated encoder
 	isHealthy  atomic.Bool
-	lastError  atomic.Value
+	lastError  atomic.Pointer[error] // Errors of differing types, which atomic.Value rejects
 }
 
 // NewHTTPSink creates a new HTTP sink
@@ -50,28 +71,38 @@ func NewHTTPSink(config *HTTPSinkConfig) (*HTTPSink, error) {
 	if config.Method == "" {
 		config.Method = http.MethodPost
 	}
+	if config.Format != "" && config.Format != FormatECS {
+		return nil, fmt.Errorf("unknown format %q", config.Format)
+	}
+	if config.Encoder == nil {
+		config.Encoder = JSONEncoder{}
+		if config.Format == FormatECS {
+			config.Encoder = ECSEncoder{}
+		}
+	}
 	if config.ContentType == "" {
 		config.ContentType = "application/json"
+		if ct, ok := config.Encoder.(ContentTyper); ok {
+			config.ContentType = ct.ContentType()
+		}
 	}
 
 	sink := &HTTPSink{
-		config: config,
+		encoders: append([]Encoder{config.Encoder}, config.FallbackEncoders...),
 		client: &http.Client{
-			Timeout: config.ConnTimeout + config.WriteTimeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        10,
-				MaxIdleConnsPerHost: 5,
-				IdleConnTimeout:     90 * time.Second,
-			},
+			Timeout:   config.ConnTimeout + config.WriteTimeout,
+			Transport: newTransport(nil, config.Discovery),
 		},
 	}
 
+	sink.config.Store(config)
 	sink.isHealthy.Store(true)
 	return sink, nil
 }
 
 // Write sends a single log entry
 func (s *HTTPSink) Write(ctx context.Context, entry *LogEntry) error {
+	defer ReleaseEntry(entry)
 	return s.WriteBatch(ctx, []*LogEntry{entry})
 }
 
@@ -81,55 +112,175 @@ func (s *HTTPSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
 		return nil
 	}
 
-	// Serialize entries to JSON
-	payload, err := json.Marshal(map[string]any{
-		"logs": entries,
-	})
+	for {
+		cur := int(s.encoder.Load())
+		next, err := s.send(ctx, cur, entries)
+		if next < 0 {
+			return err
+		}
+		// The endpoint rejected the encoding; retry with the negotiated one
+		s.encoder.CompareAndSwap(int32(cur), int32(next))
+	}
+}
+
+// send posts entries encoded with the enc-th encoder. When the endpoint
+// answers 415 Unsupported Media Type it returns the index of the encoder to
+// try next, or -1 otherwise.
+func (s *HTTPSink) send(ctx context.Context, enc int, entries []*LogEntry) (next int, err error) {
+	encoder := s.encoders[enc]
+	cfg := s.config.Load()
+
+	// Serialize entries
+	payload, err := encodeBatch(encoder, entries)
 	if err != nil {
-		s.recordError(fmt.Errorf("failed to marshal logs: %w", err))
-		return err
+		// Replace unencodable field values rather than dropping the batch
+		payload, err = encodeBatch(encoder, sanitizeEntries(entries))
+	}
+	if err != nil {
+		err = Permanent(fmt.Errorf("failed to marshal logs: %w", err))
+		s.recordError(err)
+		handleError(fmt.Errorf("http: %w", err))
+		return -1, err
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, s.config.Method, s.config.URL, bytes.NewReader(payload))
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, bytes.NewReader(payload))
 	if err != nil {
 		s.recordError(fmt.Errorf("failed to create request: %w", err))
-		return err
+		return -1, Permanent(err)
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", s.config.ContentType)
-	for key, value := range s.config.Headers {
+	req.Header.Set("Content-Type", s.contentType(enc))
+	for key, value := range cfg.Headers {
 		req.Header.Set(key, value)
 	}
+	setBatchHeaders(ctx, req.Header, cfg.Config)
 
 	// Add authentication
-	if s.config.BearerToken != "" {
-		req.Header.Set("Authorization", "Bearer "+s.config.BearerToken)
-	} else if s.config.BasicAuth != nil {
-		req.SetBasicAuth(s.config.BasicAuth.Username, s.config.BasicAuth.Password)
-	}
+	authorize(req, cfg.BearerToken, cfg.BasicAuth)
 
 	// Send request
 	resp, err := s.client.Do(req)
 	if err != nil {
 		s.recordError(fmt.Errorf("failed to send logs: %w", err))
-		return err
+		return -1, Retryable(err)
 	}
 	defer resp.Body.Close()
 
 	// Check response status
+	if resp.StatusCode == http.StatusUnsupportedMediaType {
+		if next := s.negotiate(enc, resp.Header.Get("Accept")); next >= 0 {
+			return next, nil
+		}
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		err := fmt.Errorf("HTTP error: %d %s - %s", resp.StatusCode, resp.Status, string(body))
+		err := StatusError(resp.StatusCode, fmt.Errorf("HTTP error: %d %s - %s", resp.StatusCode, resp.Status, string(body)))
 		s.recordError(err)
-		return err
+		return -1, err
 	}
 
 	s.isHealthy.Store(true)
+	return -1, nil
+}
+
+// Validate checks that the endpoint is reachable and accepts the sink's
+// headers and credentials with an OPTIONS request, or HEAD where OPTIONS is
+// not allowed. Nothing is posted; an endpoint allowing neither passes.
+func (s *HTTPSink) Validate(ctx context.Context) error {
+	cfg := s.config.Load()
+	for _, method := range []string{http.MethodOptions, http.MethodHead} {
+		req, err := http.NewRequestWithContext(ctx, method, cfg.URL, nil)
+		if err != nil {
+			return fmt.Errorf("http: %w", err)
+		}
+		for key, value := range cfg.Headers {
+			req.Header.Set(key, value)
+		}
+		authorize(req, cfg.BearerToken, cfg.BasicAuth)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("http: %w", err)
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusMethodNotAllowed, resp.StatusCode == http.StatusNotImplemented:
+			continue
+		case resp.StatusCode >= 400:
+			return fmt.Errorf("http: %s %s: %s", method, cfg.URL, resp.Status)
+		}
+		return nil
+	}
 	return nil
 }
 
+// Reconfigure changes the URL, headers or credentials of the sink (see
+// Reconfigurable)
+func (s *HTTPSink) Reconfigure(update func(ep *Endpoint)) error {
+	s.reconfigMu.Lock()
+	defer s.reconfigMu.Unlock()
+
+	cur := s.config.Load()
+	ep := Endpoint{
+		URL:         cur.URL,
+		Headers:     maps.Clone(cur.Headers),
+		BearerToken: cur.BearerToken,
+		BasicAuth:   cur.BasicAuth,
+	}
+	update(&ep)
+	if ep.URL == "" {
+		return fmt.Errorf("URL is required")
+	}
+	config := *cur
+	config.URL, config.Headers = ep.URL, ep.Headers
+	config.BearerToken, config.BasicAuth = ep.BearerToken, ep.BasicAuth
+	s.config.Store(&config)
+	return nil
+}
+
+// contentType returns the Content-Type of the enc-th encoder
+func (s *HTTPSink) contentType(enc int) string {
+	if enc == 0 {
+		return s.config.Load().ContentType
+	}
+	if ct, ok := s.encoders[enc].(ContentTyper); ok {
+		return ct.ContentType()
+	}
+	return "application/octet-stream"
+}
+
+// negotiate returns the index of the first encoder after enc whose media
+// type the endpoint accepts, per the Accept header of its 415 response if
+// any, or -1 if there is none
+func (s *HTTPSink) negotiate(enc int, accept string) int {
+	for i := enc + 1; i < len(s.encoders); i++ {
+		if accept == "" || acceptsMediaType(accept, s.contentType(i)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// acceptsMediaType reports whether an Accept header value lists the media
+// type of contentType
+func acceptsMediaType(accept, contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	for _, item := range strings.Split(accept, ",") {
+		item, _, _ = strings.Cut(item, ";")
+		item = strings.TrimSpace(item)
+		if item == "*/*" || strings.EqualFold(item, mediaType) {
+			return true
+		}
+		if typ, ok := strings.CutSuffix(item, "/*"); ok && strings.HasPrefix(mediaType, typ+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Flush is a no-op for HTTP sink (handled by BufferedSink)
 func (s *HTTPSink) Flush(ctx context.Context) error {
 	return nil
@@ -148,8 +299,8 @@ func (s *HTTPSink) IsHealthy() bool {
 
 // LastError returns the last error encountered
 func (s *HTTPSink) LastError() error {
-	if val := s.lastError.Load(); val != nil {
-		return val.(error)
+	if err := s.lastError.Load(); err != nil {
+		return *err
 	}
 	return nil
 }
@@ -157,5 +308,5 @@ func (s *HTTPSink) LastError() error {
 // recordError records an error and marks the sink as unhealthy
 func (s *HTTPSink) recordError(err error) {
 	s.isHealthy.Store(false)
-	s.lastError.Store(err)
+	s.lastError.Store(&err)
 }
@@ -0,0 +1,136 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Line formats for sinks that send text lines (see LokiSinkConfig.LineFormat)
+const (
+	LineFormatJSON   = "json"
+	LineFormatLogfmt = "logfmt"
+)
+
+// AppendLogfmt appends key=value to buf, preceded by a space unless buf is
+// empty. Maps are flattened into dotted keys in key order, strings are quoted
+// when needed and other composite values are written as quoted JSON.
+func AppendLogfmt(buf []byte, key string, value any) []byte {
+	if m, ok := value.(map[string]any); ok {
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			buf = AppendLogfmt(buf, key+"."+k, m[k])
+		}
+		return buf
+	}
+
+	if len(buf) > 0 {
+		buf = append(buf, ' ')
+	}
+	buf = appendLogfmtKey(buf, key)
+	buf = append(buf, '=')
+	return appendLogfmtValue(buf, value)
+}
+
+// appendLogfmtKey appends key with the characters logfmt keys cannot hold
+// replaced by underscores
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			r = '_'
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
+
+// appendLogfmtValue appends a single value
+func appendLogfmtValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return buf
+	case string:
+		return appendLogfmtString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return strconv.AppendFloat(buf, float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case time.Time:
+		return v.AppendFormat(buf, time.RFC3339Nano)
+	case time.Duration:
+		return append(buf, v.String()...)
+	case error:
+		return appendLogfmtString(buf, v.Error())
+	case fmt.Stringer:
+		return appendLogfmtString(buf, v.String())
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		// Replace unencodable values with placeholders
+		sanitized := sanitizeValue(value)
+		if s, ok := sanitized.(string); ok {
+			return appendLogfmtString(buf, s)
+		}
+		data, _ = json.Marshal(sanitized)
+	}
+	return appendLogfmtString(buf, string(data))
+}
+
+// appendLogfmtString appends s, quoting it if it is empty or holds spaces,
+// quotes, equals signs or control characters
+func appendLogfmtString(buf []byte, s string) []byte {
+	if s == "" || strings.IndexFunc(s, needsLogfmtQuote) >= 0 {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// needsLogfmtQuote reports whether r forces a value to be quoted
+func needsLogfmtQuote(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f
+}
+
+// logfmtLine formats data as a logfmt line with the msg key first and the
+// other keys in order
+func logfmtLine(data map[string]any) string {
+	var buf []byte
+	if msg, ok := data["msg"]; ok {
+		buf = AppendLogfmt(buf, "msg", msg)
+	}
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		if k != "msg" {
+			buf = AppendLogfmt(buf, k, data[k])
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,16 @@
+module github.com/hsdfat/go-zlog/sink/logrussink
+
+go 1.23
+
+require (
+	github.com/hsdfat/go-zlog v0.0.0
+	github.com/sirupsen/logrus v1.9.3
+)
+
+require (
+	github.com/ugorji/go/codec v1.2.12 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/hsdfat/go-zlog => ../..
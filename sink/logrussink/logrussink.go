@@ -0,0 +1,89 @@
+// Package logrussink provides a logrus.Hook that forwards logrus entries to a
+// go-zlog sink.Sink, so logrus-based code can share the same pipeline. It is
+// a separate module, so logrus only enters builds importing it.
+package logrussink
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+	"github.com/sirupsen/logrus"
+)
+
+// writeTimeout bounds each forwarded write
+const writeTimeout = 5 * time.Second
+
+// Hook forwards logrus entries to a sink
+type Hook struct {
+	sink     sink.Sink
+	levels   []logrus.Level
+	hostname string
+}
+
+// NewHook returns a hook forwarding entries at minLevel and above to s
+func NewHook(s sink.Sink, minLevel logrus.Level) *Hook {
+	hostname, _ := os.Hostname()
+	levels := make([]logrus.Level, 0, len(logrus.AllLevels))
+	for _, lvl := range logrus.AllLevels {
+		if lvl <= minLevel {
+			levels = append(levels, lvl)
+		}
+	}
+	return &Hook{sink: s, levels: levels, hostname: hostname}
+}
+
+// Levels returns the levels the hook fires for
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire converts the logrus entry to a sink.LogEntry and writes it
+func (h *Hook) Fire(e *logrus.Entry) error {
+	entry := sink.AcquireEntry()
+	entry.Timestamp = e.Time
+	entry.Level = levelString(e.Level)
+	entry.Message = e.Message
+	entry.Hostname = h.hostname
+	if e.HasCaller() {
+		entry.Caller = e.Caller.File + ":" + strconv.Itoa(e.Caller.Line)
+	}
+	for k, v := range e.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry.Fields[k] = v
+	}
+
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	return h.sink.Write(ctx, entry)
+}
+
+// levelString maps a logrus level to the sink level names
+func levelString(lvl logrus.Level) string {
+	switch lvl {
+	case logrus.TraceLevel:
+		return "trace"
+	case logrus.DebugLevel:
+		return "debug"
+	case logrus.InfoLevel:
+		return "info"
+	case logrus.WarnLevel:
+		return "warn"
+	case logrus.ErrorLevel:
+		return "error"
+	case logrus.FatalLevel:
+		return "fatal"
+	case logrus.PanicLevel:
+		return "panic"
+	default:
+		return "unknown"
+	}
+}
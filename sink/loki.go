@@ -3,11 +3,17 @@ package sink
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -20,14 +26,44 @@ type LokiSinkConfig struct {
 	Labels      map[string]string // Static labels to add to all logs
 	BearerToken string            // Optional bearer token for authentication
 	BasicAuth   *BasicAuth        // Optional basic authentication
+	TLSConfig   *tls.Config       // Optional TLS settings, e.g. a private CA or client certificates
+	Discovery   *Discovery        // Optional resolution of the URL's host, e.g. loki.service.consul
+
+	// LineFormat is the log line format: LineFormatJSON (default) or
+	// LineFormatLogfmt
+	LineFormat string
+
+	// Encoder, if set, formats log lines in place of LineFormat
+	Encoder Encoder
+
+	// TraceMetadata emits trace_id, span_id and log_id fields as Loki
+	// structured metadata (requires Loki 3.0+ with structured metadata enabled)
+	TraceMetadata bool
+
+	// MultiTenant pushes entries with a FieldTenant field under that
+	// tenant's X-Scope-OrgID, one request per tenant; other entries use
+	// TenantID
+	MultiTenant bool
+
+	// PreEncoded sends the JSON lines serialized by the logger's encoder
+	// instead of encoding entries again, saving one serialization per
+	// entry. The lines then follow the logger's encoder layout (e.g.
+	// stacktrace for stack_trace) and entries reach the sink without Fields.
+	PreEncoded bool
 }
 
 // LokiSink sends logs to Grafana Loki
 type LokiSink struct {
-	config    *LokiSinkConfig
-	client    *http.Client
-	isHealthy atomic.Bool
-	lastError atomic.Value
+	state      atomic.Pointer[lokiState] // Current settings, loaded once per request
+	reconfigMu sync.Mutex                // Serializes UpdateConfig and Reconfigure
+	isHealthy  atomic.Bool
+	lastError  atomic.Pointer[error] // Errors of differing types, which atomic.Value rejects
+}
+
+// lokiState is a snapshot of the settings of a LokiSink
+type lokiState struct {
+	config *LokiSinkConfig
+	client *http.Client
 }
 
 // lokiPushRequest represents the Loki push API request format
@@ -38,19 +74,41 @@ type lokiPushRequest struct {
 // lokiStream represents a single log stream in Loki
 type lokiStream struct {
 	Stream map[string]string `json:"stream"` // Labels
-	Values [][]string        `json:"values"` // [timestamp_ns, log_line]
+	Values [][]any           `json:"values"` // [timestamp_ns, log_line, optional structured metadata]
 }
 
 // NewLokiSink creates a new Loki sink
 func NewLokiSink(config *LokiSinkConfig) (*LokiSink, error) {
+	if err := prepareLokiConfig(config, nil); err != nil {
+		return nil, err
+	}
+
+	sink := &LokiSink{}
+	sink.state.Store(&lokiState{config: config, client: newLokiClient(config)})
+	sink.isHealthy.Store(true)
+	return sink, nil
+}
+
+// prepareLokiConfig checks config and fills in its defaults, taking the
+// common settings from prev when it has none
+func prepareLokiConfig(config *LokiSinkConfig, prev *LokiSinkConfig) error {
 	if config == nil {
-		return nil, fmt.Errorf("config is required")
+		return fmt.Errorf("config is required")
 	}
 	if config.Config == nil {
-		config.Config = DefaultConfig()
+		if prev != nil {
+			config.Config = prev.Config
+		} else {
+			config.Config = DefaultConfig()
+		}
 	}
 	if config.URL == "" {
-		return nil, fmt.Errorf("URL is required")
+		return fmt.Errorf("URL is required")
+	}
+	switch config.LineFormat {
+	case "", LineFormatJSON, LineFormatLogfmt:
+	default:
+		return fmt.Errorf("unknown line format %q", config.LineFormat)
 	}
 	if config.Labels == nil {
 		config.Labels = make(map[string]string)
@@ -66,25 +124,20 @@ func NewLokiSink(config *LokiSinkConfig) (*LokiSink, error) {
 	if config.InstanceID != "" && config.Labels["instance"] == "" {
 		config.Labels["instance"] = config.InstanceID
 	}
+	return nil
+}
 
-	sink := &LokiSink{
-		config: config,
-		client: &http.Client{
-			Timeout: config.ConnTimeout + config.WriteTimeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        10,
-				MaxIdleConnsPerHost: 5,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		},
+// newLokiClient returns the HTTP client of config
+func newLokiClient(config *LokiSinkConfig) *http.Client {
+	return &http.Client{
+		Timeout:   config.ConnTimeout + config.WriteTimeout,
+		Transport: newTransport(config.TLSConfig, config.Discovery),
 	}
-
-	sink.isHealthy.Store(true)
-	return sink, nil
 }
 
 // Write sends a single log entry
 func (s *LokiSink) Write(ctx context.Context, entry *LogEntry) error {
+	defer ReleaseEntry(entry)
 	return s.WriteBatch(ctx, []*LogEntry{entry})
 }
 
@@ -93,13 +146,39 @@ func (s *LokiSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
 	if len(entries) == 0 {
 		return nil
 	}
+	st := s.state.Load()
+	if !st.config.MultiTenant {
+		return s.push(ctx, st, st.config.TenantID, entries)
+	}
 
+	// Push each tenant's entries under its own org ID, in order of appearance
+	var tenants []string
+	byTenant := make(map[string][]*LogEntry)
+	for _, entry := range entries {
+		tenant := st.tenant(entry)
+		if _, ok := byTenant[tenant]; !ok {
+			tenants = append(tenants, tenant)
+		}
+		byTenant[tenant] = append(byTenant[tenant], entry)
+	}
+	var errs []error
+	for _, tenant := range tenants {
+		if err := s.push(ctx, st, tenant, byTenant[tenant]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// push sends entries to Loki with the settings of st under the tenant's org
+// ID ("" = none)
+func (s *LokiSink) push(ctx context.Context, st *lokiState, tenant string, entries []*LogEntry) error {
 	// Group entries by their labels (for Loki streams)
 	streamMap := make(map[string]*lokiStream)
 
 	for _, entry := range entries {
 		// Build labels for this entry
-		labels := s.buildLabels(entry)
+		labels := st.buildLabels(entry)
 		streamKey := s.labelsToKey(labels)
 
 		// Get or create stream
@@ -107,15 +186,21 @@ func (s *LokiSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
 		if !exists {
 			stream = &lokiStream{
 				Stream: labels,
-				Values: make([][]string, 0),
+				Values: make([][]any, 0),
 			}
 			streamMap[streamKey] = stream
 		}
 
 		// Convert entry to Loki format
 		timestamp := strconv.FormatInt(entry.Timestamp.UnixNano(), 10)
-		logLine := s.formatLogLine(entry)
-		stream.Values = append(stream.Values, []string{timestamp, logLine})
+		logLine := st.formatLogLine(entry)
+		value := []any{timestamp, logLine}
+		if st.config.TraceMetadata {
+			if metadata := traceMetadata(entry); metadata != nil {
+				value = append(value, metadata)
+			}
+		}
+		stream.Values = append(stream.Values, value)
 	}
 
 	// Build Loki push request
@@ -131,42 +216,41 @@ func (s *LokiSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
 	// Serialize to JSON
 	payload, err := json.Marshal(pushReq)
 	if err != nil {
-		s.recordError(fmt.Errorf("failed to marshal logs: %w", err))
+		err = Permanent(fmt.Errorf("failed to marshal logs: %w", err))
+		s.recordError(err)
+		handleError(fmt.Errorf("loki: %w", err))
 		return err
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, st.config.URL, bytes.NewReader(payload))
 	if err != nil {
 		s.recordError(fmt.Errorf("failed to create request: %w", err))
-		return err
+		return Permanent(err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	if s.config.TenantID != "" {
-		req.Header.Set("X-Scope-OrgID", s.config.TenantID)
+	if tenant != "" {
+		req.Header.Set("X-Scope-OrgID", tenant)
 	}
+	setBatchHeaders(ctx, req.Header, st.config.Config)
 
 	// Add authentication
-	if s.config.BearerToken != "" {
-		req.Header.Set("Authorization", "Bearer "+s.config.BearerToken)
-	} else if s.config.BasicAuth != nil {
-		req.SetBasicAuth(s.config.BasicAuth.Username, s.config.BasicAuth.Password)
-	}
+	authorize(req, st.config.BearerToken, st.config.BasicAuth)
 
 	// Send request
-	resp, err := s.client.Do(req)
+	resp, err := st.client.Do(req)
 	if err != nil {
 		s.recordError(fmt.Errorf("failed to send logs: %w", err))
-		return err
+		return Retryable(err)
 	}
 	defer resp.Body.Close()
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		err := fmt.Errorf("Loki error: %d %s - %s", resp.StatusCode, resp.Status, string(body))
+		err := StatusError(resp.StatusCode, fmt.Errorf("Loki error: %d %s - %s", resp.StatusCode, resp.Status, string(body)))
 		s.recordError(err)
 		return err
 	}
@@ -175,37 +259,249 @@ func (s *LokiSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
 	return nil
 }
 
+// UpdateConfig switches the sink to config, e.g. to change its URL, tenant,
+// labels or credentials without a restart. Requests already being sent
+// complete with the previous settings. A nil config.Config keeps the current
+// one; LineFormat and Encoder are kept, since loggers decide when they are
+// created whether to pre-encode lines for the sink.
+func (s *LokiSink) UpdateConfig(config *LokiSinkConfig) error {
+	s.reconfigMu.Lock()
+	defer s.reconfigMu.Unlock()
+	return s.updateConfig(config)
+}
+
+// updateConfig switches to config (must be called with reconfigMu held)
+func (s *LokiSink) updateConfig(config *LokiSinkConfig) error {
+	prev := s.state.Load()
+	if config != nil {
+		config.LineFormat, config.Encoder = prev.config.LineFormat, prev.config.Encoder
+	}
+	if err := prepareLokiConfig(config, prev.config); err != nil {
+		return err
+	}
+
+	// Keep the client, and its pooled connections, unless its settings changed
+	client := prev.client
+	if config.TLSConfig != prev.config.TLSConfig || config.Discovery != prev.config.Discovery ||
+		config.ConnTimeout+config.WriteTimeout != prev.config.ConnTimeout+prev.config.WriteTimeout {
+		client = newLokiClient(config)
+	}
+	s.state.Store(&lokiState{config: config, client: client})
+	if client != prev.client {
+		// Requests in flight keep their connections
+		prev.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Reconfigure changes the URL, tenant, labels or credentials of the sink
+// (see Reconfigurable)
+func (s *LokiSink) Reconfigure(update func(ep *Endpoint)) error {
+	s.reconfigMu.Lock()
+	defer s.reconfigMu.Unlock()
+
+	cur := s.state.Load().config
+	ep := Endpoint{
+		URL:         cur.URL,
+		TenantID:    cur.TenantID,
+		Labels:      maps.Clone(cur.Labels),
+		BearerToken: cur.BearerToken,
+		BasicAuth:   cur.BasicAuth,
+	}
+	update(&ep)
+	config := *cur
+	config.URL, config.TenantID, config.Labels = ep.URL, ep.TenantID, ep.Labels
+	config.BearerToken, config.BasicAuth = ep.BearerToken, ep.BasicAuth
+	return s.updateConfig(&config)
+}
+
+// lokiValidationMessage is the message of the entry pushed by Validate
+const lokiValidationMessage = "zlog: sink validation"
+
+// Validate checks that Loki is ready, then pushes a test entry under the
+// sink's tenant and labels, so a wrong URL, tenant or credentials fails at
+// startup. Readiness is skipped for endpoints without /ready, such as
+// gateways exposing only the push API.
+func (s *LokiSink) Validate(ctx context.Context) error {
+	st := s.state.Load()
+	ready, err := lokiReadyURL(st.config.URL)
+	if err != nil {
+		return fmt.Errorf("loki: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ready, nil)
+	if err != nil {
+		return fmt.Errorf("loki: %w", err)
+	}
+	authorize(req, st.config.BearerToken, st.config.BasicAuth)
+	resp, err := st.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("loki: %w", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("loki: GET %s: %s - %s", ready, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	entry := &LogEntry{
+		Timestamp:   time.Now(),
+		Level:       "info",
+		Severity:    SeverityInfo,
+		Message:     lokiValidationMessage,
+		ServiceName: st.config.ServiceName,
+		InstanceID:  st.config.InstanceID,
+		Environment: st.config.Environment,
+	}
+	if err := s.push(ctx, st, st.config.TenantID, []*LogEntry{entry}); err != nil {
+		return fmt.Errorf("loki: test push: %w", err)
+	}
+	return nil
+}
+
+// lokiReadyURL returns the readiness URL of the Loki serving a push URL
+func lokiReadyURL(push string) (string, error) {
+	u, err := url.Parse(push)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/loki/api/v1/push") + "/ready"
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
+// StreamKey returns the tenant and label set of the stream entry is pushed
+// to, which Loki requires in timestamp order
+func (s *LokiSink) StreamKey(entry *LogEntry) string {
+	st := s.state.Load()
+	key := s.labelsToKey(st.buildLabels(entry))
+	if st.config.MultiTenant {
+		key = st.tenant(entry) + "|" + key
+	}
+	return key
+}
+
+// tenant returns the org ID entry is pushed under with MultiTenant
+func (st *lokiState) tenant(entry *LogEntry) string {
+	if v, ok := entry.Fields[FieldTenant]; ok {
+		return fmt.Sprint(v)
+	}
+	return st.config.TenantID
+}
+
 // buildLabels creates the label set for a log entry
-func (s *LokiSink) buildLabels(entry *LogEntry) map[string]string {
+func (st *lokiState) buildLabels(entry *LogEntry) map[string]string {
 	labels := make(map[string]string)
 
 	// Copy static labels
-	for k, v := range s.config.Labels {
+	for k, v := range st.config.Labels {
 		labels[k] = v
 	}
 
-	// Add dynamic labels
+	// Add the entry's labels, then the dynamic labels; the entry's resource
+	// overrides the configured one
+	for k, v := range entry.Labels {
+		labels[lokiLabelName(k)] = v
+	}
 	labels["level"] = entry.Level
 	if entry.Hostname != "" {
 		labels["hostname"] = entry.Hostname
 	}
+	if entry.ServiceName != "" {
+		labels["service"] = entry.ServiceName
+	}
+	if entry.Environment != "" {
+		labels["environment"] = entry.Environment
+	}
+	if entry.InstanceID != "" {
+		labels["instance"] = entry.InstanceID
+	}
 
 	return labels
 }
 
+// lokiLabelName replaces the characters not allowed in Loki label names
+// with underscores
+func lokiLabelName(name string) string {
+	valid := func(i int, r rune) bool {
+		return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9'
+	}
+	for i, r := range name {
+		if !valid(i, r) {
+			b := []rune(name)
+			for j, r := range b {
+				if !valid(j, r) {
+					b[j] = '_'
+				}
+			}
+			return string(b)
+		}
+	}
+	return name
+}
+
+// traceMetadata returns the entry's severity and trace correlation fields as
+// Loki structured metadata, or nil if it has none
+func traceMetadata(entry *LogEntry) map[string]string {
+	var metadata map[string]string
+	set := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if metadata == nil {
+			metadata = make(map[string]string, 4)
+		}
+		metadata[key] = value
+	}
+	if severity := entry.SeverityNumber(); severity != 0 {
+		set(FieldSeverity, strconv.Itoa(severity))
+	}
+	traceID, spanID, flags := entry.TraceContext()
+	set(FieldTraceID, traceID)
+	set(FieldSpanID, spanID)
+	if traceID != "" {
+		set(FieldTraceFlags, fmt.Sprintf("%02x", flags))
+	}
+	if v, ok := entry.Fields[FieldEntryID]; ok {
+		set(FieldEntryID, fmt.Sprint(v))
+	}
+	return metadata
+}
+
 // labelsToKey creates a unique key for a label set
 func (s *LokiSink) labelsToKey(labels map[string]string) string {
 	// Simple JSON serialization for grouping
-	data, _ := json.Marshal(labels)
+	data, err := json.Marshal(labels)
+	if err != nil {
+		handleError(fmt.Errorf("loki: failed to marshal labels: %w", err))
+	}
 	return string(data)
 }
 
 // formatLogLine formats a log entry as a single line for Loki
-func (s *LokiSink) formatLogLine(entry *LogEntry) string {
+func (st *lokiState) formatLogLine(entry *LogEntry) string {
+	// Use the pre-encoded line if the producer already serialized it
+	if len(entry.Encoded) > 0 {
+		return string(entry.Encoded)
+	}
+	if st.config.Encoder != nil {
+		data, err := st.config.Encoder.Encode(entry)
+		if err != nil {
+			// Replace unencodable field values rather than dropping the line
+			data, err = st.config.Encoder.Encode(sanitizeEntries([]*LogEntry{entry})[0])
+		}
+		if err != nil {
+			handleError(fmt.Errorf("loki: failed to encode log line: %w", err))
+		}
+		return string(data)
+	}
+
 	// Create a structured log line
 	logData := map[string]any{
 		"msg": entry.Message,
 	}
+	if severity := entry.SeverityNumber(); severity != 0 {
+		logData[FieldSeverity] = severity
+	}
 
 	// Add fields
 	if len(entry.Fields) > 0 {
@@ -213,22 +509,58 @@ func (s *LokiSink) formatLogLine(entry *LogEntry) string {
 			logData[k] = v
 		}
 	}
+	if entry.TraceID != "" {
+		logData[FieldTraceID] = entry.TraceID
+	}
+	if entry.SpanID != "" {
+		logData[FieldSpanID] = entry.SpanID
+	}
+
+	// Add resource metadata not carried by the stream labels
+	if entry.Version != "" {
+		logData[FieldVersion] = entry.Version
+	}
+	for k, v := range entry.Resource {
+		logData[k] = v
+	}
 
 	// Add caller if present
 	if entry.Caller != "" {
 		logData["caller"] = entry.Caller
 	}
+	if entry.Function != "" {
+		logData["function"] = entry.Function
+	}
 
 	// Add stack trace if present
 	if entry.StackTrace != "" {
 		logData["stack_trace"] = entry.StackTrace
 	}
 
+	if st.config.LineFormat == LineFormatLogfmt {
+		return logfmtLine(logData)
+	}
+
 	// Serialize to JSON
-	data, _ := json.Marshal(logData)
+	data, err := json.Marshal(logData)
+	if err != nil {
+		// Replace unencodable field values rather than dropping the line
+		data, err = json.Marshal(sanitizeFields(logData))
+	}
+	if err != nil {
+		handleError(fmt.Errorf("loki: failed to marshal log line: %w", err))
+	}
 	return string(data)
 }
 
+// AcceptsEncoded reports whether Loki log lines are taken from the JSON in
+// LogEntry.Encoded: only with PreEncoded set, and unless the sink writes
+// logfmt lines or uses an Encoder
+func (s *LokiSink) AcceptsEncoded() bool {
+	cfg := s.state.Load().config
+	return cfg.PreEncoded && cfg.LineFormat != LineFormatLogfmt && cfg.Encoder == nil
+}
+
 // Flush is a no-op for Loki sink (handled by BufferedSink)
 func (s *LokiSink) Flush(ctx context.Context) error {
 	return nil
@@ -236,7 +568,7 @@ func (s *LokiSink) Flush(ctx context.Context) error {
 
 // Close closes the HTTP client
 func (s *LokiSink) Close() error {
-	s.client.CloseIdleConnections()
+	s.state.Load().client.CloseIdleConnections()
 	return nil
 }
 
@@ -247,8 +579,8 @@ func (s *LokiSink) IsHealthy() bool {
 
 // LastError returns the last error encountered
 func (s *LokiSink) LastError() error {
-	if val := s.lastError.Load(); val != nil {
-		return val.(error)
+	if err := s.lastError.Load(); err != nil {
+		return *err
 	}
 	return nil
 }
@@ -256,5 +588,5 @@ func (s *LokiSink) LastError() error {
 // recordError records an error and marks the sink as unhealthy
 func (s *LokiSink) recordError(err error) {
 	s.isHealthy.Store(false)
-	s.lastError.Store(err)
+	s.lastError.Store(&err)
 }
@@ -0,0 +1,116 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// StreamKeyer is implemented by sinks whose backend orders entries per
+// stream, so BufferedSink can keep each stream in order (see
+// Config.OrderedDelivery) and flush different streams in parallel (see
+// Config.WorkerPoolSize)
+type StreamKeyer interface {
+	// StreamKey returns the stream of entry, e.g. its Loki label set
+	StreamKey(entry *LogEntry) string
+}
+
+// DefaultStreamKey returns the service and instance of entry
+func DefaultStreamKey(entry *LogEntry) string {
+	return entry.ServiceName + "/" + entry.InstanceID
+}
+
+// streamKey returns the stream of entry in the wrapped sink
+func (bs *BufferedSink) streamKey(entry *LogEntry) string {
+	if bs.config.StreamKey != nil {
+		return bs.config.StreamKey(entry)
+	}
+	if k, ok := bs.sink.(StreamKeyer); ok {
+		return k.StreamKey(entry)
+	}
+	return DefaultStreamKey(entry)
+}
+
+// groupByStream splits entries by stream in order of first appearance,
+// keeping their order within each stream unless sorted, which sorts each
+// stream by timestamp
+func (bs *BufferedSink) groupByStream(entries []*LogEntry, sorted bool) [][]*LogEntry {
+	var streams [][]*LogEntry
+	index := make(map[string]int)
+	for _, entry := range entries {
+		key := bs.streamKey(entry)
+		i, ok := index[key]
+		if !ok {
+			i = len(streams)
+			index[key] = i
+			streams = append(streams, nil)
+		}
+		streams[i] = append(streams[i], entry)
+	}
+	if sorted {
+		for _, stream := range streams {
+			slices.SortStableFunc(stream, func(a, b *LogEntry) int {
+				return a.Timestamp.Compare(b.Timestamp)
+			})
+		}
+	}
+	return streams
+}
+
+// flushStreams sends each stream one batch at a time. A batch failing after
+// its retries holds back the rest of its stream, which is buffered again
+// ahead of newer entries, while the other streams are still sent. A batch
+// failing permanently is dead-lettered without holding back its stream.
+//
+// With Config.WorkerPoolSize above 1, up to that many streams are sent in
+// parallel. Each stream is sent by a single worker, so its batches are never
+// in flight at the same time or out of order. Results are recorded by the
+// calling goroutine (must be called with lock held).
+func (bs *BufferedSink) flushStreams(ctx context.Context, streams [][]*LogEntry) error {
+	var errs []error
+	workers := min(bs.config.WorkerPoolSize, len(streams))
+	if workers <= 1 {
+		for _, stream := range streams {
+			if err := bs.sendEntries(ctx, stream); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	work := make(chan []*LogEntry)
+	results := make(chan batchResult)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for stream := range work {
+				for i := 0; i < len(stream); {
+					r := bs.sendBatch(ctx, stream, i)
+					results <- r
+					if r.err != nil && !IsPermanent(r.err) {
+						break
+					}
+					i = r.end
+				}
+			}
+		}()
+	}
+	go func() {
+		for _, stream := range streams {
+			work <- stream
+		}
+		close(work)
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		if err := bs.record(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+package sink
+
+import "sync"
+
+// entryPool recycles LogEntry values and their Fields maps
+var entryPool = sync.Pool{
+	New: func() any {
+		return &LogEntry{Fields: make(map[string]any, 8)}
+	},
+}
+
+// AcquireEntry returns an empty LogEntry from the shared pool.
+//
+// Ownership rules: passing a pooled entry to Sink.Write transfers ownership
+// to the sink, which returns it to the pool with ReleaseEntry once the entry
+// has been delivered or dropped. The caller must not touch the entry after
+// Write. WriteBatch borrows entries and never releases them, except on
+// BufferedSink which takes ownership of every entry it accepts.
+func AcquireEntry() *LogEntry {
+	entry := entryPool.Get().(*LogEntry)
+	entry.pooled = true
+	return entry
+}
+
+// ReleaseEntry resets a pooled entry and returns it to the pool. Entries not
+// obtained from AcquireEntry are left untouched, so it is always safe to call.
+func ReleaseEntry(entry *LogEntry) {
+	if entry == nil || !entry.pooled {
+		return
+	}
+
+	fields, encoded := entry.Fields, entry.Encoded[:0]
+	clear(fields)
+	*entry = LogEntry{Fields: fields, Encoded: encoded}
+	entryPool.Put(entry)
+}
+
+// releaseEntries returns every pooled entry in entries to the pool
+func releaseEntries(entries []*LogEntry) {
+	for _, entry := range entries {
+		ReleaseEntry(entry)
+	}
+}
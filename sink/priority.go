@@ -0,0 +1,74 @@
+package sink
+
+// Level priorities used by BufferedSink to decide which entries to evict
+// when the buffer is saturated and which to send first on flush
+const (
+	priorityLow  = iota // trace, debug, info and unknown levels
+	priorityWarn        // warn
+	priorityHigh        // error, panic, fatal, audit
+)
+
+// levelPriority maps a LogEntry level string to its buffering priority
+func levelPriority(level string) int {
+	switch level {
+	case "warn":
+		return priorityWarn
+	case "error", "panic", "fatal", "audit":
+		return priorityHigh
+	default:
+		return priorityLow
+	}
+}
+
+// evictionIndex returns the index of the oldest entry with the lowest priority
+// strictly below the given priority, or -1 if no such entry exists
+func evictionIndex(entries []*LogEntry, priority int) int {
+	idx, lowest := -1, priority
+	for i, entry := range entries {
+		if p := levelPriority(entry.Level); p < lowest {
+			idx, lowest = i, p
+			if p == priorityLow {
+				break
+			}
+		}
+	}
+	return idx
+}
+
+// prioritize reorders entries so that higher priority entries come first,
+// preserving the relative order of entries within the same priority
+func prioritize(entries []*LogEntry) []*LogEntry {
+	ordered := make([]*LogEntry, 0, len(entries))
+	for p := priorityHigh; p >= priorityLow; p-- {
+		for _, entry := range entries {
+			if levelPriority(entry.Level) == p {
+				ordered = append(ordered, entry)
+			}
+		}
+	}
+	return ordered
+}
+
+// levelRanks orders LogEntry level strings from least to most severe
+var levelRanks = map[string]int{
+	"trace":  0,
+	"debug":  1,
+	"info":   2,
+	"warn":   3,
+	"error":  4,
+	"dpanic": 5,
+	"panic":  5,
+	"fatal":  6,
+	"audit":  7,
+}
+
+// LevelEnabled reports whether a LogEntry level is at or above min. An empty
+// or unknown min enables every level, and unknown levels are always enabled.
+func LevelEnabled(level, min string) bool {
+	m, ok := levelRanks[min]
+	if !ok {
+		return true
+	}
+	l, ok := levelRanks[level]
+	return !ok || l >= m
+}
@@ -0,0 +1,141 @@
+package sink
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ProtobufEncoder encodes entries as Protocol Buffers messages of the
+// following schema, and batches as LogBatch:
+//
+//	message LogEntry {
+//	  int64 timestamp_unix_nano = 1;
+//	  string level = 2;
+//	  string message = 3;
+//	  google.protobuf.Struct fields = 4;
+//	  string service_name = 5;
+//	  string instance_id = 6;
+//	  string environment = 7;
+//	  string version = 8;
+//	  map<string, string> resource = 9;
+//	  string hostname = 10;
+//	  string caller = 11;
+//	  string function = 12;
+//	  string stack_trace = 13;
+//	  map<string, string> labels = 14;
+//	  string trace_id = 15;
+//	  string span_id = 16;
+//	  uint32 trace_flags = 17;
+//	  int32 severity_number = 18;
+//	}
+//
+//	message LogBatch {
+//	  repeated LogEntry entries = 1;
+//	}
+type ProtobufEncoder struct{}
+
+// Encode encodes entry as a LogEntry message
+func (ProtobufEncoder) Encode(entry *LogEntry) ([]byte, error) {
+	return appendProtoEntry(nil, entry)
+}
+
+// EncodeBatch encodes entries as a LogBatch message
+func (ProtobufEncoder) EncodeBatch(entries []*LogEntry) ([]byte, error) {
+	var buf []byte
+	for _, entry := range entries {
+		msg, err := appendProtoEntry(nil, entry)
+		if err != nil {
+			return nil, err
+		}
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, msg)
+	}
+	return buf, nil
+}
+
+// ContentType returns application/x-protobuf
+func (ProtobufEncoder) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// appendProtoEntry appends entry as a LogEntry message
+func appendProtoEntry(buf []byte, entry *LogEntry) ([]byte, error) {
+	appendString := func(num protowire.Number, s string) {
+		if s != "" {
+			buf = protowire.AppendTag(buf, num, protowire.BytesType)
+			buf = protowire.AppendString(buf, s)
+		}
+	}
+
+	if !entry.Timestamp.IsZero() {
+		buf = protowire.AppendTag(buf, 1, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(entry.Timestamp.UnixNano()))
+	}
+	appendString(2, entry.Level)
+	appendString(3, entry.Message)
+	if len(entry.Fields) > 0 {
+		fields, err := protoStruct(entry.Fields)
+		if err != nil {
+			return nil, err
+		}
+		buf = protowire.AppendTag(buf, 4, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, fields)
+	}
+	appendString(5, entry.ServiceName)
+	appendString(6, entry.InstanceID)
+	appendString(7, entry.Environment)
+	appendString(8, entry.Version)
+	appendMap := func(num protowire.Number, m map[string]string) {
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			var kv []byte
+			kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+			kv = protowire.AppendString(kv, k)
+			kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+			kv = protowire.AppendString(kv, m[k])
+			buf = protowire.AppendTag(buf, num, protowire.BytesType)
+			buf = protowire.AppendBytes(buf, kv)
+		}
+	}
+	appendMap(9, entry.Resource)
+	appendString(10, entry.Hostname)
+	appendString(11, entry.Caller)
+	appendString(12, entry.Function)
+	appendString(13, entry.StackTrace)
+	appendMap(14, entry.Labels)
+	appendString(15, entry.TraceID)
+	appendString(16, entry.SpanID)
+	if entry.TraceFlags != 0 {
+		buf = protowire.AppendTag(buf, 17, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(entry.TraceFlags))
+	}
+	if severity := entry.SeverityNumber(); severity != 0 {
+		buf = protowire.AppendTag(buf, 18, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(severity))
+	}
+	return buf, nil
+}
+
+// protoStruct encodes fields as a google.protobuf.Struct. Values structpb
+// does not support directly are converted through their JSON encoding.
+func protoStruct(fields map[string]any) ([]byte, error) {
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			data, err = json.Marshal(sanitizeFields(fields))
+		}
+		if err != nil {
+			return nil, err
+		}
+		s = &structpb.Struct{}
+		if err := s.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(s)
+}
@@ -0,0 +1,343 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuotaAction is what a QuotaSink does with entries over quota
+type QuotaAction string
+
+// Over-quota actions
+const (
+	QuotaDrop      QuotaAction = "drop"      // Drop the entries
+	QuotaSample    QuotaAction = "sample"    // Keep one entry in SampleEvery
+	QuotaSummarize QuotaAction = "summarize" // Replace the entries with per-message summary entries
+)
+
+// quotaBuckets is the number of buckets of a rolling window
+const quotaBuckets = 60
+
+// OtherTenants is the usage key of the tenants seen once MaxTenants are
+// tracked; they share one tenant quota
+const OtherTenants = "~other"
+
+// Quota limits the entries and bytes written over the rolling window
+type Quota struct {
+	MaxEntries  int64       // Entries per window (0 = unlimited)
+	MaxBytes    int64       // Approximate bytes per window (0 = unlimited)
+	Action      QuotaAction // What to do once over quota (default and unknown: QuotaDrop)
+	SampleEvery int         // Over-quota entries per entry kept by QuotaSample (default: 10)
+}
+
+// QuotaConfig configures a QuotaSink
+type QuotaConfig struct {
+	Window        time.Duration    // Rolling window of the quotas (default: 1h)
+	Sink          Quota            // Quota of all entries written to the sink
+	Tenant        Quota            // Quota of each tenant without an entry in Tenants
+	Tenants       map[string]Quota // Quotas of specific tenants
+	TenantField   string           // Field holding the tenant (default: FieldTenant)
+	MaxTenants    int              // Tenants tracked at once; idle tenants are forgotten first (default: 10000)
+	SummaryWindow time.Duration    // Period of the QuotaSummarize summaries (default: 60s)
+	Clock         Clock            // Time source for windows (nil = SystemClock)
+}
+
+// Usage reports the usage of the sink or of a tenant
+type Usage struct {
+	Entries      int64  `json:"entries"`       // Entries written in the current window
+	Bytes        int64  `json:"bytes"`         // Approximate bytes written in the current window
+	TotalEntries uint64 `json:"total_entries"` // Entries written since the sink was created
+	TotalBytes   uint64 `json:"total_bytes"`   // Approximate bytes written since the sink was created
+	Dropped      uint64 `json:"dropped"`       // Over-quota entries dropped, or not kept by sampling
+	Summarized   uint64 `json:"summarized"`    // Over-quota entries replaced by summaries
+	OverQuota    bool   `json:"over_quota"`    // Whether the quota is currently exhausted
+}
+
+// UsageReport holds the usage of a QuotaSink, e.g. for chargeback
+type UsageReport struct {
+	Time    time.Time        `json:"time"`
+	Window  time.Duration    `json:"window_ns"`
+	Sink    Usage            `json:"sink"`
+	Tenants map[string]Usage `json:"tenants,omitempty"`
+}
+
+// QuotaSink enforces entry and byte quotas over a rolling window on the
+// entries written to a sink, overall and per tenant (the FieldTenant field
+// set by logger.TenantManager), and reports usage for chargeback. Entries over
+// quota are dropped, sampled or summarized. Wrap a BufferedSink with it so
+// rejected entries never take buffer space. Tenants idle for a whole window
+// are forgotten, with their totals, when MaxTenants is reached; tenants seen
+// while all tracked ones are active share the OtherTenants usage.
+type QuotaSink struct {
+	Sink
+	config    QuotaConfig
+	clock     Clock
+	bucket    time.Duration
+	summaries *AggregatingSink // Aggregates entries of QuotaSummarize quotas (nil = unused)
+
+	mu        sync.Mutex
+	sink      *quotaUsage
+	tenants   map[string]*quotaUsage
+	lastSweep time.Time // Last search for idle tenants
+}
+
+// quotaUsage tracks the usage of the sink or of a tenant
+type quotaUsage struct {
+	quota   Quota
+	buckets [quotaBuckets]quotaBucket
+	usage   Usage     // Totals and over-quota counters
+	skipped int       // Over-quota entries since the last one kept by sampling
+	seen    time.Time // Time of the last entry
+}
+
+// quotaBucket counts the entries written in one slice of the window
+type quotaBucket struct {
+	start   time.Time
+	entries int64
+	bytes   int64
+}
+
+// NewQuotaSink wraps s, enforcing the quotas of cfg
+func NewQuotaSink(s Sink, cfg QuotaConfig) *QuotaSink {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Hour
+	}
+	if cfg.TenantField == "" {
+		cfg.TenantField = FieldTenant
+	}
+	if cfg.SummaryWindow <= 0 {
+		cfg.SummaryWindow = 60 * time.Second
+	}
+	if cfg.MaxTenants <= 0 {
+		cfg.MaxTenants = 10000
+	}
+
+	q := &QuotaSink{
+		Sink:    s,
+		config:  cfg,
+		clock:   clockOrSystem(cfg.Clock),
+		bucket:  max(cfg.Window/quotaBuckets, time.Nanosecond),
+		sink:    &quotaUsage{quota: cfg.Sink},
+		tenants: make(map[string]*quotaUsage),
+	}
+	summarize := cfg.Sink.Action == QuotaSummarize || cfg.Tenant.Action == QuotaSummarize
+	for _, quota := range cfg.Tenants {
+		summarize = summarize || quota.Action == QuotaSummarize
+	}
+	if summarize {
+		levels := make(map[string]AggregateRule)
+		for _, level := range []string{"trace", "debug", "info", "warn", "error", "dpanic", "panic", "fatal", "audit"} {
+			levels[level] = AggregateRule{Window: cfg.SummaryWindow}
+		}
+		q.summaries = NewAggregatingSink(s, AggregateConfig{Levels: levels, Clock: cfg.Clock})
+	}
+	return q
+}
+
+// Write writes entry if it is within quota
+func (q *QuotaSink) Write(ctx context.Context, entry *LogEntry) error {
+	if !q.admit(entry) {
+		return nil
+	}
+	return q.Sink.Write(ctx, entry)
+}
+
+// WriteBatch writes the entries that are within quota
+func (q *QuotaSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
+	kept := make([]*LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if q.admit(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return q.Sink.WriteBatch(ctx, kept)
+}
+
+// admit accounts for entry, reporting whether it is written. Entries over
+// quota are released unless sampled.
+func (q *QuotaSink) admit(entry *LogEntry) bool {
+	size := int64(approxEntrySize(entry))
+	now := q.clock.Now()
+
+	q.mu.Lock()
+	usages := []*quotaUsage{q.sink}
+	if t := q.tenant(entry, now); t != nil {
+		usages = append(usages, t)
+	}
+
+	// The quota of the tenant, if exhausted, decides over the sink's
+	var over *quotaUsage
+	for _, u := range usages {
+		if u.exceeded(now, q.config.Window, size) {
+			over = u
+		}
+	}
+	if over != nil {
+		action := over.quota.Action
+		if action == QuotaSample {
+			every := over.quota.SampleEvery
+			if every <= 0 {
+				every = 10
+			}
+			over.skipped++
+			if over.skipped < every {
+				over.usage.Dropped++
+				q.mu.Unlock()
+				ReleaseEntry(entry)
+				return false
+			}
+			over.skipped = 0
+		} else {
+			if action == QuotaSummarize && q.summaries != nil && q.summaries.aggregate(entry) {
+				over.usage.Summarized++
+			} else {
+				over.usage.Dropped++
+			}
+			q.mu.Unlock()
+			ReleaseEntry(entry)
+			return false
+		}
+	}
+	for _, u := range usages {
+		u.seen = now
+		u.add(now, q.bucket, size)
+	}
+	q.mu.Unlock()
+	return true
+}
+
+// tenant returns the usage of the entry's tenant, if it has one (must be
+// called with mu held)
+func (q *QuotaSink) tenant(entry *LogEntry, now time.Time) *quotaUsage {
+	v, ok := entry.Fields[q.config.TenantField]
+	if !ok {
+		return nil
+	}
+	id := fmt.Sprint(v)
+	u := q.tenants[id]
+	if u != nil {
+		return u
+	}
+	if len(q.tenants) >= q.config.MaxTenants && !q.evictIdle(now) {
+		id = OtherTenants
+		if u = q.tenants[id]; u != nil {
+			return u
+		}
+	}
+	quota, ok := q.config.Tenants[id]
+	if !ok {
+		quota = q.config.Tenant
+	}
+	u = &quotaUsage{quota: quota}
+	q.tenants[id] = u
+	return u
+}
+
+// evictIdle forgets the tenants without entries in the window ending at now,
+// reporting whether any was. It searches at most once per bucket, so new
+// tenants arriving while all tracked ones are active cost O(1).
+func (q *QuotaSink) evictIdle(now time.Time) bool {
+	if now.Sub(q.lastSweep) < q.bucket {
+		return false
+	}
+	q.lastSweep = now
+	since := now.Add(-q.config.Window)
+	evicted := false
+	for id, u := range q.tenants {
+		if id != OtherTenants && !u.seen.After(since) {
+			delete(q.tenants, id)
+			evicted = true
+		}
+	}
+	return evicted
+}
+
+// exceeded reports whether writing size more bytes would exceed the quota
+func (u *quotaUsage) exceeded(now time.Time, window time.Duration, size int64) bool {
+	if u.quota.MaxEntries <= 0 && u.quota.MaxBytes <= 0 {
+		return false
+	}
+	entries, bytes := u.sum(now, window)
+	return u.quota.MaxEntries > 0 && entries >= u.quota.MaxEntries ||
+		u.quota.MaxBytes > 0 && bytes+size > u.quota.MaxBytes
+}
+
+// add counts an entry of size bytes in the bucket of now
+func (u *quotaUsage) add(now time.Time, bucket time.Duration, size int64) {
+	start := now.Truncate(bucket)
+	b := &u.buckets[(start.UnixNano()/int64(bucket))%quotaBuckets]
+	if !b.start.Equal(start) {
+		*b = quotaBucket{start: start}
+	}
+	b.entries++
+	b.bytes += size
+	u.usage.TotalEntries++
+	u.usage.TotalBytes += uint64(size)
+}
+
+// sum returns the entries and bytes counted in the window ending at now
+func (u *quotaUsage) sum(now time.Time, window time.Duration) (entries, bytes int64) {
+	since := now.Add(-window)
+	for _, b := range u.buckets {
+		if b.start.After(since) {
+			entries += b.entries
+			bytes += b.bytes
+		}
+	}
+	return entries, bytes
+}
+
+// report returns the usage in the window ending at now
+func (u *quotaUsage) report(now time.Time, window time.Duration) Usage {
+	usage := u.usage
+	usage.Entries, usage.Bytes = u.sum(now, window)
+	usage.OverQuota = u.quota.MaxEntries > 0 && usage.Entries >= u.quota.MaxEntries ||
+		u.quota.MaxBytes > 0 && usage.Bytes >= u.quota.MaxBytes
+	return usage
+}
+
+// Usage returns the usage of the sink and of every tenant seen
+func (q *QuotaSink) Usage() UsageReport {
+	now := q.clock.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	report := UsageReport{
+		Time:    now,
+		Window:  q.config.Window,
+		Sink:    q.sink.report(now, q.config.Window),
+		Tenants: make(map[string]Usage, len(q.tenants)),
+	}
+	for id, u := range q.tenants {
+		report.Tenants[id] = u.report(now, q.config.Window)
+	}
+	return report
+}
+
+// MinLevel returns the minimum level of the wrapped sink, if it filters levels
+func (q *QuotaSink) MinLevel() string {
+	if lf, ok := q.Sink.(LevelFilter); ok {
+		return lf.MinLevel()
+	}
+	return ""
+}
+
+// Flush writes the pending summaries, then flushes the wrapped sink
+func (q *QuotaSink) Flush(ctx context.Context) error {
+	if q.summaries != nil {
+		return q.summaries.Flush(ctx)
+	}
+	return q.Sink.Flush(ctx)
+}
+
+// Close writes the pending summaries and closes the wrapped sink
+func (q *QuotaSink) Close() error {
+	if q.summaries != nil {
+		return q.summaries.Close()
+	}
+	return q.Sink.Close()
+}
@@ -0,0 +1,36 @@
+package sink
+
+import "errors"
+
+// ErrNotReconfigurable is returned by Reconfigure for sinks whose endpoint
+// cannot be changed while running
+var ErrNotReconfigurable = errors.New("sink is not reconfigurable")
+
+// Endpoint holds the backend settings of a sink that can be changed while it
+// runs, e.g. after a secret rotation or a service discovery change
+type Endpoint struct {
+	URL         string
+	TenantID    string            // Loki tenant ("" = none)
+	Labels      map[string]string // Loki static labels
+	Headers     map[string]string // Additional HTTP headers of the HTTP sink
+	BearerToken string
+	BasicAuth   *BasicAuth
+}
+
+// Reconfigurable is implemented by sinks whose endpoint can be changed while
+// running
+type Reconfigurable interface {
+	// Reconfigure calls update with a copy of the current endpoint and
+	// switches to the result. Batches being sent complete with the previous
+	// endpoint; later batches use the new one.
+	Reconfigure(update func(ep *Endpoint)) error
+}
+
+// Reconfigure changes the endpoint of s (see Reconfigurable), or returns
+// ErrNotReconfigurable
+func Reconfigure(s Sink, update func(ep *Endpoint)) error {
+	if r, ok := s.(Reconfigurable); ok {
+		return r.Reconfigure(update)
+	}
+	return ErrNotReconfigurable
+}
@@ -0,0 +1,80 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// registry tracks live buffered sinks so they can be flushed or drained together
+var registry = struct {
+	sync.Mutex
+	sinks map[*BufferedSink]struct{}
+}{sinks: make(map[*BufferedSink]struct{})}
+
+// register adds a buffered sink to the registry
+func register(bs *BufferedSink) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.sinks[bs] = struct{}{}
+}
+
+// unregister removes a buffered sink from the registry
+func unregister(bs *BufferedSink) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.sinks, bs)
+}
+
+// registered returns a snapshot of the registered buffered sinks
+func registered() []*BufferedSink {
+	registry.Lock()
+	defer registry.Unlock()
+	sinks := make([]*BufferedSink, 0, len(registry.sinks))
+	for bs := range registry.sinks {
+		sinks = append(sinks, bs)
+	}
+	return sinks
+}
+
+// FlushAll flushes every live buffered sink, returning the joined errors
+func FlushAll(ctx context.Context) error {
+	var errs []error
+	for _, bs := range registered() {
+		if err := bs.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DrainAll drains every live buffered sink concurrently until ctx expires,
+// returning the combined report and the joined errors
+func DrainAll(ctx context.Context) (DrainReport, error) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total DrainReport
+		errs  []error
+	)
+	for _, bs := range registered() {
+		wg.Add(1)
+		go func(bs *BufferedSink) {
+			defer wg.Done()
+			report, err := bs.Drain(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			total.Delivered += report.Delivered
+			total.Abandoned += report.Abandoned
+			if report.Duration > total.Duration {
+				total.Duration = report.Duration
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(bs)
+	}
+	wg.Wait()
+	return total, errors.Join(errs...)
+}
@@ -0,0 +1,250 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReplayConfig controls Replay
+type ReplayConfig struct {
+	BatchSize int       // Entries per WriteBatch call (default: 100)
+	Rate      int       // Maximum entries per second (0 = unlimited)
+	Since     time.Time // Skip entries before Since (zero = no lower bound)
+	Until     time.Time // Skip entries at or after Until (zero = no upper bound)
+	Resource  *Resource // Stamped onto entries without a service name
+	Clock     Clock     // Time source for rate limiting (nil = SystemClock)
+}
+
+// ReplayStats counts the lines and entries processed by Replay
+type ReplayStats struct {
+	Lines    int // Lines read
+	Replayed int // Entries written to the sink
+	Skipped  int // Entries outside the Since/Until window
+	Invalid  int // Lines that are not log entries
+}
+
+// Replay reads archived NDJSON log lines from r and writes them to s in
+// batches, keeping their original timestamps, e.g. to backfill a backend
+// after an outage. Lines may be written by the file output in the default,
+// ECS or GCP layout, or be serialized LogEntry values or {"logs": [...]}
+// batches. Lines that cannot be decoded are counted and skipped.
+func Replay(ctx context.Context, r io.Reader, s Sink, cfg ReplayConfig) (ReplayStats, error) {
+	var stats ReplayStats
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	clock := clockOrSystem(cfg.Clock)
+	start := clock.Now()
+
+	batch := make([]*LogEntry, 0, cfg.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if cfg.Rate > 0 {
+			// Wait until the entries written so far are within the rate
+			due := start.Add(time.Duration(float64(stats.Replayed+len(batch)) / float64(cfg.Rate) * float64(time.Second)))
+			if wait := due.Sub(clock.Now()); wait > 0 {
+				select {
+				case <-clock.After(wait):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		if err := s.WriteBatch(ctx, batch); err != nil {
+			return err
+		}
+		stats.Replayed += len(batch)
+		batch = make([]*LogEntry, 0, cfg.BatchSize)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		stats.Lines++
+		entries, err := DecodeEntries(scanner.Bytes())
+		if err != nil {
+			stats.Invalid++
+			continue
+		}
+		for _, entry := range entries {
+			if !cfg.Since.IsZero() && entry.Timestamp.Before(cfg.Since) ||
+				!cfg.Until.IsZero() && !entry.Timestamp.Before(cfg.Until) {
+				stats.Skipped++
+				continue
+			}
+			if entry.ServiceName == "" && cfg.Resource != nil {
+				cfg.Resource.Apply(entry)
+			}
+			batch = append(batch, entry)
+			if len(batch) >= cfg.BatchSize {
+				if err := flush(); err != nil {
+					return stats, fmt.Errorf("replay: %w", err)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("replay: %w", err)
+	}
+	if err := flush(); err != nil {
+		return stats, fmt.Errorf("replay: %w", err)
+	}
+	return stats, nil
+}
+
+// Keys of the entry properties in the default, ECS and GCP layouts of the
+// file output, in the order they are looked up
+var (
+	replayTimeKeys    = []string{"ts", ECSTimestamp, "time", "timestamp"}
+	replayLevelKeys   = []string{"level", ECSLevel, "severity"}
+	replayMessageKeys = []string{"msg", ECSMessage}
+	replayCallerKeys  = []string{"caller", "log.origin", "logging.googleapis.com/sourceLocation"}
+	replayFuncKeys    = []string{"function", ECSOriginFunction}
+	replayStackKeys   = []string{"stacktrace", ECSErrorStackTrace, "stack_trace"}
+)
+
+// gcpReplayLevels maps Cloud Logging severities to level names
+var gcpReplayLevels = map[string]string{
+	"DEFAULT":   "info",
+	"DEBUG":     "debug",
+	"INFO":      "info",
+	"NOTICE":    "audit",
+	"WARNING":   "warn",
+	"ERROR":     "error",
+	"CRITICAL":  "dpanic",
+	"ALERT":     "panic",
+	"EMERGENCY": "fatal",
+}
+
+// DecodeEntries decodes an archived JSON log line into entries: a line of
+// the file output, a serialized LogEntry or a {"logs": [...]} batch
+func DecodeEntries(line []byte) ([]*LogEntry, error) {
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	if logs, ok := doc["logs"].([]any); ok {
+		entries := make([]*LogEntry, 0, len(logs))
+		for _, l := range logs {
+			obj, ok := l.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("batch entry is %T, not an object", l)
+			}
+			entry, err := decodeEntry(obj)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		return entries, nil
+	}
+	entry, err := decodeEntry(doc)
+	if err != nil {
+		return nil, err
+	}
+	return []*LogEntry{entry}, nil
+}
+
+// decodeEntry decodes a serialized LogEntry, or a file output line whose
+// unknown keys become fields
+func decodeEntry(doc map[string]any) (*LogEntry, error) {
+	if _, ok := doc["service_name"]; ok {
+		// Serialized LogEntry, as written by JSONEncoder
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		entry := &LogEntry{}
+		if err := json.Unmarshal(data, entry); err != nil {
+			return nil, err
+		}
+		if entry.Timestamp.IsZero() {
+			return nil, fmt.Errorf("entry has no timestamp")
+		}
+		return entry, nil
+	}
+
+	entry := &LogEntry{Fields: doc}
+	ts, ok := takeValue(doc, replayTimeKeys)
+	if !ok {
+		return nil, fmt.Errorf("entry has no timestamp")
+	}
+	var err error
+	if entry.Timestamp, err = parseTimestamp(ts); err != nil {
+		return nil, err
+	}
+	if level, ok := takeValue(doc, replayLevelKeys); ok {
+		entry.Level = fmt.Sprint(level)
+		if l, ok := gcpReplayLevels[entry.Level]; ok {
+			entry.Level = l
+		}
+	}
+	if msg, ok := takeValue(doc, replayMessageKeys); ok {
+		entry.Message = fmt.Sprint(msg)
+	}
+	if caller, ok := takeValue(doc, replayCallerKeys); ok {
+		entry.Caller = formatCaller(caller)
+	}
+	if fn, ok := takeValue(doc, replayFuncKeys); ok {
+		entry.Function = fmt.Sprint(fn)
+	}
+	if stack, ok := takeValue(doc, replayStackKeys); ok {
+		entry.StackTrace = fmt.Sprint(stack)
+	}
+	return entry, nil
+}
+
+// takeValue removes and returns the value of the first of keys in doc
+func takeValue(doc map[string]any, keys []string) (any, bool) {
+	for _, key := range keys {
+		if v, ok := doc[key]; ok {
+			delete(doc, key)
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// parseTimestamp parses an RFC 3339 or ISO 8601 timestamp, or seconds since
+// the Unix epoch
+func parseTimestamp(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", v)
+	case json.Number:
+		secs, err := v.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, int64(secs*float64(time.Second))), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+}
+
+// formatCaller formats a caller string, or a GCP sourceLocation object as
+// file:line
+func formatCaller(v any) string {
+	if loc, ok := v.(map[string]any); ok {
+		return fmt.Sprintf("%v:%v", loc["file"], loc["line"])
+	}
+	return fmt.Sprint(v)
+}
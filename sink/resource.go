@@ -0,0 +1,34 @@
+package sink
+
+// Resource describes the service emitting log entries. Producers stamp it
+// onto every entry so all sinks export the same metadata.
+type Resource struct {
+	ServiceName string            // Name of the service (e.g., "checkout")
+	InstanceID  string            // Unique instance of the service (e.g., pod name)
+	Environment string            // Deployment environment (e.g., "production")
+	Version     string            // Service version or build
+	Attributes  map[string]string // Arbitrary resource attributes (e.g., "region")
+}
+
+// Well-known keys used for resource metadata in encoded log lines
+const (
+	FieldService     = "service"
+	FieldInstance    = "instance"
+	FieldEnvironment = "environment"
+	FieldVersion     = "version"
+)
+
+// Apply stamps the resource onto entry. Attributes are shared, not copied.
+func (r *Resource) Apply(entry *LogEntry) {
+	entry.ServiceName = r.ServiceName
+	entry.InstanceID = r.InstanceID
+	entry.Environment = r.Environment
+	entry.Version = r.Version
+	entry.Resource = r.Attributes
+}
+
+// IsZero reports whether the resource carries no metadata
+func (r *Resource) IsZero() bool {
+	return r.ServiceName == "" && r.InstanceID == "" && r.Environment == "" &&
+		r.Version == "" && len(r.Attributes) == 0
+}
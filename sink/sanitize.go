@@ -0,0 +1,115 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// sanitizeFields returns a copy of fields in which values that cannot be
+// encoded as JSON (channels, funcs, NaN and infinite floats, values whose
+// MarshalJSON fails) are replaced with a typed placeholder string, so one bad
+// value never drops the whole entry
+func sanitizeFields(fields map[string]any) map[string]any {
+	if fields == nil {
+		return nil
+	}
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		out[k] = sanitizeValue(v)
+	}
+	return out
+}
+
+// sanitizeEntries returns shallow copies of entries with sanitized fields
+func sanitizeEntries(entries []*LogEntry) []*LogEntry {
+	out := make([]*LogEntry, len(entries))
+	for i, entry := range entries {
+		clone := *entry
+		clone.Fields = sanitizeFields(entry.Fields)
+		clone.pooled = false
+		out[i] = &clone
+	}
+	return out
+}
+
+// sanitizeValue returns v, or a JSON-encodable replacement for it
+func sanitizeValue(v any) any {
+	switch val := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, json.Number:
+		return v
+	case float64:
+		return sanitizeFloat(val, 64)
+	case float32:
+		return sanitizeFloat(float64(val), 32)
+	case map[string]any:
+		return sanitizeFields(val)
+	case []any:
+		out := make([]any, len(val))
+		for i, elem := range val {
+			out[i] = sanitizeValue(elem)
+		}
+		return out
+	case json.Marshaler:
+		if _, err := val.MarshalJSON(); err != nil {
+			return unencodable(v, err)
+		}
+		return v
+	case error:
+		// Most error types encode as {}, losing the message
+		return val.Error()
+	}
+
+	if _, err := json.Marshal(v); err == nil {
+		return v
+	}
+	return sanitizeReflect(reflect.ValueOf(v))
+}
+
+// sanitizeReflect sanitizes the elements of unencodable maps, slices and
+// arrays, and replaces any other unencodable value with a placeholder
+func sanitizeReflect(rv reflect.Value) any {
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = sanitizeValue(iter.Value().Interface())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = sanitizeValue(rv.Index(i).Interface())
+		}
+		return out
+	case reflect.Pointer, reflect.Interface:
+		if !rv.IsNil() {
+			return sanitizeValue(rv.Elem().Interface())
+		}
+		return nil
+	}
+	return unencodable(rv.Interface(), nil)
+}
+
+// sanitizeFloat encodes NaN and infinities, which JSON cannot represent, as strings
+func sanitizeFloat(f float64, bitSize int) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	return f
+}
+
+// unencodable returns the placeholder for a value that cannot be encoded
+func unencodable(v any, err error) string {
+	if err == nil {
+		return fmt.Sprintf("<unencodable %T>", v)
+	}
+	return fmt.Sprintf("<unencodable %T: %v>", v, err)
+}
@@ -0,0 +1,71 @@
+package sink
+
+// OpenTelemetry SeverityNumber values of the level names
+const (
+	SeverityTrace = 1
+	SeverityDebug = 5
+	SeverityInfo  = 9
+	SeverityAudit = 12 // INFO4: audit records are notable but not failures
+	SeverityWarn  = 13
+	SeverityError = 17
+	SeverityPanic = 21
+	SeverityFatal = 24
+)
+
+// FieldSeverity holds the numeric severity in Loki structured metadata and
+// encoded lines
+const FieldSeverity = "severity"
+
+// LevelSeverity returns the OpenTelemetry SeverityNumber of a level name, so
+// entries can be filtered by numeric range (e.g. severity >= 17 for errors
+// and worse), or 0 for unknown levels
+func LevelSeverity(level string) int {
+	switch level {
+	case "trace":
+		return SeverityTrace
+	case "debug":
+		return SeverityDebug
+	case "info":
+		return SeverityInfo
+	case "audit":
+		return SeverityAudit
+	case "warn", "warning":
+		return SeverityWarn
+	case "error":
+		return SeverityError
+	case "panic", "dpanic":
+		return SeverityPanic
+	case "fatal":
+		return SeverityFatal
+	}
+	return 0
+}
+
+// SyslogSeverity returns the RFC 5424 severity of a level name, from 1
+// (alert) to 7 (debug); the syslog priority is facility*8 + severity
+func SyslogSeverity(level string) int {
+	switch level {
+	case "fatal":
+		return 1
+	case "panic", "dpanic":
+		return 2
+	case "error":
+		return 3
+	case "warn", "warning":
+		return 4
+	case "audit":
+		return 5
+	case "info":
+		return 6
+	}
+	return 7
+}
+
+// SeverityNumber returns the entry's Severity, or the severity of its level
+// for entries built without one
+func (e *LogEntry) SeverityNumber() int {
+	if e.Severity != 0 {
+		return e.Severity
+	}
+	return LevelSeverity(e.Level)
+}
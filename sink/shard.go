@@ -0,0 +1,111 @@
+package sink
+
+import (
+	"sort"
+	"sync"
+)
+
+// bufferShard is a producer-side staging buffer. With Config.Shards > 1,
+// writers append to shards under per-shard locks and the flusher merges them
+// into the main buffer, so concurrent producers rarely contend.
+type bufferShard struct {
+	mu      sync.Mutex
+	entries []*LogEntry
+	bytes   int
+}
+
+// newShards creates n shards
+func newShards(n int) []*bufferShard {
+	shards := make([]*bufferShard, n)
+	for i := range shards {
+		shards[i] = &bufferShard{}
+	}
+	return shards
+}
+
+// writeSharded adds an entry to the next shard. Shards and the main buffer
+// share BufferSize and MaxBufferBytes; when they are exhausted the entry goes
+// through the main buffer, which evicts by priority (DropOnFull) or flushes
+// synchronously.
+func (bs *BufferedSink) writeSharded(entry *LogEntry) error {
+	n := len(bs.shards)
+	shard := bs.shards[int(bs.nextShard.Add(1)%uint64(n))]
+	size := approxEntrySize(entry)
+
+	shard.mu.Lock()
+	if bs.draining.Load() {
+		// Checked under the shard lock so Drain's final collection sees the
+		// entry or the writer sees the flag
+		shard.mu.Unlock()
+		ReleaseEntry(entry)
+		return ErrDraining
+	}
+	if !bs.reserve(size) {
+		shard.mu.Unlock()
+		bs.bufferMu.Lock()
+		defer bs.bufferMu.Unlock()
+		return bs.writeLocked(entry)
+	}
+	shard.entries = append(shard.entries, entry)
+	shard.bytes += size
+	wake := len(shard.entries) >= bs.batchSizeHint()/n || levelPriority(entry.Level) == priorityHigh
+	shard.mu.Unlock()
+
+	bs.recentCount.Add(1)
+	if wake {
+		bs.wakeFlusher()
+	}
+	return nil
+}
+
+// reserve counts an entry of size bytes as staged if the shards and the main
+// buffer together stay within BufferSize and MaxBufferBytes. Like isFull, an
+// empty sink always accepts one entry.
+func (bs *BufferedSink) reserve(size int) bool {
+	held := bs.buffered.Load() + bs.staged.Add(1)
+	heldBytes := bs.bufferedMem.Load() + bs.stagedBytes.Add(int64(size))
+	if held > 1 && (held > int64(bs.config.BufferSize) ||
+		(bs.config.MaxBufferBytes > 0 && heldBytes > int64(bs.config.MaxBufferBytes))) {
+		bs.staged.Add(-1)
+		bs.stagedBytes.Add(-int64(size))
+		return false
+	}
+	return true
+}
+
+// collectShards moves all shard entries into the main buffer in timestamp
+// order (must be called with bufferMu held)
+func (bs *BufferedSink) collectShards() {
+	if len(bs.shards) == 0 {
+		return
+	}
+
+	moved, movedBytes := 0, 0
+	for _, shard := range bs.shards {
+		shard.mu.Lock()
+		if len(shard.entries) > 0 {
+			bs.buffer = append(bs.buffer, shard.entries...)
+			bs.bufferBytes += shard.bytes
+			moved += len(shard.entries)
+			movedBytes += shard.bytes
+			clear(shard.entries)
+			shard.entries = shard.entries[:0]
+			shard.bytes = 0
+		}
+		shard.mu.Unlock()
+	}
+	if moved == 0 {
+		return
+	}
+
+	// Publish the main buffer before releasing the reservations, so reserve
+	// never sees the entries in neither
+	bs.storeBuffered()
+	bs.staged.Add(-int64(moved))
+	bs.stagedBytes.Add(-int64(movedBytes))
+
+	// Shards interleave producers, so restore chronological order
+	sort.SliceStable(bs.buffer, func(i, j int) bool {
+		return bs.buffer[i].Timestamp.Before(bs.buffer[j].Timestamp)
+	})
+}
@@ -0,0 +1,241 @@
+package sink
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SIEMConfig configures the CEF and LEEF encoders
+type SIEMConfig struct {
+	Vendor  string // Device vendor (default: "go-zlog")
+	Product string // Device product (default: the entry's service name, else "go-zlog")
+	Version string // Device version (default: the entry's version, else "1.0")
+
+	// EventIDKey is the field holding the event class ID (CEF signature ID,
+	// LEEF event ID); entries without it use their level
+	EventIDKey string
+
+	// FieldMap renames field keys to CEF or LEEF keys, e.g.
+	// {"user": "suser", "client_ip": "src"} for CEF or
+	// {"user": "usrName", "client_ip": "src"} for LEEF
+	FieldMap map[string]string
+
+	// DropUnmapped drops fields missing from FieldMap instead of writing
+	// them under their own (sanitized) key
+	DropUnmapped bool
+}
+
+// defaults returns the vendor, product and version for entry
+func (c *SIEMConfig) defaults(entry *LogEntry) (vendor, product, version string) {
+	vendor, product, version = c.Vendor, c.Product, c.Version
+	if vendor == "" {
+		vendor = "go-zlog"
+	}
+	if product == "" {
+		product = cmp.Or(entry.ServiceName, "go-zlog")
+	}
+	if version == "" {
+		version = cmp.Or(entry.Version, "1.0")
+	}
+	return vendor, product, version
+}
+
+// eventID returns the event class ID of entry
+func (c *SIEMConfig) eventID(entry *LogEntry) string {
+	if c.EventIDKey != "" {
+		if v, ok := entry.Fields[c.EventIDKey]; ok {
+			return siemValue(v)
+		}
+	}
+	return entry.Level
+}
+
+// extensions returns the entry fields under their mapped keys, in key order
+func (c *SIEMConfig) extensions(entry *LogEntry) [][2]string {
+	var ext [][2]string
+	for _, k := range slices.Sorted(maps.Keys(entry.Fields)) {
+		if k == c.EventIDKey {
+			continue
+		}
+		key, ok := c.FieldMap[k]
+		if !ok {
+			if c.DropUnmapped {
+				continue
+			}
+			key = siemKey(k)
+		}
+		ext = append(ext, [2]string{key, siemValue(entry.Fields[k])})
+	}
+	return ext
+}
+
+// siemSeverity maps levels to the 0-10 CEF and LEEF severity scale
+func siemSeverity(level string) int {
+	switch level {
+	case "trace", "debug":
+		return 1
+	case "info":
+		return 3
+	case "audit":
+		return 5
+	case "warn":
+		return 6
+	case "error":
+		return 8
+	case "dpanic", "panic", "fatal":
+		return 10
+	}
+	return 0
+}
+
+// CEFEncoder encodes entries as ArcSight Common Event Format lines:
+//
+//	CEF:0|vendor|product|version|event ID|message|severity|rt=... dvchost=... key=value
+type CEFEncoder struct {
+	config SIEMConfig
+}
+
+// NewCEFEncoder creates a CEF encoder
+func NewCEFEncoder(cfg SIEMConfig) *CEFEncoder {
+	return &CEFEncoder{config: cfg}
+}
+
+// Encode encodes entry as a CEF line
+func (e *CEFEncoder) Encode(entry *LogEntry) ([]byte, error) {
+	vendor, product, version := e.config.defaults(entry)
+
+	var b strings.Builder
+	b.WriteString("CEF:0")
+	for _, h := range []string{vendor, product, version, e.config.eventID(entry), entry.Message, strconv.Itoa(siemSeverity(entry.Level))} {
+		b.WriteByte('|')
+		b.WriteString(cefHeaderEscaper.Replace(h))
+	}
+	b.WriteByte('|')
+
+	ext := [][2]string{{"rt", strconv.FormatInt(entry.Timestamp.UnixMilli(), 10)}}
+	if entry.Hostname != "" {
+		ext = append(ext, [2]string{"dvchost", entry.Hostname})
+	}
+	ext = append(ext, e.config.extensions(entry)...)
+	for i, kv := range ext {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(kv[0])
+		b.WriteByte('=')
+		b.WriteString(cefValueEscaper.Replace(kv[1]))
+	}
+	return []byte(b.String()), nil
+}
+
+// ContentType returns text/plain
+func (e *CEFEncoder) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// CEF escaping of header fields and extension values
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefValueEscaper  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// LEEFEncoder encodes entries as IBM QRadar LEEF 2.0 lines with
+// tab-separated attributes:
+//
+//	LEEF:2.0|vendor|product|version|event ID|x09|devTime=...	sev=...	key=value
+type LEEFEncoder struct {
+	config SIEMConfig
+}
+
+// NewLEEFEncoder creates a LEEF encoder
+func NewLEEFEncoder(cfg SIEMConfig) *LEEFEncoder {
+	return &LEEFEncoder{config: cfg}
+}
+
+// leefTimeFormat is the devTimeFormat of LEEF lines
+const leefTimeFormat = "yyyy-MM-dd'T'HH:mm:ss.SSSZ"
+
+// Encode encodes entry as a LEEF line
+func (e *LEEFEncoder) Encode(entry *LogEntry) ([]byte, error) {
+	vendor, product, version := e.config.defaults(entry)
+
+	var b strings.Builder
+	b.WriteString("LEEF:2.0")
+	for _, h := range []string{vendor, product, version, e.config.eventID(entry)} {
+		b.WriteByte('|')
+		b.WriteString(cefHeaderEscaper.Replace(h))
+	}
+	b.WriteString("|x09|") // Tab attribute delimiter
+
+	attrs := [][2]string{
+		{"devTime", entry.Timestamp.Format("2006-01-02T15:04:05.000-0700")},
+		{"devTimeFormat", leefTimeFormat},
+		{"sev", strconv.Itoa(siemSeverity(entry.Level))},
+		{"cat", entry.Level},
+		{"msg", entry.Message},
+	}
+	if entry.Hostname != "" {
+		attrs = append(attrs, [2]string{"identHostName", entry.Hostname})
+	}
+	attrs = append(attrs, e.config.extensions(entry)...)
+	for i, kv := range attrs {
+		if i > 0 {
+			b.WriteByte('\t')
+		}
+		b.WriteString(kv[0])
+		b.WriteByte('=')
+		b.WriteString(leefValueEscaper.Replace(kv[1]))
+	}
+	return []byte(b.String()), nil
+}
+
+// ContentType returns text/plain
+func (e *LEEFEncoder) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// leefValueEscaper removes the tab delimiter and line breaks from values
+var leefValueEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// siemKey strips the characters CEF and LEEF keys cannot hold
+func siemKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' {
+			return r
+		}
+		return -1
+	}, key)
+	return cmp.Or(key, "_")
+}
+
+// siemValue formats a field value as a string
+func siemValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case error:
+		return val.Error()
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return val.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(val)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		if s, ok := sanitizeValue(v).(string); ok {
+			return s
+		}
+		data, _ = json.Marshal(sanitizeValue(v))
+	}
+	return string(data)
+}
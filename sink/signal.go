@@ -0,0 +1,64 @@
+package sink
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// HandleSignals installs a signal handler that drains every buffered sink on
+// SIGTERM or SIGINT, then re-raises the signal so the process exits as it
+// normally would. On platforms that support it, SIGUSR1 flushes all sinks
+// without stopping them. The returned function removes the handler and may
+// be called more than once.
+func HandleSignals(timeout time.Duration) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, append([]os.Signal{os.Interrupt, syscall.SIGTERM}, flushSignals...)...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				if isFlushSignal(sig) {
+					_ = FlushAll(ctx)
+					cancel()
+					continue
+				}
+				_, _ = DrainAll(ctx)
+				cancel()
+
+				// Restore default handling and deliver the signal again
+				signal.Stop(sigs)
+				if p, err := os.FindProcess(os.Getpid()); err == nil {
+					_ = p.Signal(sig)
+				}
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+		})
+	}
+}
+
+// isFlushSignal reports whether sig should trigger a flush rather than a drain
+func isFlushSignal(sig os.Signal) bool {
+	for _, s := range flushSignals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package sink
+
+import "os"
+
+// flushSignals trigger FlushAll without draining
+var flushSignals = []os.Signal{}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package sink
+
+import (
+	"os"
+	"syscall"
+)
+
+// flushSignals trigger FlushAll without draining
+var flushSignals = []os.Signal{syscall.SIGUSR1}
@@ -2,6 +2,7 @@ package sink
 
 import (
 	"context"
+	"strconv"
 	"time"
 )
 
@@ -9,19 +10,126 @@ import (
 type LogEntry struct {
 	Timestamp   time.Time         `json:"timestamp"`
 	Level       string            `json:"level"`
+	Severity    int               `json:"severity,omitempty"` // OpenTelemetry SeverityNumber of Level (see LevelSeverity)
 	Message     string            `json:"message"`
 	Fields      map[string]any    `json:"fields,omitempty"`
 	ServiceName string            `json:"service_name"`
 	InstanceID  string            `json:"instance_id,omitempty"`
 	Environment string            `json:"environment,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Resource    map[string]string `json:"resource,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"` // Index dimensions, e.g. Loki labels; may be shared between entries, so not modified
 	Hostname    string            `json:"hostname,omitempty"`
 	Caller      string            `json:"caller,omitempty"`
+	Function    string            `json:"function,omitempty"`
 	StackTrace  string            `json:"stack_trace,omitempty"`
+	TraceID     string            `json:"trace_id,omitempty"`    // W3C trace ID in hex
+	SpanID      string            `json:"span_id,omitempty"`     // W3C span ID in hex
+	TraceFlags  byte              `json:"trace_flags,omitempty"` // W3C trace flags, e.g. 1 for sampled
+
+	// Deadline is the deadline of the context the entry was logged with, if
+	// any; BufferedSink counts entries sent after it (see Stats.Late)
+	Deadline time.Time `json:"-"`
+
+	// Encoded optionally holds the entry pre-serialized as a single JSON log
+	// line. Sinks implementing PreEncoder send it as-is instead of encoding
+	// Fields again; producers may then leave Fields empty.
+	Encoded []byte `json:"-"`
+
+	pooled bool // Entry was obtained from AcquireEntry
+}
+
+// Well-known field keys for trace correlation
+const (
+	FieldTraceID    = "trace_id"
+	FieldSpanID     = "span_id"
+	FieldTraceFlags = "trace_flags" // W3C trace flags as two hex digits, e.g. "01"
+	FieldEntryID    = "log_id"      // ULID of the entry, for linking from exemplars and span events
+)
+
+// TraceContext returns the trace ID, span ID and trace flags of the entry,
+// falling back to the trace_id, span_id and trace_flags fields of entries
+// built without them, e.g. by other producers or replayed from files
+func (e *LogEntry) TraceContext() (traceID, spanID string, flags byte) {
+	traceID, spanID, flags = e.TraceID, e.SpanID, e.TraceFlags
+	if traceID == "" {
+		if v, ok := e.Fields[FieldTraceID].(string); ok {
+			traceID = v
+		}
+	}
+	if spanID == "" {
+		if v, ok := e.Fields[FieldSpanID].(string); ok {
+			spanID = v
+		}
+	}
+	if flags == 0 {
+		if v, ok := ParseTraceFlags(e.Fields[FieldTraceFlags]); ok {
+			flags = v
+		}
+	}
+	return traceID, spanID, flags
+}
+
+// ParseTraceFlags returns the trace flags in a field value: two hex digits
+// as in traceparent headers, or a number
+func ParseTraceFlags(v any) (byte, bool) {
+	switch v := v.(type) {
+	case string:
+		n, err := strconv.ParseUint(v, 16, 8)
+		return byte(n), err == nil
+	case int64:
+		return byte(v), v >= 0 && v <= 0xff
+	case uint64:
+		return byte(v), v <= 0xff
+	case int:
+		return byte(v), v >= 0 && v <= 0xff
+	case float64:
+		return byte(v), v >= 0 && v <= 0xff && v == float64(byte(v))
+	}
+	return 0, false
+}
+
+// FieldTenant holds the tenant of entries logged through a tenant's logger
+const FieldTenant = "tenant"
+
+// FieldMessageTemplate holds the unrendered template of a templated message,
+// which groups entries better than the rendered message
+const FieldMessageTemplate = "msg_template"
+
+// PreEncoder is implemented by sinks that can consume LogEntry.Encoded in
+// place of Fields, so producers serialize each line exactly once
+type PreEncoder interface {
+	// AcceptsEncoded reports whether the sink uses LogEntry.Encoded when set
+	AcceptsEncoded() bool
+}
+
+// LevelFilter is implemented by sinks that discard entries below a minimum
+// level, so producers can skip building them
+type LevelFilter interface {
+	// MinLevel returns the minimum level written, or "" for all levels
+	MinLevel() string
+}
+
+// Validator is implemented by sinks that can check their settings against
+// the backend, so misconfiguration fails at startup rather than when the
+// first batch is dropped (see Validate)
+type Validator interface {
+	// Validate checks that the backend is reachable and accepts the sink's
+	// settings, such as its URL, tenant and credentials
+	Validate(ctx context.Context) error
+}
+
+// Validate checks s against its backend if it implements Validator
+func Validate(ctx context.Context, s Sink) error {
+	if v, ok := s.(Validator); ok {
+		return v.Validate(ctx)
+	}
+	return nil
 }
 
 // Sink interface for pluggable log destinations
 type Sink interface {
-	// Write sends a single log entry to the sink
+	// Write sends a single log entry to the sink, taking ownership of pooled entries
 	Write(ctx context.Context, entry *LogEntry) error
 
 	// WriteBatch sends multiple log entries in a batch
@@ -39,48 +147,98 @@ type Sink interface {
 
 // Config holds common configuration for all sinks
 type Config struct {
+	// Sink name used in debug, health and metrics output
+	Name string
+
 	// Service metadata
 	ServiceName string
 	InstanceID  string
 	Environment string
 
 	// Buffering configuration
-	BufferSize      int           // Number of logs to buffer before flushing
-	FlushInterval   time.Duration // Time interval to flush buffer
-	MaxBatchSize    int           // Maximum number of logs in a single batch
+	BufferSize     int           // Number of logs to buffer before flushing
+	MaxBufferBytes int           // Approximate memory limit for buffered logs in bytes (0 = unlimited)
+	FlushInterval  time.Duration // Time interval to flush buffer
+	MaxBatchSize   int           // Maximum number of logs in a single batch
+	MaxBatchBytes  int           // Maximum serialized size of a single batch in bytes (0 = unlimited)
+
+	// Adaptive batching configuration
+	AdaptiveBatching bool          // Grow batch size and flush interval under load, shrink when idle
+	MinBatchSize     int           // Lower bound for the adaptive batch size (upper bound is MaxBatchSize)
+	MinFlushInterval time.Duration // Lower bound for the adaptive flush interval
+	MaxFlushInterval time.Duration // Upper bound for the adaptive flush interval
 
 	// Retry configuration
-	MaxRetries      int           // Maximum number of retry attempts
-	RetryInterval   time.Duration // Initial retry interval (exponential backoff)
-	RetryTimeout    time.Duration // Maximum time to retry
+	MaxRetries    int           // Maximum number of retry attempts
+	RetryInterval time.Duration // Initial retry interval (exponential backoff)
+	RetryTimeout  time.Duration // Maximum time to retry
 
 	// Connection configuration
-	ConnTimeout     time.Duration // Connection timeout
-	WriteTimeout    time.Duration // Write operation timeout
+	ConnTimeout  time.Duration // Connection timeout
+	WriteTimeout time.Duration // Write operation timeout
 
 	// Performance tuning
-	WorkerPoolSize  int           // Number of concurrent workers for sending logs
+	WorkerPoolSize int // Number of streams flushed in parallel, each by one worker (see StreamKey)
+	Shards         int // Number of buffer shards for concurrent producers (0 or 1 = single buffer)
 
 	// Behavior configuration
-	DropOnFull      bool          // Drop logs if buffer is full (instead of blocking)
-	AsyncWrite      bool          // Write logs asynchronously
+	DropOnFull bool   // Drop logs if buffer is full (instead of blocking)
+	AsyncWrite bool   // Write logs asynchronously
+	MinLevel   string // Minimum level written, e.g. "info" ("" = all levels)
+	Clock      Clock  // Time source for flushing and retries (nil = SystemClock)
+
+	// Ordering: with OrderedDelivery, BufferedSink sends the entries of each
+	// stream in timestamp order instead of highest priority first, and a
+	// failed batch holds back the rest of its stream
+	OrderedDelivery bool
+	StreamKey       func(entry *LogEntry) string // Stream of an entry (default: the sink's StreamKeyer, else DefaultStreamKey)
+
+	// Batch metadata: BufferedSink passes a BatchInfo to WriteBatch in the
+	// context (see BatchInfoFromContext), which the HTTP and Loki sinks send
+	// as X-Batch-* request headers
+	BatchMetadata bool
+	BatchHeaders  func(info BatchInfo) map[string]string // Optional extra request headers per flush
+
+	// DeadLetter receives the batches BufferedSink gives up on: those failing
+	// with a PermanentError, which are not retried, and with DropOnFull those
+	// failing after all retries. It is written synchronously and not closed
+	// by BufferedSink (nil = drop them).
+	DeadLetter Sink
+
+	// Hooks (called synchronously by BufferedSink; must not block or call back into the sink)
+	OnDrop  func(count int, reason string)     // Called when entries are dropped
+	OnError func(err error, batch []*LogEntry) // Called when a batch fails after all retries or permanently (must not retain batch)
 }
 
+// Drop reasons reported to Config.OnDrop
+const (
+	DropReasonBufferFull = "buffer_full" // New entry discarded because the buffer was full
+	DropReasonEvicted    = "evicted"     // Buffered entry evicted to make room for a higher priority one
+	DropReasonSendFailed = "send_failed" // Entries discarded after the batch failed to send
+	DropReasonRejected   = "rejected"    // Entries of a batch the sink failed permanently
+	DropReasonAbandoned  = "abandoned"   // Entries still buffered when a drain deadline expired
+)
+
 // DefaultConfig returns a config with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
-		ServiceName:     "unknown",
-		Environment:     "development",
-		BufferSize:      1000,
-		FlushInterval:   5 * time.Second,
-		MaxBatchSize:    100,
-		MaxRetries:      3,
-		RetryInterval:   1 * time.Second,
-		RetryTimeout:    30 * time.Second,
-		ConnTimeout:     10 * time.Second,
-		WriteTimeout:    5 * time.Second,
-		WorkerPoolSize:  2,
-		DropOnFull:      false,
-		AsyncWrite:      true,
+		ServiceName:      "unknown",
+		Environment:      "development",
+		BufferSize:       1000,
+		MaxBufferBytes:   64 << 20,
+		FlushInterval:    5 * time.Second,
+		MaxBatchSize:     100,
+		MaxBatchBytes:    1 << 20,
+		MinBatchSize:     10,
+		MinFlushInterval: 1 * time.Second,
+		MaxFlushInterval: 30 * time.Second,
+		MaxRetries:       3,
+		RetryInterval:    1 * time.Second,
+		RetryTimeout:     30 * time.Second,
+		ConnTimeout:      10 * time.Second,
+		WriteTimeout:     5 * time.Second,
+		WorkerPoolSize:   2,
+		DropOnFull:       false,
+		AsyncWrite:       true,
 	}
 }
@@ -0,0 +1,136 @@
+package sinktest
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// ErrInjected is the default error of writes failed by a Flaky sink
+var ErrInjected = errors.New("sinktest: injected failure")
+
+// FailureSpec describes the failures a Flaky sink injects
+type FailureSpec struct {
+	ErrorRate float64       // Fraction of writes failing with Err (0 to 1)
+	Err       error         // Error of failed writes (default: ErrInjected)
+	Latency   time.Duration // Delay before each write
+	Jitter    time.Duration // Random extra delay of up to Jitter
+	Seed      uint64        // Seed making the injected errors and jitter reproducible
+
+	// Health flaps: the sink reports healthy for HealthyFor, then unhealthy
+	// and failing every write for UnhealthyFor, repeatedly (both 0 = no flaps)
+	HealthyFor   time.Duration
+	UnhealthyFor time.Duration
+
+	Clock sink.Clock // Time source for latencies and flaps (nil = SystemClock)
+}
+
+// FlakySink wraps a sink, injecting the failures of a FailureSpec
+type FlakySink struct {
+	sink.Sink
+	spec  FailureSpec
+	clock sink.Clock
+	start time.Time // Start of the first healthy phase
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	writes   int // Write and WriteBatch calls
+	injected int // Calls failed by the wrapper
+}
+
+// Flaky wraps s in a sink injecting the failures described by spec, e.g. to
+// test retries and drop accounting against an unreliable backend
+func Flaky(s sink.Sink, spec FailureSpec) *FlakySink {
+	if spec.Err == nil {
+		spec.Err = ErrInjected
+	}
+	clock := spec.Clock
+	if clock == nil {
+		clock = sink.SystemClock
+	}
+	return &FlakySink{
+		Sink:  s,
+		spec:  spec,
+		clock: clock,
+		start: clock.Now(),
+		rng:   rand.New(rand.NewPCG(spec.Seed, spec.Seed)),
+	}
+}
+
+// Write delays and possibly fails the write before passing it on, releasing
+// entry if it fails
+func (f *FlakySink) Write(ctx context.Context, entry *sink.LogEntry) error {
+	if err := f.inject(ctx); err != nil {
+		sink.ReleaseEntry(entry)
+		return err
+	}
+	return f.Sink.Write(ctx, entry)
+}
+
+// WriteBatch delays and possibly fails the batch before passing it on
+func (f *FlakySink) WriteBatch(ctx context.Context, entries []*sink.LogEntry) error {
+	if err := f.inject(ctx); err != nil {
+		return err
+	}
+	return f.Sink.WriteBatch(ctx, entries)
+}
+
+// IsHealthy reports false during unhealthy phases, else the wrapped sink's
+// health
+func (f *FlakySink) IsHealthy() bool {
+	return f.healthy() && f.Sink.IsHealthy()
+}
+
+// Writes returns the number of Write and WriteBatch calls
+func (f *FlakySink) Writes() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes
+}
+
+// Injected returns the number of calls failed by the wrapper
+func (f *FlakySink) Injected() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.injected
+}
+
+// inject waits out the write's latency and returns its injected error, if any
+func (f *FlakySink) inject(ctx context.Context) error {
+	f.mu.Lock()
+	f.writes++
+	delay := f.spec.Latency
+	if f.spec.Jitter > 0 {
+		delay += time.Duration(f.rng.Int64N(int64(f.spec.Jitter)))
+	}
+	fail := f.spec.ErrorRate > 0 && f.rng.Float64() < f.spec.ErrorRate
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-f.clock.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if fail || !f.healthy() {
+		f.mu.Lock()
+		f.injected++
+		f.mu.Unlock()
+		return f.spec.Err
+	}
+	return nil
+}
+
+// healthy reports whether the current flap phase is healthy
+func (f *FlakySink) healthy() bool {
+	period := f.spec.HealthyFor + f.spec.UnhealthyFor
+	if f.spec.UnhealthyFor <= 0 || period <= 0 {
+		return true
+	}
+	return f.clock.Now().Sub(f.start)%period < f.spec.HealthyFor
+}
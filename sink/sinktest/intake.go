@@ -0,0 +1,114 @@
+package sinktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FakeHTTPIntake is an in-process log intake endpoint recording the batches
+// posted to it, e.g. by a sink.HTTPSink pointed at its URL
+type FakeHTTPIntake struct {
+	*fakeServer
+
+	mu     sync.Mutex
+	accept []string // Media types accepted; others get 415 (nil = all)
+}
+
+// NewFakeHTTPIntake starts a fake intake accepting any request
+func NewFakeHTTPIntake() *FakeHTTPIntake {
+	in := &FakeHTTPIntake{}
+	in.fakeServer = newFakeServer(in.check)
+	return in
+}
+
+// SetAccept makes the intake answer requests of other media types with 415
+// and an Accept header listing mediaTypes, exercising content negotiation.
+// No media types accepts everything again.
+func (in *FakeHTTPIntake) SetAccept(mediaTypes ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.accept = mediaTypes
+}
+
+// check accepts requests of an accepted media type
+func (in *FakeHTTPIntake) check(h http.Header, req *Request) int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if len(in.accept) == 0 {
+		return http.StatusOK
+	}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	for _, accepted := range in.accept {
+		if strings.EqualFold(mediaType, accepted) {
+			return http.StatusOK
+		}
+	}
+	h.Set("Accept", strings.Join(in.accept, ", "))
+	return http.StatusUnsupportedMediaType
+}
+
+// Batches returns the bodies of the accepted requests in arrival order
+func (in *FakeHTTPIntake) Batches() [][]byte {
+	var batches [][]byte
+	for _, req := range in.accepted() {
+		batches = append(batches, req.Body)
+	}
+	return batches
+}
+
+// Logs returns the entries of the accepted JSON batches, decoded from
+// {"logs": [...]} bodies, arrays or newline-delimited JSON
+func (in *FakeHTTPIntake) Logs() []map[string]any {
+	var logs []map[string]any
+	for _, req := range in.accepted() {
+		logs = append(logs, decodeLogs(req.Body)...)
+	}
+	return logs
+}
+
+// Lines returns the non-empty lines of the accepted text batches, e.g. of
+// the logfmt, CEF and LEEF encoders
+func (in *FakeHTTPIntake) Lines() []string {
+	var lines []string
+	for _, req := range in.accepted() {
+		if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "text/plain" {
+			continue
+		}
+		for _, line := range strings.Split(string(req.Body), "\n") {
+			if line = strings.TrimRight(line, "\r"); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+// decodeLogs decodes the entries of a JSON batch, returning nil for other
+// payloads
+func decodeLogs(body []byte) []map[string]any {
+	var batch struct {
+		Logs []map[string]any `json:"logs"`
+	}
+	if err := json.Unmarshal(body, &batch); err == nil && batch.Logs != nil {
+		return batch.Logs
+	}
+	var array []map[string]any
+	if err := json.Unmarshal(body, &array); err == nil {
+		return array
+	}
+
+	var logs []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for dec.More() {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			return nil
+		}
+		logs = append(logs, entry)
+	}
+	return logs
+}
@@ -0,0 +1,153 @@
+package sinktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Paths of Loki's push API and readiness probe
+const (
+	lokiPushPath  = "/loki/api/v1/push"
+	lokiReadyPath = "/ready"
+)
+
+// FakeLoki is an in-process Loki push API recording the streams pushed to it.
+// Point sink.LokiSinkConfig.URL at PushURL and Close it when done:
+//
+//	loki := sinktest.NewFakeLoki()
+//	defer loki.Close()
+//	loki.FailNext(2, http.StatusTooManyRequests)
+type FakeLoki struct {
+	*fakeServer
+}
+
+// LokiPush is a push accepted by a FakeLoki
+type LokiPush struct {
+	Tenant  string // X-Scope-OrgID header
+	Header  http.Header
+	Streams []LokiStream
+}
+
+// LokiStream is a decoded Loki stream
+type LokiStream struct {
+	Labels  map[string]string
+	Entries []LokiEntry
+}
+
+// LokiEntry is a decoded Loki log line
+type LokiEntry struct {
+	Timestamp time.Time
+	Line      string
+	Metadata  map[string]string // Structured metadata, if any
+}
+
+// lokiPayload is the JSON body of a push request
+type lokiPayload struct {
+	Streams []struct {
+		Stream map[string]string   `json:"stream"`
+		Values [][]json.RawMessage `json:"values"`
+	} `json:"streams"`
+}
+
+// NewFakeLoki starts a fake Loki accepting JSON pushes on PushURL and
+// reporting ready on /ready
+func NewFakeLoki() *FakeLoki {
+	return &FakeLoki{newFakeServer(func(_ http.Header, req *Request) int {
+		if req.Method == http.MethodGet && req.Path == lokiReadyPath {
+			return http.StatusOK
+		}
+		if req.Method != http.MethodPost || req.Path != lokiPushPath {
+			return http.StatusNotFound
+		}
+		if _, err := decodeLokiPush(req.Body); err != nil {
+			return http.StatusBadRequest
+		}
+		return http.StatusNoContent
+	})}
+}
+
+// PushURL returns the URL of the push API
+func (l *FakeLoki) PushURL() string {
+	return l.URL + lokiPushPath
+}
+
+// Pushes returns the accepted pushes in arrival order; failed pushes are
+// only listed by Requests
+func (l *FakeLoki) Pushes() []LokiPush {
+	var pushes []LokiPush
+	for _, req := range l.accepted() {
+		streams, _ := decodeLokiPush(req.Body)
+		pushes = append(pushes, LokiPush{Tenant: req.Header.Get("X-Scope-OrgID"), Header: req.Header, Streams: streams})
+	}
+	return pushes
+}
+
+// Streams returns the streams of all accepted pushes
+func (l *FakeLoki) Streams() []LokiStream {
+	var streams []LokiStream
+	for _, push := range l.Pushes() {
+		streams = append(streams, push.Streams...)
+	}
+	return streams
+}
+
+// Lines returns the log lines of all accepted pushes
+func (l *FakeLoki) Lines() []string {
+	var lines []string
+	for _, stream := range l.Streams() {
+		for _, e := range stream.Entries {
+			lines = append(lines, e.Line)
+		}
+	}
+	return lines
+}
+
+// decodeLokiPush decodes a JSON push request
+func decodeLokiPush(body []byte) ([]LokiStream, error) {
+	var payload lokiPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	streams := make([]LokiStream, 0, len(payload.Streams))
+	for _, s := range payload.Streams {
+		stream := LokiStream{Labels: s.Stream, Entries: make([]LokiEntry, 0, len(s.Values))}
+		for _, v := range s.Values {
+			entry, err := decodeLokiValue(v)
+			if err != nil {
+				return nil, err
+			}
+			stream.Entries = append(stream.Entries, entry)
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// decodeLokiValue decodes a [timestamp_ns, line, optional metadata] value
+func decodeLokiValue(v []json.RawMessage) (LokiEntry, error) {
+	var entry LokiEntry
+	if len(v) < 2 || len(v) > 3 {
+		return entry, fmt.Errorf("sinktest: loki value has %d elements", len(v))
+	}
+	var ts string
+	if err := json.Unmarshal(v[0], &ts); err != nil {
+		return entry, err
+	}
+	ns, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return entry, err
+	}
+	entry.Timestamp = time.Unix(0, ns)
+	if err := json.Unmarshal(v[1], &entry.Line); err != nil {
+		return entry, err
+	}
+	if len(v) == 3 {
+		if err := json.Unmarshal(v[2], &entry.Metadata); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
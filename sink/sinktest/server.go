@@ -0,0 +1,176 @@
+package sinktest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Request is an HTTP request received by a fake server
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+	Status int // Status code the server answered with (0 if the client gave up first)
+}
+
+// fakeServer is the httptest server shared by FakeLoki and FakeHTTPIntake,
+// recording requests and injecting failures before handing them to accept
+type fakeServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	requests   []*Request
+	status     int           // Status of every request while set
+	failNext   int           // Requests answered with failStatus before succeeding again
+	failStatus int           // Status of the failNext requests
+	hangNext   int           // Requests left unanswered until the client gives up
+	delay      time.Duration // Delay before answering each request
+	done       chan struct{} // Closed by Close to release hanging requests
+	closeOnce  sync.Once
+
+	accept func(h http.Header, req *Request) int // Answers requests that are not failed, setting headers in h
+}
+
+// newFakeServer starts a fake server passing requests that are not failed
+// to accept
+func newFakeServer(accept func(h http.Header, req *Request) int) *fakeServer {
+	s := &fakeServer{accept: accept, done: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// serveHTTP records the request and answers it with the injected failure,
+// if any, or the status returned by accept
+func (s *fakeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	status, hang, delay := s.fault()
+	s.mu.Unlock()
+
+	if hang {
+		select {
+		case <-r.Context().Done():
+		case <-s.done:
+		}
+		return
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+
+	if status == 0 {
+		status = s.accept(w.Header(), req)
+	}
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "1")
+	}
+	s.mu.Lock()
+	req.Status = status
+	s.mu.Unlock()
+	w.WriteHeader(status)
+	if status >= 300 {
+		io.WriteString(w, strconv.Itoa(status)+" "+http.StatusText(status))
+	}
+}
+
+// fault returns the injected failure of the current request (must be called
+// with the lock held)
+func (s *fakeServer) fault() (status int, hang bool, delay time.Duration) {
+	if s.hangNext > 0 {
+		s.hangNext--
+		return 0, true, 0
+	}
+	if s.failNext > 0 {
+		s.failNext--
+		return s.failStatus, false, s.delay
+	}
+	return s.status, false, s.delay
+}
+
+// Close releases hanging requests and shuts the server down
+func (s *fakeServer) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.Server.Close()
+}
+
+// FailNext answers the next n requests with status, e.g. 429 or 500
+func (s *fakeServer) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext, s.failStatus = n, status
+}
+
+// SetStatus answers every request with status until it is set to 0
+func (s *fakeServer) SetStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// HangNext leaves the next n requests unanswered until the client times out
+func (s *fakeServer) HangNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hangNext = n
+}
+
+// SetDelay delays every answer by d, or until the client gives up
+func (s *fakeServer) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Requests returns every received request, failed ones included
+func (s *fakeServer) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Request, len(s.requests))
+	for i, req := range s.requests {
+		c := *req
+		out[i] = &c
+	}
+	return out
+}
+
+// Reset discards the received requests and injected failures
+func (s *fakeServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.status, s.failNext, s.hangNext, s.delay = 0, 0, 0, 0
+}
+
+// accepted returns the write requests answered with a 2xx status
+func (s *fakeServer) accepted() []*Request {
+	var out []*Request
+	for _, req := range s.Requests() {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			// Probes, e.g. of sink.Validate, carry no logs
+			continue
+		}
+		if req.Status >= 200 && req.Status < 300 {
+			out = append(out, req)
+		}
+	}
+	return out
+}
@@ -0,0 +1,267 @@
+// Package sinktest provides a recording sink.Sink with assertion helpers, so
+// applications can unit-test their logging without running a log backend:
+//
+//	rec := sinktest.NewRecorder()
+//	l := logger.NewLogger(logger.WithConsole(false), logger.WithSink(rec))
+//	l.Infow("order placed", "order_id", 42)
+//	rec.AssertLogged(t, "info", "order placed", "order_id", 42)
+package sinktest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// TestingT is the subset of testing.TB used by the assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Recorder is a sink capturing copies of the entries written to it. It can
+// simulate failing, slow and unhealthy backends.
+type Recorder struct {
+	mu       sync.Mutex
+	entries  []*sink.LogEntry
+	err      error         // Returned by every write while set
+	failNext int           // Writes failing with failErr before succeeding again
+	failErr  error         // Error of the failNext writes
+	latency  time.Duration // Delay before each write
+	healthy  bool
+	flushes  int
+	closed   bool
+}
+
+// NewRecorder creates an empty, healthy recorder
+func NewRecorder() *Recorder {
+	return &Recorder{healthy: true}
+}
+
+// Write records a copy of entry, releasing the original
+func (r *Recorder) Write(ctx context.Context, entry *sink.LogEntry) error {
+	defer sink.ReleaseEntry(entry)
+	return r.WriteBatch(ctx, []*sink.LogEntry{entry})
+}
+
+// WriteBatch records copies of entries
+func (r *Recorder) WriteBatch(ctx context.Context, entries []*sink.LogEntry) error {
+	r.mu.Lock()
+	latency := r.latency
+	r.mu.Unlock()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.failure(); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		r.entries = append(r.entries, copyEntry(entry))
+	}
+	return nil
+}
+
+// failure returns the simulated error of the current write (must be called
+// with the lock held)
+func (r *Recorder) failure() error {
+	if r.closed {
+		return sink.Permanent(fmt.Errorf("sinktest: recorder closed"))
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if r.failNext > 0 {
+		r.failNext--
+		return r.failErr
+	}
+	return nil
+}
+
+// copyEntry returns a copy of entry that outlives the pooled original
+func copyEntry(entry *sink.LogEntry) *sink.LogEntry {
+	c := *entry
+	c.Fields = maps.Clone(entry.Fields)
+	c.Encoded = slices.Clone(entry.Encoded)
+	return &c
+}
+
+// Flush counts the flush
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushes++
+	return nil
+}
+
+// Close makes later writes fail
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+// IsHealthy reports the health set with SetHealthy (default: true)
+func (r *Recorder) IsHealthy() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.healthy
+}
+
+// SetError makes every write fail with err until it is set to nil
+func (r *Recorder) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// FailNext makes the next n writes fail with err
+func (r *Recorder) FailNext(n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failNext, r.failErr = n, err
+}
+
+// SetLatency delays every write by d, or until its context is done
+func (r *Recorder) SetLatency(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latency = d
+}
+
+// SetHealthy sets the result of IsHealthy
+func (r *Recorder) SetHealthy(healthy bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.healthy = healthy
+}
+
+// Flushes returns the number of Flush calls
+func (r *Recorder) Flushes() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flushes
+}
+
+// Entries returns the recorded entries in write order
+func (r *Recorder) Entries() []*sink.LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.entries)
+}
+
+// Len returns the number of recorded entries
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// Reset discards the recorded entries
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// FilterByLevel returns the recorded entries at level
+func (r *Recorder) FilterByLevel(level string) []*sink.LogEntry {
+	return Filter(r.Entries(), func(e *sink.LogEntry) bool { return e.Level == level })
+}
+
+// Find returns the recorded entries at level ("" = any) whose message
+// contains msgSubstr and whose fields hold the key-value pairs in fields
+func (r *Recorder) Find(level, msgSubstr string, fields ...any) []*sink.LogEntry {
+	return Filter(r.Entries(), func(e *sink.LogEntry) bool {
+		return (level == "" || e.Level == level) && strings.Contains(e.Message, msgSubstr) && HasFields(e, fields...)
+	})
+}
+
+// AssertLogged reports an error unless an entry matching Find was recorded,
+// returning the first match
+func (r *Recorder) AssertLogged(t TestingT, level, msgSubstr string, fields ...any) *sink.LogEntry {
+	t.Helper()
+	found := r.Find(level, msgSubstr, fields...)
+	if len(found) == 0 {
+		t.Errorf("no %s entry containing %q with fields %v; recorded:\n%s", levelName(level), msgSubstr, fields, r.dump())
+		return nil
+	}
+	return found[0]
+}
+
+// AssertNotLogged reports an error if an entry matching Find was recorded
+func (r *Recorder) AssertNotLogged(t TestingT, level, msgSubstr string, fields ...any) {
+	t.Helper()
+	if found := r.Find(level, msgSubstr, fields...); len(found) > 0 {
+		t.Errorf("unexpected %s entry containing %q with fields %v: %s", levelName(level), msgSubstr, fields, format(found[0]))
+	}
+}
+
+// levelName describes a level filter in assertion messages
+func levelName(level string) string {
+	if level == "" {
+		return "any level"
+	}
+	return level
+}
+
+// dump formats the recorded entries one per line
+func (r *Recorder) dump() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return "  (none)"
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "  " + format(e)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// format formats an entry as "level message fields"
+func format(e *sink.LogEntry) string {
+	return fmt.Sprintf("%s %q %v", e.Level, e.Message, e.Fields)
+}
+
+// Filter returns the entries for which keep returns true
+func Filter(entries []*sink.LogEntry, keep func(*sink.LogEntry) bool) []*sink.LogEntry {
+	var out []*sink.LogEntry
+	for _, e := range entries {
+		if keep(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// HasFields reports whether entry holds the key-value pairs in fields. Values
+// match if they are deeply equal or format the same, so 42 matches the int64
+// recorded for an int field.
+func HasFields(entry *sink.LogEntry, fields ...any) bool {
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		got, ok := entry.Fields[key]
+		if !ok {
+			return false
+		}
+		want := fields[i+1]
+		if !reflect.DeepEqual(got, want) && fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,249 @@
+package sink
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SocketSinkConfig holds socket-specific configuration
+type SocketSinkConfig struct {
+	*Config
+	Network string      // tcp (default), udp or unix
+	Address string      // host:port, or the socket path for unix
+	TLS     *tls.Config // Optional TLS for tcp
+	Encoder Encoder     // Line encoder (default: JSONEncoder), e.g. a CEFEncoder
+
+	// Syslog prefixes each line with an RFC 5424 header, as expected by
+	// syslog daemons and SIEM collectors
+	Syslog   bool
+	Facility int    // Syslog facility (default: 1, user-level)
+	AppName  string // Syslog APP-NAME (default: the entry's service name)
+}
+
+// SocketSink writes entries as lines to a TCP, UDP or Unix socket, such as a
+// syslog daemon or a SIEM collector. Stream sockets carry newline-terminated
+// lines; each UDP datagram carries one entry. A failed write closes the
+// connection, which is dialed again on the next batch.
+type SocketSink struct {
+	config    *SocketSinkConfig
+	mu        sync.Mutex // Serializes writes and reconnects
+	conn      net.Conn
+	isHealthy atomic.Bool
+	lastError atomic.Pointer[error]
+}
+
+// NewSocketSink creates a new socket sink. The connection is dialed on the
+// first write.
+func NewSocketSink(config *SocketSinkConfig) (*SocketSink, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	if config.Config == nil {
+		config.Config = DefaultConfig()
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	switch config.Network {
+	case "":
+		config.Network = "tcp"
+	case "tcp", "udp", "unix":
+	default:
+		return nil, fmt.Errorf("unknown network %q", config.Network)
+	}
+	if config.TLS != nil && config.Network != "tcp" {
+		return nil, fmt.Errorf("TLS requires a tcp network")
+	}
+	if config.Encoder == nil {
+		config.Encoder = JSONEncoder{}
+	}
+	if config.Facility == 0 {
+		config.Facility = 1
+	}
+
+	sink := &SocketSink{config: config}
+	sink.isHealthy.Store(true)
+	return sink, nil
+}
+
+// Write sends a single log entry
+func (s *SocketSink) Write(ctx context.Context, entry *LogEntry) error {
+	defer ReleaseEntry(entry)
+	return s.WriteBatch(ctx, []*LogEntry{entry})
+}
+
+// WriteBatch sends multiple log entries
+func (s *SocketSink) WriteBatch(ctx context.Context, entries []*LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	lines, err := s.encode(entries)
+	if err != nil {
+		// Replace unencodable field values rather than dropping the batch
+		lines, err = s.encode(sanitizeEntries(entries))
+	}
+	if err != nil {
+		err = Permanent(fmt.Errorf("failed to encode logs: %w", err))
+		s.recordError(err)
+		handleError(fmt.Errorf("socket: %w", err))
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			s.recordError(fmt.Errorf("failed to connect: %w", err))
+			return Retryable(err)
+		}
+		s.conn = conn
+	}
+
+	deadline := time.Now().Add(s.config.WriteTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetWriteDeadline(deadline)
+	if err := s.send(lines); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.recordError(fmt.Errorf("failed to send logs: %w", err))
+		return Retryable(err)
+	}
+
+	s.isHealthy.Store(true)
+	return nil
+}
+
+// encode returns the lines of entries, with syslog headers if configured
+func (s *SocketSink) encode(entries []*LogEntry) ([][]byte, error) {
+	lines := make([][]byte, len(entries))
+	for i, entry := range entries {
+		data, err := s.config.Encoder.Encode(entry)
+		if err != nil {
+			return nil, err
+		}
+		if s.config.Syslog {
+			data = append(s.syslogHeader(entry), data...)
+		}
+		lines[i] = data
+	}
+	return lines, nil
+}
+
+// syslogHeader returns the RFC 5424 header of entry:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME - - -
+func (s *SocketSink) syslogHeader(entry *LogEntry) []byte {
+	pri := s.config.Facility*8 + SyslogSeverity(entry.Level)
+	b := []byte("<" + strconv.Itoa(pri) + ">1 ")
+	b = entry.Timestamp.UTC().AppendFormat(b, time.RFC3339Nano)
+	for _, field := range []string{
+		entry.Hostname,
+		cmp.Or(s.config.AppName, entry.ServiceName),
+	} {
+		b = append(b, ' ')
+		b = append(b, syslogHeaderField(field)...)
+	}
+	return append(b, " - - - "...)
+}
+
+// syslogHeaderField returns v as a syslog header field: printable ASCII
+// without spaces, truncated to 48 characters, or "-" if empty
+func syslogHeaderField(v string) string {
+	b := make([]byte, 0, min(len(v), 48))
+	for i := 0; i < len(v) && len(b) < 48; i++ {
+		if c := v[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// send writes lines to the connection (must be called with lock held)
+func (s *SocketSink) send(lines [][]byte) error {
+	if s.config.Network == "udp" {
+		for _, line := range lines {
+			if _, err := s.conn.Write(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+// dial connects to the configured address within ConnTimeout
+func (s *SocketSink) dial(ctx context.Context) (net.Conn, error) {
+	d := net.Dialer{Timeout: s.config.ConnTimeout}
+	if s.config.TLS != nil {
+		td := tls.Dialer{NetDialer: &d, Config: s.config.TLS}
+		return td.DialContext(ctx, s.config.Network, s.config.Address)
+	}
+	return d.DialContext(ctx, s.config.Network, s.config.Address)
+}
+
+// Validate checks that the address accepts connections. UDP sockets only
+// report unreachable hosts on later writes.
+func (s *SocketSink) Validate(ctx context.Context) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("socket: %w", err)
+	}
+	return conn.Close()
+}
+
+// Flush is a no-op, since every batch is written when it is sent
+func (s *SocketSink) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Close closes the connection
+func (s *SocketSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// IsHealthy reports whether the last write succeeded
+func (s *SocketSink) IsHealthy() bool {
+	return s.isHealthy.Load()
+}
+
+// LastError returns the last error, if any
+func (s *SocketSink) LastError() error {
+	if err := s.lastError.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// recordError marks the sink unhealthy with err
+func (s *SocketSink) recordError(err error) {
+	s.isHealthy.Store(false)
+	s.lastError.Store(&err)
+}
@@ -0,0 +1,74 @@
+package sink
+
+import (
+	"sort"
+	"time"
+)
+
+// latencySampleSize is the number of recent batch latencies kept for percentiles
+const latencySampleSize = 256
+
+// Stats holds BufferedSink operating statistics
+type Stats struct {
+	Sent          uint64         // Entries successfully sent
+	Dropped       uint64         // Entries dropped (buffer full, evicted, failed, rejected or abandoned)
+	Buffered      uint64         // Entries currently buffered
+	Retries       uint64         // Retry attempts across all batches
+	FailedBatches uint64         // Batches that failed after all retries or permanently
+	DeadLettered  uint64         // Entries handed to Config.DeadLetter
+	Late          uint64         // Entries sent after their LogEntry.Deadline
+	BytesSent     uint64         // Approximate bytes of entries successfully sent
+	Batches       uint64         // Batches successfully sent
+	FlushTime     time.Duration  // Cumulative send time of successful batches
+	LastFlush     time.Time      // Time of the last successfully sent batch
+	LastError     error          // Last error returned by the underlying sink
+	FlushLatency  LatencySummary // Per-batch send latency over recent batches
+}
+
+// LatencySummary holds latency percentiles over a window of recent samples
+type LatencySummary struct {
+	Count int // Number of samples in the window
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// latencyWindow is a fixed-size ring of recent latency samples (not safe for concurrent use)
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+// observe records a latency sample, overwriting the oldest once full
+func (w *latencyWindow) observe(d time.Duration) {
+	if len(w.samples) < latencySampleSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencySampleSize
+}
+
+// summary computes percentiles over the recorded samples
+func (w *latencyWindow) summary() LatencySummary {
+	if len(w.samples) == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p float64) time.Duration {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+
+	return LatencySummary{
+		Count: len(sorted),
+		P50:   percentile(0.50),
+		P90:   percentile(0.90),
+		P99:   percentile(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
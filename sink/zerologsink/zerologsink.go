@@ -0,0 +1,138 @@
+// Package zerologsink provides an io.Writer that parses zerolog JSON output
+// and forwards each event to a go-zlog sink.Sink:
+//
+//	log := zerolog.New(zerologsink.NewWriter(s))
+package zerologsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// writeTimeout bounds each forwarded write
+const writeTimeout = 5 * time.Second
+
+// Default zerolog field names
+const (
+	levelKey   = "level"
+	timeKey    = "time"
+	messageKey = "message"
+	callerKey  = "caller"
+	stackKey   = "stack"
+)
+
+// Writer converts zerolog JSON events into sink entries
+type Writer struct {
+	sink     sink.Sink
+	hostname string
+}
+
+// NewWriter returns a writer forwarding zerolog events to s
+func NewWriter(s sink.Sink) *Writer {
+	hostname, _ := os.Hostname()
+	return &Writer{sink: s, hostname: hostname}
+}
+
+// Write parses one or more newline-delimited zerolog events and writes them
+// to the sink
+func (w *Writer) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := w.writeEvent(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// writeEvent converts a single JSON event and writes it to the sink
+func (w *Writer) writeEvent(line []byte) error {
+	var event map[string]any
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&event); err != nil {
+		return fmt.Errorf("zerologsink: invalid event: %w", err)
+	}
+
+	entry := sink.AcquireEntry()
+	entry.Timestamp = parseTime(event[timeKey])
+	entry.Level = levelString(event[levelKey])
+	entry.Message, _ = event[messageKey].(string)
+	entry.Caller, _ = event[callerKey].(string)
+	entry.Hostname = w.hostname
+	if stack, ok := event[stackKey]; ok {
+		entry.StackTrace = fmt.Sprint(stack)
+	}
+
+	for k, v := range event {
+		switch k {
+		case levelKey, timeKey, messageKey, callerKey, stackKey:
+			continue
+		}
+		entry.Fields[k] = v
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	return w.sink.Write(ctx, entry)
+}
+
+// parseTime parses RFC 3339 or Unix (seconds, ms, µs or ns) timestamps,
+// falling back to the current time
+func parseTime(v any) time.Time {
+	switch t := v.(type) {
+	case string:
+		if ts, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return ts
+		}
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			switch {
+			case n > 1e17:
+				return time.Unix(0, n)
+			case n > 1e14:
+				return time.UnixMicro(n)
+			case n > 1e11:
+				return time.UnixMilli(n)
+			default:
+				return time.Unix(n, 0)
+			}
+		}
+		if f, err := t.Float64(); err == nil {
+			return time.Unix(0, int64(f*float64(time.Second)))
+		}
+	}
+	return time.Now()
+}
+
+// levelString maps a zerolog level name to the sink level names
+func levelString(v any) string {
+	switch v {
+	case "trace":
+		return "trace"
+	case "debug":
+		return "debug"
+	case "info", "":
+		return "info"
+	case "warn":
+		return "warn"
+	case "error":
+		return "error"
+	case "fatal":
+		return "fatal"
+	case "panic":
+		return "panic"
+	case nil:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
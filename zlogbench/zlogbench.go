@@ -0,0 +1,231 @@
+// Package zlogbench load-tests a logging pipeline: it drives goroutines
+// logging at a target rate and reports throughput, allocations, sink drops
+// and the latency of the logging calls, e.g.
+//
+//	p := zlogbench.DiscardPipeline()
+//	res, err := zlogbench.Run(ctx, p, zlogbench.Config{Goroutines: 8, Rate: 100000, Duration: 10 * time.Second})
+//	fmt.Println(res)
+package zlogbench
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"github.com/hsdfat/go-zlog/sink"
+	"github.com/hsdfat/go-zlog/zlogconfig"
+)
+
+// maxSamples is the number of latency samples kept per goroutine
+const maxSamples = 1 << 16
+
+// Config describes the load
+type Config struct {
+	Goroutines int           // Concurrent producers (default: GOMAXPROCS)
+	Rate       int           // Target entries per second across producers (0 = unlimited)
+	Duration   time.Duration // Length of the run (default: 10s)
+	Fields     int           // Key-value pairs per entry (default: 4)
+	Message    string        // Entry message (default: "benchmark entry")
+	Level      string        // Entry level (default: info)
+}
+
+// Result holds the measurements of a run
+type Result struct {
+	Entries        uint64        `json:"entries"`          // Entries logged
+	Elapsed        time.Duration `json:"elapsed_ns"`       // Wall time of the run
+	Throughput     float64       `json:"throughput"`       // Entries per second
+	AllocsPerEntry float64       `json:"allocs_per_entry"` // Heap allocations per entry, background work included
+	BytesPerEntry  float64       `json:"bytes_per_entry"`  // Heap bytes allocated per entry
+	Sent           uint64        `json:"sent"`             // Entries sent by the pipeline's sinks
+	Dropped        uint64        `json:"dropped"`          // Entries dropped by the pipeline's sinks
+	P50            time.Duration `json:"p50_ns"`           // Median latency of the logging calls
+	P99            time.Duration `json:"p99_ns"`           // 99th percentile latency of the logging calls
+	Max            time.Duration `json:"max_ns"`           // Maximum latency of the logging calls
+}
+
+// String formats the result as a one-line report
+func (r *Result) String() string {
+	return fmt.Sprintf("%d entries in %s (%.0f/s), %.1f allocs/entry, %.0f B/entry, %d sent, %d dropped, latency p50=%s p99=%s max=%s",
+		r.Entries, r.Elapsed.Round(time.Millisecond), r.Throughput, r.AllocsPerEntry, r.BytesPerEntry,
+		r.Sent, r.Dropped, r.P50, r.P99, r.Max)
+}
+
+// Run logs to p's logger as described by cfg until the duration elapses or
+// ctx is done, then flushes the sinks and reports the measurements
+func Run(ctx context.Context, p *zlogconfig.Pipeline, cfg Config) (*Result, error) {
+	cfg.Goroutines = cmp.Or(cfg.Goroutines, runtime.GOMAXPROCS(0))
+	cfg.Duration = cmp.Or(cfg.Duration, 10*time.Second)
+	cfg.Fields = cmp.Or(cfg.Fields, 4)
+	cfg.Message = cmp.Or(cfg.Message, "benchmark entry")
+	logw, err := logFunc(p.Logger, cmp.Or(cfg.Level, "info"))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Goroutines < 0 || cfg.Rate < 0 || cfg.Fields < 0 {
+		return nil, fmt.Errorf("zlogbench: goroutines, rate and fields must not be negative")
+	}
+
+	fields := make([]any, 0, 2*cfg.Fields)
+	for i := range cfg.Fields {
+		fields = append(fields, "key"+strconv.Itoa(i), i)
+	}
+	before := sinkStats(p.Sinks)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var m0, m1 runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m0)
+	start := time.Now()
+
+	producers := make([]*producer, cfg.Goroutines)
+	var wg sync.WaitGroup
+	for i := range producers {
+		producers[i] = &producer{rng: rand.New(rand.NewPCG(uint64(i), 0))}
+		if cfg.Rate > 0 {
+			producers[i].interval = time.Duration(float64(time.Second) * float64(cfg.Goroutines) / float64(cfg.Rate))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			producers[i].run(ctx, logw, cfg.Message, fields)
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&m1)
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFlush()
+	for _, s := range p.Sinks {
+		if err := s.Flush(flushCtx); err != nil {
+			return nil, fmt.Errorf("zlogbench: flush %s: %w", s.Name(), err)
+		}
+	}
+	after := sinkStats(p.Sinks)
+
+	res := &Result{Elapsed: elapsed, Sent: after.Sent - before.Sent, Dropped: after.Dropped - before.Dropped}
+	var samples []time.Duration
+	for _, pr := range producers {
+		res.Entries += pr.entries
+		samples = append(samples, pr.samples...)
+	}
+	if res.Entries > 0 {
+		res.Throughput = float64(res.Entries) / elapsed.Seconds()
+		res.AllocsPerEntry = float64(m1.Mallocs-m0.Mallocs) / float64(res.Entries)
+		res.BytesPerEntry = float64(m1.TotalAlloc-m0.TotalAlloc) / float64(res.Entries)
+	}
+	if len(samples) > 0 {
+		slices.Sort(samples)
+		res.P50 = samples[len(samples)/2]
+		res.P99 = samples[int(0.99*float64(len(samples)-1))]
+		res.Max = samples[len(samples)-1]
+	}
+	return res, nil
+}
+
+// producer is a goroutine logging entries at a fixed interval
+type producer struct {
+	interval time.Duration   // Time between entries (0 = unlimited)
+	rng      *rand.Rand      // Reservoir sampling of latencies
+	entries  uint64          // Entries logged
+	samples  []time.Duration // Uniform sample of the logging call latencies
+}
+
+// run logs entries until ctx is done
+func (pr *producer) run(ctx context.Context, logw func(string, ...any), msg string, fields []any) {
+	next := time.Now()
+	for ctx.Err() == nil {
+		if pr.interval > 0 {
+			next = next.Add(pr.interval)
+			// Sleep in steps of at least a millisecond to keep high rates accurate
+			if wait := time.Until(next); wait > time.Millisecond {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		t := time.Now()
+		logw(msg, fields...)
+		pr.observe(time.Since(t))
+	}
+}
+
+// observe records a latency, keeping a uniform sample of maxSamples
+func (pr *producer) observe(d time.Duration) {
+	pr.entries++
+	if len(pr.samples) < maxSamples {
+		pr.samples = append(pr.samples, d)
+		return
+	}
+	if i := pr.rng.Uint64N(pr.entries); i < maxSamples {
+		pr.samples[i] = d
+	}
+}
+
+// sinkStats sums the sent and dropped counts of sinks
+func sinkStats(sinks []*sink.BufferedSink) sink.Stats {
+	var total sink.Stats
+	for _, s := range sinks {
+		st := s.Stats()
+		total.Sent += st.Sent
+		total.Dropped += st.Dropped
+	}
+	return total
+}
+
+// DiscardPipeline returns a pipeline logging JSON to a buffered sink that
+// discards every batch, measuring the logger and buffering overhead alone
+func DiscardPipeline() *zlogconfig.Pipeline {
+	cfg := sink.DefaultConfig()
+	cfg.Name = "discard"
+	cfg.DropOnFull = true
+	bs := sink.NewBufferedSink(discardSink{}, cfg)
+	return &zlogconfig.Pipeline{
+		Logger: logger.NewLogger(logger.WithConsole(false), logger.WithSink(bs)),
+		Sinks:  []*sink.BufferedSink{bs},
+	}
+}
+
+// discardSink is a sink accepting and discarding every entry
+type discardSink struct{}
+
+func (discardSink) Write(ctx context.Context, entry *sink.LogEntry) error {
+	sink.ReleaseEntry(entry)
+	return nil
+}
+func (discardSink) WriteBatch(ctx context.Context, entries []*sink.LogEntry) error { return nil }
+func (discardSink) Flush(ctx context.Context) error                                { return nil }
+func (discardSink) Close() error                                                   { return nil }
+func (discardSink) IsHealthy() bool                                                { return true }
+
+// logFunc returns l's sugared logging method for level
+func logFunc(l *logger.Logger, level string) (func(string, ...any), error) {
+	switch level {
+	case "trace":
+		return l.Tracew, nil
+	case "debug":
+		return l.Debugw, nil
+	case "info":
+		return l.Infow, nil
+	case "warn":
+		return l.Warnw, nil
+	case "error":
+		return l.Errorw, nil
+	case "audit":
+		return l.Auditw, nil
+	}
+	return nil, fmt.Errorf("zlogbench: unsupported level %q (want trace, debug, info, warn, error or audit)", level)
+}
@@ -0,0 +1,634 @@
+package zlogconfig
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+	"github.com/hsdfat/go-zlog/sink"
+)
+
+// OutputFactory creates the sink for an output of a registered type. cfg
+// holds the buffering and service settings; the returned sink is buffered
+// by the pipeline.
+type OutputFactory func(out Output, cfg *sink.Config) (sink.Sink, error)
+
+// outputFactories holds the output types added with RegisterOutput
+var outputFactories sync.Map
+
+// RegisterOutput adds an output type (e.g., "kafka") backed by factory
+func RegisterOutput(typ string, factory OutputFactory) {
+	outputFactories.Store(typ, factory)
+}
+
+// Pipeline is a logger and the sinks created for it
+type Pipeline struct {
+	Logger *logger.Logger
+	Sinks  []*sink.BufferedSink
+
+	redactor *logger.Redactor                 // Updated by Apply
+	wrappers map[*sink.BufferedSink]sink.Sink // Outermost sink wrapping each sink, closed in its place
+	quotas   map[string]*sink.QuotaSink       // Quota sinks by output name
+
+	// NonBlocking holds the queues of remote outputs when non_blocking is
+	// configured, for their Dropped and Queued counts
+	NonBlocking *logger.NonBlocking
+}
+
+// Usage returns the quota usage of the named remote output, if it has quotas
+func (p *Pipeline) Usage(output string) (sink.UsageReport, bool) {
+	q, ok := p.quotas[output]
+	if !ok {
+		return sink.UsageReport{}, false
+	}
+	return q.Usage(), true
+}
+
+// Close drains every sink, waiting at most until ctx is done, then closes them
+func (p *Pipeline) Close(ctx context.Context) error {
+	var errs []error
+	if p.NonBlocking != nil {
+		// Hand the queued entries to the sinks before draining them
+		if err := p.NonBlocking.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, s := range p.Sinks {
+		var closer io.Closer = s
+		if w := p.wrappers[s]; w != nil {
+			// Buffer the pending summaries before draining
+			if err := w.Flush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+			}
+			closer = w
+		}
+		if _, err := s.Drain(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Build creates the logger and sinks described by the configuration
+func (c *Config) Build() (*Pipeline, error) {
+	p := &Pipeline{}
+	opts, err := c.options(p)
+	if err != nil {
+		return nil, err
+	}
+
+	b := logger.NewBuilder().Options(opts...)
+	outputs := c.Outputs
+	if len(outputs) == 0 {
+		outputs = []Output{{Type: "console"}}
+	}
+	for i, out := range outputs {
+		if out.Level != "" {
+			if _, err := logger.ParseLevel(out.Level); err != nil {
+				return nil, p.fail(fmt.Errorf("output %d: %w", i, err))
+			}
+		}
+		if err := c.addOutput(b, p, out); err != nil {
+			return nil, p.fail(fmt.Errorf("output %d (%s): %w", i, out.Type, err))
+		}
+	}
+
+	for _, pattern := range levelPatterns(c.Levels) {
+		if err := logger.SetLevelFor(pattern, c.Levels[pattern]); err != nil {
+			return nil, p.fail(fmt.Errorf("levels: %w", err))
+		}
+	}
+
+	p.Logger = b.Build()
+	return p, nil
+}
+
+// levelPatterns returns the patterns of levels from the least to the most
+// specific, i.e. with the most literal characters, ties broken
+// alphabetically. SetLevelFor applies the last matching pattern, so "db.pool"
+// wins over "db.*" whatever the order of the map.
+func levelPatterns(levels map[string]string) []string {
+	patterns := slices.Collect(maps.Keys(levels))
+	slices.SortFunc(patterns, func(a, b string) int {
+		return cmp.Or(cmp.Compare(literalLen(a), literalLen(b)), strings.Compare(a, b))
+	})
+	return patterns
+}
+
+// literalLen returns the number of characters of a glob pattern other than
+// wildcards
+func literalLen(pattern string) int {
+	return len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+}
+
+// fail closes the sinks created so far and returns err
+func (p *Pipeline) fail(err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	p.Close(ctx)
+	return err
+}
+
+// options converts the logger-wide settings into logger options, recording
+// the redactor in p
+func (c *Config) options(p *Pipeline) ([]logger.Option, error) {
+	level := c.Level
+	if level == "" {
+		level = "info"
+	}
+	if _, err := logger.ParseLevel(level); err != nil {
+		return nil, err
+	}
+	opts := []logger.Option{logger.WithLevel(level)}
+
+	if c.CallerFunction {
+		opts = append(opts, logger.WithCallerFunction())
+	}
+	if c.MessageTemplates {
+		opts = append(opts, logger.WithMessageTemplates())
+	}
+	if e := c.Encoding; e != nil {
+		ec, err := e.encoderConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, logger.WithEncoderConfig(ec))
+	}
+	if c.Development {
+		opts = append(opts, logger.WithDevelopment())
+	}
+	if st := c.Stacktrace; st != nil {
+		if st.Level != "" {
+			if _, err := logger.ParseLevel(st.Level); err != nil {
+				return nil, fmt.Errorf("stacktrace: %w", err)
+			}
+		}
+		opts = append(opts, logger.WithStacktrace(logger.StacktraceConfig{
+			Level:         st.Level,
+			MaxFrames:     st.MaxFrames,
+			SkipRuntime:   st.SkipRuntime,
+			SkipVendor:    st.SkipVendor,
+			SkipFunctions: st.SkipFunctions,
+		}))
+	}
+	switch c.Format {
+	case "":
+	case sink.FormatECS:
+		opts = append(opts, logger.WithECS())
+	case "gcp":
+		opts = append(opts, logger.WithGCP(c.GCPProject))
+	default:
+		return nil, fmt.Errorf("unknown format %q", c.Format)
+	}
+	if r := c.resource(); !r.IsZero() {
+		opts = append(opts, logger.WithResource(r))
+	}
+	if len(c.Fields) > 0 {
+		var fields []any
+		for _, key := range slices.Sorted(maps.Keys(c.Fields)) {
+			fields = append(fields, key, c.Fields[key])
+		}
+		opts = append(opts, logger.WithFields(fields...))
+	}
+	if s := c.Sampling.config(); s != nil {
+		opts = append(opts, logger.WithSampling(s.Tick, s.First, s.Thereafter))
+	}
+
+	// Always install a redactor so that reloads can add rules
+	redact := logger.RedactConfig{}
+	if c.Redaction != nil {
+		var err error
+		if redact, err = c.Redaction.redactConfig(); err != nil {
+			return nil, err
+		}
+	}
+	r, err := logger.NewRedactor(redact)
+	if err != nil {
+		return nil, err
+	}
+	p.redactor = r
+	opts = append(opts, logger.WithRedactor(r))
+	if l := c.Limits; l != nil {
+		opts = append(opts, logger.WithLimits(logger.Limits{
+			MaxMessageBytes: l.MaxMessageBytes,
+			MaxValueBytes:   l.MaxValueBytes,
+			MaxFields:       l.MaxFields,
+			Suffix:          l.Suffix,
+		}))
+	}
+	if ft := c.FieldTypes; ft != nil {
+		types := make(map[string]logger.FieldType, len(ft.Types))
+		for key, name := range ft.Types {
+			t, err := logger.ParseFieldType(name)
+			if err != nil {
+				return nil, fmt.Errorf("field_types: %s: %w", key, err)
+			}
+			types[key] = t
+		}
+		opts = append(opts, logger.WithFieldTypes(logger.FieldTypesConfig{Types: types, Coerce: ft.Coerce}))
+	}
+	if fs := c.FieldShape; fs != nil {
+		mode := logger.ShapeMode(fs.Mode)
+		if mode != logger.ShapeFlatten && mode != logger.ShapeNest {
+			return nil, fmt.Errorf("field_shape: unknown mode %q", fs.Mode)
+		}
+		opts = append(opts, logger.WithFieldShape(logger.ShapeConfig{Mode: mode, Separator: fs.Separator, MaxDepth: fs.MaxDepth}))
+	}
+	if kn := c.KeyNormalization; kn != nil {
+		kc, err := logger.ParseKeyCase(kn.Case)
+		if err != nil {
+			return nil, fmt.Errorf("key_normalization: %w", err)
+		}
+		opts = append(opts, logger.WithKeyNormalization(logger.NormalizeConfig{Case: kc, Dedupe: kn.Dedupe, Keep: kn.Keep}))
+	}
+	if nb := c.NonBlocking; nb != nil {
+		p.NonBlocking = logger.NewNonBlocking(logger.NonBlockingConfig{
+			Size:      nb.QueueSize,
+			Watermark: nb.Watermark,
+			OnWatermark: func(queued, capacity int) {
+				sink.HandleError(fmt.Errorf("sink queue at %d of %d entries", queued, capacity))
+			},
+		})
+		opts = append(opts, logger.WithNonBlocking(p.NonBlocking))
+	}
+	return opts, nil
+}
+
+// resource returns the service metadata as a sink resource
+func (c *Config) resource() sink.Resource {
+	return sink.Resource{
+		ServiceName: c.Service.Name,
+		InstanceID:  c.Service.Instance,
+		Environment: c.Service.Environment,
+		Version:     c.Service.Version,
+		Attributes:  c.Service.Attributes,
+	}
+}
+
+// encoderConfig converts and validates the encoding settings
+func (e *Encoding) encoderConfig() (logger.EncoderConfig, error) {
+	ec := logger.EncoderConfig{
+		TimeKey:       e.TimeKey,
+		LevelKey:      e.LevelKey,
+		MessageKey:    e.MessageKey,
+		NameKey:       e.NameKey,
+		CallerKey:     e.CallerKey,
+		FunctionKey:   e.FunctionKey,
+		StacktraceKey: e.StacktraceKey,
+		TimeFormat:    e.TimeFormat,
+		LevelCase:     e.LevelCase,
+		CallerFormat:  e.CallerFormat,
+		DurationUnit:  e.DurationUnit,
+		FieldKeys:     e.FieldKeys,
+	}
+	if e.TimeZone != "" {
+		loc, err := time.LoadLocation(e.TimeZone)
+		if err != nil {
+			return ec, fmt.Errorf("encoding: %w", err)
+		}
+		ec.TimeZone = loc
+	}
+	if err := ec.Validate(); err != nil {
+		return ec, fmt.Errorf("encoding: %w", err)
+	}
+	return ec, nil
+}
+
+// builtinPatterns maps Redaction.Builtin names to value patterns
+var builtinPatterns = map[string]*regexp.Regexp{
+	"emails":        logger.RedactEmails,
+	"credit_cards":  logger.RedactCreditCards,
+	"bearer_tokens": logger.RedactBearerTokens,
+}
+
+// redactConfig compiles the redaction rules
+func (r *Redaction) redactConfig() (logger.RedactConfig, error) {
+	cfg := logger.RedactConfig{
+		Keys:     r.Keys,
+		Patterns: r.Patterns,
+		HashKey:  []byte(r.HashKey),
+	}
+	switch strings.ToLower(r.Mode) {
+	case "", "mask":
+		cfg.Mode = logger.RedactMask
+	case "remove":
+		cfg.Mode = logger.RedactRemove
+	case "hash":
+		cfg.Mode = logger.RedactHash
+	default:
+		return cfg, fmt.Errorf("redaction: unknown mode %q", r.Mode)
+	}
+	for _, name := range r.Builtin {
+		re, ok := builtinPatterns[name]
+		if !ok {
+			return cfg, fmt.Errorf("redaction: unknown builtin pattern %q", name)
+		}
+		cfg.Values = append(cfg.Values, re)
+	}
+	for _, expr := range r.Values {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return cfg, fmt.Errorf("redaction: %w", err)
+		}
+		cfg.Values = append(cfg.Values, re)
+	}
+	return cfg, nil
+}
+
+// config returns the sampling settings, or nil when s is nil
+func (s *Sampling) config() *logger.SamplingConfig {
+	if s == nil {
+		return nil
+	}
+	tick := time.Duration(s.Tick)
+	if tick == 0 {
+		tick = time.Second
+	}
+	return &logger.SamplingConfig{Tick: tick, First: s.First, Thereafter: s.Thereafter}
+}
+
+// addOutput adds out to the builder, recording any sink it creates in p
+func (c *Config) addOutput(b *logger.Builder, p *Pipeline, out Output) error {
+	switch out.Type {
+	case "console", "":
+		switch out.Encoder {
+		case "", "console":
+			b.Console(out.Level)
+		case "json":
+			b.StderrJSON(out.Level)
+		case "logfmt":
+			b.StderrLogfmt(out.Level)
+		default:
+			return fmt.Errorf("unknown encoder %q", out.Encoder)
+		}
+		return nil
+	case "file":
+		if out.Path == "" {
+			return fmt.Errorf("path is required")
+		}
+		if out.Encoder != "" && out.Encoder != "json" && out.Encoder != "logfmt" {
+			return fmt.Errorf("unknown encoder %q", out.Encoder)
+		}
+		b.File(logger.FileConfig{
+			Filename:   out.Path,
+			MaxSizeMB:  out.MaxSizeMB,
+			MaxBackups: out.MaxBackups,
+			MaxAgeDays: out.MaxAgeDays,
+			Compress:   out.Compress,
+			Encoder:    out.Encoder,
+		}, out.Level)
+		return nil
+	}
+
+	var qcfg sink.QuotaConfig
+	if out.Quota != nil {
+		var err error
+		if qcfg, err = out.Quota.config(); err != nil {
+			return err
+		}
+	}
+
+	cfg := c.sinkConfig(out)
+	s, err := newSink(out, cfg)
+	if err != nil {
+		return err
+	}
+	if c.Validate {
+		// Fail at startup on a wrong URL, tenant or credentials
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnTimeout+cfg.WriteTimeout)
+		err := sink.Validate(ctx, s)
+		cancel()
+		if err != nil {
+			s.Close()
+			return fmt.Errorf("validate: %w", err)
+		}
+	}
+	buffered := sink.NewBufferedSink(s, cfg)
+	p.Sinks = append(p.Sinks, buffered)
+	if out.Aggregate == nil && out.Quota == nil {
+		b.Sink(buffered, out.Level)
+		return nil
+	}
+
+	var wrapped sink.Sink = buffered
+	if out.Aggregate != nil {
+		wrapped = sink.NewAggregatingSink(wrapped, out.Aggregate.config())
+	}
+	if out.Quota != nil {
+		q := sink.NewQuotaSink(wrapped, qcfg)
+		if p.quotas == nil {
+			p.quotas = make(map[string]*sink.QuotaSink)
+		}
+		p.quotas[buffered.Name()] = q
+		wrapped = q
+	}
+	if p.wrappers == nil {
+		p.wrappers = make(map[*sink.BufferedSink]sink.Sink)
+	}
+	p.wrappers[buffered] = wrapped
+	b.Sink(wrapped, out.Level)
+	return nil
+}
+
+// config converts the aggregation settings
+func (a *Aggregate) config() sink.AggregateConfig {
+	cfg := sink.AggregateConfig{Levels: make(map[string]sink.AggregateRule, len(a.Levels)), MaxKeys: a.MaxKeys}
+	for level, l := range a.Levels {
+		cfg.Levels[level] = sink.AggregateRule{Window: time.Duration(l.Window), PassThrough: l.PassThrough}
+	}
+	return cfg
+}
+
+// config converts the quota settings
+func (q *Quota) config() (sink.QuotaConfig, error) {
+	cfg := sink.QuotaConfig{
+		Window:        time.Duration(q.Window),
+		TenantField:   q.TenantField,
+		MaxTenants:    q.MaxTenants,
+		SummaryWindow: time.Duration(q.SummaryWindow),
+	}
+	var err error
+	if cfg.Sink, err = q.Sink.quota(); err != nil {
+		return cfg, fmt.Errorf("quota: %w", err)
+	}
+	if cfg.Tenant, err = q.Tenant.quota(); err != nil {
+		return cfg, fmt.Errorf("quota: tenant: %w", err)
+	}
+	if len(q.Tenants) > 0 {
+		cfg.Tenants = make(map[string]sink.Quota, len(q.Tenants))
+	}
+	for id, l := range q.Tenants {
+		if cfg.Tenants[id], err = l.quota(); err != nil {
+			return cfg, fmt.Errorf("quota: tenant %q: %w", id, err)
+		}
+	}
+	return cfg, nil
+}
+
+// quota converts the limits, validating the action
+func (l QuotaLimit) quota() (sink.Quota, error) {
+	switch action := sink.QuotaAction(l.Action); action {
+	case "", sink.QuotaDrop, sink.QuotaSample, sink.QuotaSummarize:
+		return sink.Quota{MaxEntries: l.MaxEntries, MaxBytes: l.MaxBytes, Action: action, SampleEvery: l.SampleEvery}, nil
+	}
+	return sink.Quota{}, fmt.Errorf("unknown action %q", l.Action)
+}
+
+// newEncoder creates the named http body encoder, applying siem to the CEF
+// and LEEF encoders
+func newEncoder(name string, siem *SIEM) (sink.Encoder, error) {
+	if siem == nil || (name != "cef" && name != "leef") {
+		return sink.NewEncoder(name)
+	}
+	cfg := sink.SIEMConfig{
+		Vendor:       siem.Vendor,
+		Product:      siem.Product,
+		Version:      siem.Version,
+		EventIDKey:   siem.EventIDKey,
+		FieldMap:     siem.FieldMap,
+		DropUnmapped: siem.DropUnmapped,
+	}
+	if name == "cef" {
+		return sink.NewCEFEncoder(cfg), nil
+	}
+	return sink.NewLEEFEncoder(cfg), nil
+}
+
+// newSink creates the unbuffered sink for a remote output
+func newSink(out Output, cfg *sink.Config) (sink.Sink, error) {
+	var auth *sink.BasicAuth
+	if out.Username != "" {
+		auth = &sink.BasicAuth{Username: out.Username, Password: out.Password}
+	}
+	var discovery *sink.Discovery
+	if d := out.Discovery; d != nil {
+		discovery = &sink.Discovery{
+			Resolver: sink.SRVResolver(d.Service, d.Proto),
+			Interval: time.Duration(d.Interval),
+		}
+	}
+
+	switch out.Type {
+	case "loki":
+		return sink.NewLokiSink(&sink.LokiSinkConfig{
+			Config:      cfg,
+			URL:         out.URL,
+			TenantID:    out.TenantID,
+			MultiTenant: out.MultiTenant,
+			Labels:      out.Labels,
+			BearerToken: out.BearerToken,
+			BasicAuth:   auth,
+			Discovery:   discovery,
+			LineFormat:  out.Encoder,
+		})
+	case "http":
+		var encoders []sink.Encoder
+		for _, name := range splitList(out.Encoder) {
+			enc, err := newEncoder(name, out.SIEM)
+			if err != nil {
+				return nil, err
+			}
+			encoders = append(encoders, enc)
+		}
+		hc := &sink.HTTPSinkConfig{
+			Config:      cfg,
+			URL:         out.URL,
+			Headers:     out.Headers,
+			BearerToken: out.BearerToken,
+			BasicAuth:   auth,
+			Discovery:   discovery,
+			Format:      out.Format,
+		}
+		if len(encoders) > 0 {
+			hc.Encoder, hc.FallbackEncoders = encoders[0], encoders[1:]
+		}
+		return sink.NewHTTPSink(hc)
+	case "socket":
+		enc, err := newEncoder(cmp.Or(out.Encoder, "json"), out.SIEM)
+		if err != nil {
+			return nil, err
+		}
+		return sink.NewSocketSink(&sink.SocketSinkConfig{
+			Config:   cfg,
+			Network:  out.Network,
+			Address:  out.Address,
+			Encoder:  enc,
+			Syslog:   out.Syslog,
+			Facility: out.Facility,
+		})
+	}
+
+	factory, ok := outputFactories.Load(out.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown output type %q (register it with RegisterOutput)", out.Type)
+	}
+	return factory.(OutputFactory)(out, cfg)
+}
+
+// sinkConfig returns the sink configuration for a remote output: the
+// defaults, overridden by the top-level then the output's buffer settings
+func (c *Config) sinkConfig(out Output) *sink.Config {
+	cfg := sink.DefaultConfig()
+	cfg.Name = out.Name
+	if c.Service.Name != "" {
+		cfg.ServiceName = c.Service.Name
+	}
+	if c.Service.Environment != "" {
+		cfg.Environment = c.Service.Environment
+	}
+	cfg.InstanceID = c.Service.Instance
+
+	c.Buffer.apply(cfg)
+	if out.Buffer != nil {
+		out.Buffer.apply(cfg)
+	}
+	return cfg
+}
+
+// apply copies the non-zero buffer settings into cfg
+func (b *Buffer) apply(cfg *sink.Config) {
+	setInt := func(dst *int, v int) {
+		if v != 0 {
+			*dst = v
+		}
+	}
+	setDuration := func(dst *time.Duration, v Duration) {
+		if v != 0 {
+			*dst = time.Duration(v)
+		}
+	}
+	setInt(&cfg.BufferSize, b.Size)
+	setInt(&cfg.MaxBufferBytes, b.MaxBytes)
+	setInt(&cfg.MaxBatchSize, b.MaxBatchSize)
+	setInt(&cfg.MaxBatchBytes, b.MaxBatchBytes)
+	setInt(&cfg.MaxRetries, b.MaxRetries)
+	setInt(&cfg.Shards, b.Shards)
+	setInt(&cfg.WorkerPoolSize, b.Workers)
+	setDuration(&cfg.FlushInterval, b.FlushInterval)
+	setDuration(&cfg.RetryInterval, b.RetryInterval)
+	setDuration(&cfg.RetryTimeout, b.RetryTimeout)
+	if b.Adaptive {
+		cfg.AdaptiveBatching = true
+	}
+	if b.DropOnFull != nil {
+		cfg.DropOnFull = *b.DropOnFull
+	}
+	if b.BatchMetadata {
+		cfg.BatchMetadata = true
+	}
+	if b.Ordered {
+		cfg.OrderedDelivery = true
+	}
+}
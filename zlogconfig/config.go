@@ -0,0 +1,322 @@
+// Package zlogconfig builds a complete logger and sink pipeline from a
+// declarative YAML, JSON or TOML configuration, e.g.
+//
+//	level: info
+//	service:
+//	  name: checkout
+//	  environment: production
+//	outputs:
+//	  - type: console
+//	  - type: loki
+//	    url: http://loki:3100/loki/api/v1/push
+//	    level: warn
+//	redaction:
+//	  keys: [password, authorization]
+//	  builtin: [emails]
+//
+// Settings are resolved with increasing precedence from the defaults, the
+// configuration file and the ZLOG_* environment variables (see ApplyEnv).
+package zlogconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a logger and its outputs
+type Config struct {
+	Level            string            `json:"level" yaml:"level" toml:"level"`                                     // Minimum level (default: info)
+	Levels           map[string]string `json:"levels" yaml:"levels" toml:"levels"`                                  // Levels of named loggers by glob pattern, the most specific winning (see logger.SetLevelFor)
+	CallerFunction   bool              `json:"caller_function" yaml:"caller_function" toml:"caller_function"`       // Record the caller's function name
+	MessageTemplates bool              `json:"message_templates" yaml:"message_templates" toml:"message_templates"` // Render {key} placeholders in messages (see logger.WithMessageTemplates)
+	Development      bool              `json:"development" yaml:"development" toml:"development"`                   // Colored console output and stack traces on errors
+	Format           string            `json:"format" yaml:"format" toml:"format"`                                  // Field layout of JSON and file outputs: "", ecs or gcp
+	GCPProject       string            `json:"gcp_project" yaml:"gcp_project" toml:"gcp_project"`                   // Project qualifying trace IDs in the gcp format
+	Encoding         *Encoding         `json:"encoding" yaml:"encoding" toml:"encoding"`                            // Optional entry layout customization
+	Service          Service           `json:"service" yaml:"service" toml:"service"`                               // Service metadata stamped on sink entries
+	Fields           map[string]any    `json:"fields" yaml:"fields" toml:"fields"`                                  // Fields added to every entry
+	Sampling         *Sampling         `json:"sampling" yaml:"sampling" toml:"sampling"`                            // Optional sampling
+	Redaction        *Redaction        `json:"redaction" yaml:"redaction" toml:"redaction"`                         // Optional redaction rules
+	Limits           *Limits           `json:"limits" yaml:"limits" toml:"limits"`                                  // Optional entry size limits
+	FieldTypes       *FieldTypes       `json:"field_types" yaml:"field_types" toml:"field_types"`                   // Optional field type enforcement
+	FieldShape       *FieldShape       `json:"field_shape" yaml:"field_shape" toml:"field_shape"`                   // Optional field flattening or nesting
+	KeyNormalization *KeyNormalization `json:"key_normalization" yaml:"key_normalization" toml:"key_normalization"` // Optional field key naming convention
+	NonBlocking      *NonBlocking      `json:"non_blocking" yaml:"non_blocking" toml:"non_blocking"`                // Optional non-blocking sink writes
+	Stacktrace       *Stacktrace       `json:"stacktrace" yaml:"stacktrace" toml:"stacktrace"`                      // Optional stack trace policy
+	Buffer           Buffer            `json:"buffer" yaml:"buffer" toml:"buffer"`                                  // Buffering defaults for remote outputs
+	Outputs          []Output          `json:"outputs" yaml:"outputs" toml:"outputs"`                               // Outputs (default: console)
+	Validate         bool              `json:"validate" yaml:"validate" toml:"validate"`                            // Check remote outputs against their backends at Build (see sink.Validator)
+}
+
+// Service describes the service emitting logs
+type Service struct {
+	Name        string            `json:"name" yaml:"name" toml:"name"`
+	Instance    string            `json:"instance" yaml:"instance" toml:"instance"`
+	Environment string            `json:"environment" yaml:"environment" toml:"environment"`
+	Version     string            `json:"version" yaml:"version" toml:"version"`
+	Attributes  map[string]string `json:"attributes" yaml:"attributes" toml:"attributes"`
+}
+
+// Sampling configures sampling (see logger.WithSampling)
+type Sampling struct {
+	Tick       Duration `json:"tick" yaml:"tick" toml:"tick"`                   // Sampling window (default: 1s)
+	First      int      `json:"first" yaml:"first" toml:"first"`                // Entries logged per window for each level and message
+	Thereafter int      `json:"thereafter" yaml:"thereafter" toml:"thereafter"` // Then log every thereafter-th entry
+}
+
+// Redaction configures redaction (see logger.RedactConfig)
+type Redaction struct {
+	Keys     []string `json:"keys" yaml:"keys" toml:"keys"`             // Field keys to redact
+	Patterns []string `json:"patterns" yaml:"patterns" toml:"patterns"` // Glob patterns matched against keys
+	Values   []string `json:"values" yaml:"values" toml:"values"`       // Regular expressions redacted inside values
+	Builtin  []string `json:"builtin" yaml:"builtin" toml:"builtin"`    // Built-in value patterns: emails, credit_cards, bearer_tokens
+	Mode     string   `json:"mode" yaml:"mode" toml:"mode"`             // mask (default), remove or hash
+	HashKey  string   `json:"hash_key" yaml:"hash_key" toml:"hash_key"` // HMAC key for hash mode
+}
+
+// Encoding customizes the entry layout (see logger.EncoderConfig)
+type Encoding struct {
+	TimeKey       string            `json:"time_key" yaml:"time_key" toml:"time_key"`
+	LevelKey      string            `json:"level_key" yaml:"level_key" toml:"level_key"`
+	MessageKey    string            `json:"message_key" yaml:"message_key" toml:"message_key"`
+	NameKey       string            `json:"name_key" yaml:"name_key" toml:"name_key"`
+	CallerKey     string            `json:"caller_key" yaml:"caller_key" toml:"caller_key"`
+	FunctionKey   string            `json:"function_key" yaml:"function_key" toml:"function_key"`
+	StacktraceKey string            `json:"stacktrace_key" yaml:"stacktrace_key" toml:"stacktrace_key"`
+	TimeFormat    string            `json:"time_format" yaml:"time_format" toml:"time_format"`       // iso8601, rfc3339, rfc3339nano, epoch, millis, nanos or a Go layout
+	TimeZone      string            `json:"time_zone" yaml:"time_zone" toml:"time_zone"`             // IANA name, e.g. "UTC" or "Europe/Paris"
+	LevelCase     string            `json:"level_case" yaml:"level_case" toml:"level_case"`          // lower, upper or color
+	CallerFormat  string            `json:"caller_format" yaml:"caller_format" toml:"caller_format"` // short or full
+	DurationUnit  string            `json:"duration_unit" yaml:"duration_unit" toml:"duration_unit"` // s, ms, nanos or string
+	FieldKeys     map[string]string `json:"field_keys" yaml:"field_keys" toml:"field_keys"`          // Field key renames
+}
+
+// SIEM configures the CEF and LEEF encoders (see sink.SIEMConfig)
+type SIEM struct {
+	Vendor       string            `json:"vendor" yaml:"vendor" toml:"vendor"`
+	Product      string            `json:"product" yaml:"product" toml:"product"`
+	Version      string            `json:"version" yaml:"version" toml:"version"`
+	EventIDKey   string            `json:"event_id_key" yaml:"event_id_key" toml:"event_id_key"`    // Field holding the event class ID
+	FieldMap     map[string]string `json:"field_map" yaml:"field_map" toml:"field_map"`             // Field key to CEF or LEEF key
+	DropUnmapped bool              `json:"drop_unmapped" yaml:"drop_unmapped" toml:"drop_unmapped"` // Drop fields missing from FieldMap
+}
+
+// Aggregate configures the aggregation of repeated messages of a remote
+// output into summary entries (see sink.AggregatingSink)
+type Aggregate struct {
+	Levels  map[string]AggregateLevel `json:"levels" yaml:"levels" toml:"levels"`       // Rules by level; other levels are not aggregated
+	MaxKeys int                       `json:"max_keys" yaml:"max_keys" toml:"max_keys"` // Messages tracked at once (default: 10000)
+}
+
+// Discovery configures the resolution of a remote output's URL host with DNS
+// SRV records (see sink.Discovery)
+type Discovery struct {
+	Service  string   `json:"service" yaml:"service" toml:"service"`    // SRV service, e.g. "http" (default: look up the host as is)
+	Proto    string   `json:"proto" yaml:"proto" toml:"proto"`          // SRV protocol, e.g. "tcp"
+	Interval Duration `json:"interval" yaml:"interval" toml:"interval"` // Re-resolution interval (default: 30s)
+}
+
+// Quota configures the entry and byte quotas of a remote output, overall and
+// per tenant (see sink.QuotaSink)
+type Quota struct {
+	Window        Duration              `json:"window" yaml:"window" toml:"window"`                         // Rolling window of the quotas (default: 1h)
+	Sink          QuotaLimit            `json:"sink" yaml:"sink" toml:"sink"`                               // Quota of all entries of the output
+	Tenant        QuotaLimit            `json:"tenant" yaml:"tenant" toml:"tenant"`                         // Quota of each tenant not in Tenants
+	Tenants       map[string]QuotaLimit `json:"tenants" yaml:"tenants" toml:"tenants"`                      // Quotas of specific tenants
+	TenantField   string                `json:"tenant_field" yaml:"tenant_field" toml:"tenant_field"`       // Field holding the tenant (default: tenant)
+	MaxTenants    int                   `json:"max_tenants" yaml:"max_tenants" toml:"max_tenants"`          // Tenants tracked at once (default: 10000)
+	SummaryWindow Duration              `json:"summary_window" yaml:"summary_window" toml:"summary_window"` // Period of summaries (default: 60s)
+}
+
+// QuotaLimit limits the entries and bytes of a rolling window
+type QuotaLimit struct {
+	MaxEntries  int64  `json:"max_entries" yaml:"max_entries" toml:"max_entries"`    // 0 = unlimited
+	MaxBytes    int64  `json:"max_bytes" yaml:"max_bytes" toml:"max_bytes"`          // 0 = unlimited
+	Action      string `json:"action" yaml:"action" toml:"action"`                   // drop (default), sample or summarize
+	SampleEvery int    `json:"sample_every" yaml:"sample_every" toml:"sample_every"` // Over-quota entries per entry kept by sample (default: 10)
+}
+
+// AggregateLevel configures the aggregation of one level
+type AggregateLevel struct {
+	Window      Duration `json:"window" yaml:"window" toml:"window"`                   // Period of each summary (default: 60s)
+	PassThrough int      `json:"pass_through" yaml:"pass_through" toml:"pass_through"` // Entries written as-is per window before aggregating
+}
+
+// Stacktrace configures the stack trace policy (see logger.StacktraceConfig)
+type Stacktrace struct {
+	Level         string   `json:"level" yaml:"level" toml:"level"`                            // Lowest level capturing stack traces
+	MaxFrames     int      `json:"max_frames" yaml:"max_frames" toml:"max_frames"`             // Frames kept (0 = all)
+	SkipRuntime   bool     `json:"skip_runtime" yaml:"skip_runtime" toml:"skip_runtime"`       // Drop runtime.* frames
+	SkipVendor    bool     `json:"skip_vendor" yaml:"skip_vendor" toml:"skip_vendor"`          // Drop vendored frames
+	SkipFunctions []string `json:"skip_functions" yaml:"skip_functions" toml:"skip_functions"` // Function prefixes dropped
+}
+
+// Limits configures entry size limits (see logger.Limits)
+type Limits struct {
+	MaxMessageBytes int    `json:"max_message_bytes" yaml:"max_message_bytes" toml:"max_message_bytes"`
+	MaxValueBytes   int    `json:"max_value_bytes" yaml:"max_value_bytes" toml:"max_value_bytes"`
+	MaxFields       int    `json:"max_fields" yaml:"max_fields" toml:"max_fields"`
+	Suffix          string `json:"suffix" yaml:"suffix" toml:"suffix"`
+}
+
+// FieldTypes configures field type enforcement (see logger.WithFieldTypes)
+type FieldTypes struct {
+	Types  map[string]string `json:"types" yaml:"types" toml:"types"`    // Expected type by field key: string, int, float, bool, duration or time
+	Coerce bool              `json:"coerce" yaml:"coerce" toml:"coerce"` // Convert mismatched values when possible
+}
+
+// FieldShape configures field flattening or nesting (see logger.WithFieldShape)
+type FieldShape struct {
+	Mode      string `json:"mode" yaml:"mode" toml:"mode"`                // flatten or nest
+	Separator string `json:"separator" yaml:"separator" toml:"separator"` // Key separator (default: ".")
+	MaxDepth  int    `json:"max_depth" yaml:"max_depth" toml:"max_depth"` // Maximum key segments (default: 10)
+}
+
+// KeyNormalization configures field key normalization (see
+// logger.WithKeyNormalization)
+type KeyNormalization struct {
+	Case   string   `json:"case" yaml:"case" toml:"case"`       // snake, camel or lower ("" = unchanged)
+	Dedupe bool     `json:"dedupe" yaml:"dedupe" toml:"dedupe"` // Keep only the last of keys differing only by case
+	Keep   []string `json:"keep" yaml:"keep" toml:"keep"`       // Keys left unchanged
+}
+
+// NonBlocking configures non-blocking sink writes (see logger.NonBlocking)
+type NonBlocking struct {
+	QueueSize int     `json:"queue_size" yaml:"queue_size" toml:"queue_size"` // Entries queued per remote output (default: 4096)
+	Watermark float64 `json:"watermark" yaml:"watermark" toml:"watermark"`    // Fill ratio reported on stderr (default: 0.8)
+}
+
+// Buffer configures the buffering of a remote output. Zero values keep the
+// defaults of sink.DefaultConfig.
+type Buffer struct {
+	Size          int      `json:"size" yaml:"size" toml:"size"`                                  // Entries buffered before flushing
+	MaxBytes      int      `json:"max_bytes" yaml:"max_bytes" toml:"max_bytes"`                   // Approximate memory limit
+	FlushInterval Duration `json:"flush_interval" yaml:"flush_interval" toml:"flush_interval"`    // Time between flushes
+	MaxBatchSize  int      `json:"max_batch_size" yaml:"max_batch_size" toml:"max_batch_size"`    // Entries per batch
+	MaxBatchBytes int      `json:"max_batch_bytes" yaml:"max_batch_bytes" toml:"max_batch_bytes"` // Serialized bytes per batch
+	MaxRetries    int      `json:"max_retries" yaml:"max_retries" toml:"max_retries"`             // Retry attempts per batch
+	RetryInterval Duration `json:"retry_interval" yaml:"retry_interval" toml:"retry_interval"`    // Initial retry backoff
+	RetryTimeout  Duration `json:"retry_timeout" yaml:"retry_timeout" toml:"retry_timeout"`       // Total retry budget
+	Shards        int      `json:"shards" yaml:"shards" toml:"shards"`                            // Buffer shards
+	Workers       int      `json:"workers" yaml:"workers" toml:"workers"`                         // Streams flushed in parallel
+	Adaptive      bool     `json:"adaptive" yaml:"adaptive" toml:"adaptive"`                      // Adaptive batching
+	DropOnFull    *bool    `json:"drop_on_full" yaml:"drop_on_full" toml:"drop_on_full"`          // Drop new entries when full
+	BatchMetadata bool     `json:"batch_metadata" yaml:"batch_metadata" toml:"batch_metadata"`    // Send X-Batch-* headers with each flush
+	Ordered       bool     `json:"ordered" yaml:"ordered" toml:"ordered"`                         // Deliver each stream in timestamp order
+}
+
+// Output configures one destination
+type Output struct {
+	Type  string `json:"type" yaml:"type" toml:"type"`    // console, file, loki, http, socket or a type added with RegisterOutput
+	Name  string `json:"name" yaml:"name" toml:"name"`    // Sink name in stats and health output
+	Level string `json:"level" yaml:"level" toml:"level"` // Minimum level (default: the logger's level)
+
+	// Encoder is the line format: console (default), json or logfmt for
+	// console outputs, json (default) or logfmt for file and loki outputs.
+	// For http outputs it lists body encoders in order of preference (json,
+	// ecs, logfmt, msgpack, cbor, protobuf), e.g. "msgpack,json"; the later
+	// ones are used if the endpoint rejects the earlier ones, and cef or leef
+	// encode entries for SIEMs as configured by SIEM. Socket outputs take one
+	// of the http encoders (default: json).
+	Encoder string `json:"encoder" yaml:"encoder" toml:"encoder"`
+
+	// File
+	Path       string `json:"path" yaml:"path" toml:"path"`
+	MaxSizeMB  int    `json:"max_size_mb" yaml:"max_size_mb" toml:"max_size_mb"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+	MaxAgeDays int    `json:"max_age_days" yaml:"max_age_days" toml:"max_age_days"`
+	Compress   bool   `json:"compress" yaml:"compress" toml:"compress"`
+
+	// Socket
+	Network  string `json:"network" yaml:"network" toml:"network"`    // tcp (default), udp or unix
+	Address  string `json:"address" yaml:"address" toml:"address"`    // host:port, or the socket path for unix
+	Syslog   bool   `json:"syslog" yaml:"syslog" toml:"syslog"`       // Prefix lines with an RFC 5424 header
+	Facility int    `json:"facility" yaml:"facility" toml:"facility"` // Syslog facility (default: 1, user-level)
+
+	// Remote (loki, http)
+	URL         string            `json:"url" yaml:"url" toml:"url"`
+	TenantID    string            `json:"tenant_id" yaml:"tenant_id" toml:"tenant_id"`
+	MultiTenant bool              `json:"multi_tenant" yaml:"multi_tenant" toml:"multi_tenant"` // Push entries under their tenant field's org ID (loki)
+	Labels      map[string]string `json:"labels" yaml:"labels" toml:"labels"`
+	Headers     map[string]string `json:"headers" yaml:"headers" toml:"headers"`
+	BearerToken string            `json:"bearer_token" yaml:"bearer_token" toml:"bearer_token"`
+	Username    string            `json:"username" yaml:"username" toml:"username"`
+	Password    string            `json:"password" yaml:"password" toml:"password"`
+	Format      string            `json:"format" yaml:"format" toml:"format"`          // Entry layout of http outputs: "" or ecs
+	Buffer      *Buffer           `json:"buffer" yaml:"buffer" toml:"buffer"`          // Overrides the top-level buffer settings
+	SIEM        *SIEM             `json:"siem" yaml:"siem" toml:"siem"`                // Settings of the cef and leef encoders
+	Aggregate   *Aggregate        `json:"aggregate" yaml:"aggregate" toml:"aggregate"` // Optional aggregation of repeated messages
+	Quota       *Quota            `json:"quota" yaml:"quota" toml:"quota"`             // Optional entry and byte quotas
+	Discovery   *Discovery        `json:"discovery" yaml:"discovery" toml:"discovery"` // Optional SRV resolution of the URL host
+
+	// Options holds settings for types added with RegisterOutput
+	Options map[string]any `json:"options" yaml:"options" toml:"options"`
+}
+
+// Duration is a time.Duration written as a string such as "5s" or "250ms"
+type Duration time.Duration
+
+// UnmarshalText parses a duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration as a string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load reads a configuration file, choosing the format from its extension
+// (.yaml, .yml, .json or .toml)
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	cfg, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes a configuration in the given format: "yaml", "yml", "json"
+// or "toml". Unknown keys are rejected.
+func Parse(data []byte, format string) (*Config, error) {
+	cfg := &Config{}
+	var err error
+	switch format {
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	case "toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", format, err)
+	}
+	return cfg, nil
+}
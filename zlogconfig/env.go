@@ -0,0 +1,262 @@
+package zlogconfig
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hsdfat/go-zlog/logger"
+)
+
+// Environment variables read by ApplyEnv. They take precedence over the
+// configuration file, which takes precedence over the defaults.
+const (
+	EnvConfig         = "ZLOG_CONFIG"          // Path of the configuration file loaded by LoadEnv
+	EnvLevel          = "ZLOG_LEVEL"           // Minimum level
+	EnvLevels         = "ZLOG_LEVELS"          // Named logger levels, e.g. "grpc.*=warn,db=debug"
+	EnvConsole        = "ZLOG_CONSOLE"         // "false" removes console outputs, "true" adds one if missing
+	EnvConsoleEncoder = "ZLOG_CONSOLE_ENCODER" // Console encoder: console, json or logfmt
+	EnvDevelopment    = "ZLOG_DEVELOPMENT"     // "true" enables development mode
+	EnvServiceName    = "ZLOG_SERVICE_NAME"    // Service name
+	EnvInstance       = "ZLOG_INSTANCE"        // Service instance
+	EnvEnvironment    = "ZLOG_ENVIRONMENT"     // Deployment environment
+	EnvVersion        = "ZLOG_VERSION"         // Service version
+	EnvLokiURL        = "ZLOG_LOKI_URL"        // Loki push URL; adds a loki output if missing
+	EnvLokiTenant     = "ZLOG_LOKI_TENANT"     // Loki tenant ID
+	EnvLokiLevel      = "ZLOG_LOKI_LEVEL"      // Minimum level of the loki output
+	EnvLokiLabels     = "ZLOG_LOKI_LABELS"     // Static Loki labels, e.g. "team=core,tier=web"
+	EnvHTTPURL        = "ZLOG_HTTP_URL"        // HTTP intake URL; adds an http output if missing
+	EnvHTTPLevel      = "ZLOG_HTTP_LEVEL"      // Minimum level of the http output
+	EnvBearerToken    = "ZLOG_BEARER_TOKEN"    // Bearer token of the loki and http outputs
+	EnvBufferSize     = "ZLOG_BUFFER_SIZE"     // Entries buffered before flushing
+	EnvFlushInterval  = "ZLOG_FLUSH_INTERVAL"  // Time between flushes, e.g. "5s"
+	EnvMaxRetries     = "ZLOG_MAX_RETRIES"     // Retry attempts per batch
+	EnvDropOnFull     = "ZLOG_DROP_ON_FULL"    // Drop new entries when the buffer is full
+	EnvRedactKeys     = "ZLOG_REDACT_KEYS"     // Comma-separated field keys to redact
+)
+
+// LoadEnv loads the file named by ZLOG_CONFIG, if set, then applies the
+// environment variables over it
+func LoadEnv() (*Config, error) {
+	cfg := &Config{}
+	if path := os.Getenv(EnvConfig); path != "" {
+		var err error
+		if cfg, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// SetupDefault builds the pipeline described by LoadEnv and makes its
+// logger the default logger (see logger.ReplaceGlobals)
+func SetupDefault() (*Pipeline, error) {
+	cfg, err := LoadEnv()
+	if err != nil {
+		return nil, err
+	}
+	p, err := cfg.Build()
+	if err != nil {
+		return nil, err
+	}
+	logger.ReplaceGlobals(p.Logger)
+	return p, nil
+}
+
+// ApplyEnv overrides the configuration with the ZLOG_* environment variables
+func (c *Config) ApplyEnv() error {
+	env := func(key string, apply func(string) error) error {
+		v, ok := os.LookupEnv(key)
+		if !ok || v == "" {
+			return nil
+		}
+		if err := apply(v); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		return nil
+	}
+	set := func(dst *string) func(string) error {
+		return func(v string) error {
+			*dst = v
+			return nil
+		}
+	}
+
+	// Outputs default to a console; keep it when env vars add remote outputs
+	if len(c.Outputs) == 0 {
+		c.Outputs = []Output{{Type: "console"}}
+	}
+
+	return firstError(
+		env(EnvLevel, func(v string) error {
+			_, err := logger.ParseLevel(v)
+			c.Level = v
+			return err
+		}),
+		env(EnvLevels, func(v string) error {
+			levels, err := parsePairs(v)
+			if c.Levels == nil {
+				c.Levels = make(map[string]string)
+			}
+			for pattern, level := range levels {
+				c.Levels[pattern] = level
+			}
+			return err
+		}),
+		env(EnvConsole, func(v string) error {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			if !enabled {
+				c.removeOutputs("console")
+			} else {
+				c.output("console")
+			}
+			return nil
+		}),
+		env(EnvConsoleEncoder, func(v string) error {
+			c.output("console").Encoder = v
+			return nil
+		}),
+		env(EnvDevelopment, func(v string) (err error) {
+			c.Development, err = strconv.ParseBool(v)
+			return err
+		}),
+		env(EnvServiceName, set(&c.Service.Name)),
+		env(EnvInstance, set(&c.Service.Instance)),
+		env(EnvEnvironment, set(&c.Service.Environment)),
+		env(EnvVersion, set(&c.Service.Version)),
+		env(EnvLokiURL, func(v string) error {
+			c.output("loki").URL = v
+			return nil
+		}),
+		env(EnvLokiTenant, func(v string) error { return c.setRemote("loki", func(o *Output) { o.TenantID = v }) }),
+		env(EnvLokiLevel, func(v string) error { return c.setRemote("loki", func(o *Output) { o.Level = v }) }),
+		env(EnvLokiLabels, func(v string) error {
+			labels, err := parsePairs(v)
+			if err != nil {
+				return err
+			}
+			return c.setRemote("loki", func(o *Output) {
+				if o.Labels == nil {
+					o.Labels = make(map[string]string)
+				}
+				for k, v := range labels {
+					o.Labels[k] = v
+				}
+			})
+		}),
+		env(EnvHTTPURL, func(v string) error {
+			c.output("http").URL = v
+			return nil
+		}),
+		env(EnvHTTPLevel, func(v string) error { return c.setRemote("http", func(o *Output) { o.Level = v }) }),
+		env(EnvBearerToken, func(v string) error {
+			for i := range c.Outputs {
+				if c.Outputs[i].Type == "loki" || c.Outputs[i].Type == "http" {
+					c.Outputs[i].BearerToken = v
+				}
+			}
+			return nil
+		}),
+		env(EnvBufferSize, func(v string) (err error) {
+			c.Buffer.Size, err = strconv.Atoi(v)
+			return err
+		}),
+		env(EnvFlushInterval, func(v string) error {
+			return c.Buffer.FlushInterval.UnmarshalText([]byte(v))
+		}),
+		env(EnvMaxRetries, func(v string) (err error) {
+			c.Buffer.MaxRetries, err = strconv.Atoi(v)
+			return err
+		}),
+		env(EnvDropOnFull, func(v string) error {
+			drop, err := strconv.ParseBool(v)
+			c.Buffer.DropOnFull = &drop
+			return err
+		}),
+		env(EnvRedactKeys, func(v string) error {
+			if c.Redaction == nil {
+				c.Redaction = &Redaction{}
+			}
+			c.Redaction.Keys = append(c.Redaction.Keys, splitList(v)...)
+			return nil
+		}),
+	)
+}
+
+// output returns the first output of type typ, adding one if there is none
+func (c *Config) output(typ string) *Output {
+	for i := range c.Outputs {
+		if c.Outputs[i].Type == typ || (typ == "console" && c.Outputs[i].Type == "") {
+			return &c.Outputs[i]
+		}
+	}
+	c.Outputs = append(c.Outputs, Output{Type: typ})
+	return &c.Outputs[len(c.Outputs)-1]
+}
+
+// setRemote applies fn to every output of type typ, failing if there is none
+func (c *Config) setRemote(typ string, fn func(*Output)) error {
+	found := false
+	for i := range c.Outputs {
+		if c.Outputs[i].Type == typ {
+			fn(&c.Outputs[i])
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no %s output is configured", typ)
+	}
+	return nil
+}
+
+// removeOutputs removes every output of type typ
+func (c *Config) removeOutputs(typ string) {
+	kept := c.Outputs[:0]
+	for _, out := range c.Outputs {
+		if out.Type != typ && !(typ == "console" && out.Type == "") {
+			kept = append(kept, out)
+		}
+	}
+	c.Outputs = kept
+}
+
+// parsePairs parses "k1=v1,k2=v2"
+func parsePairs(s string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for _, item := range splitList(s) {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pair %q (want key=value)", item)
+		}
+		pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return pairs, nil
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// firstError returns the first non-nil error
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
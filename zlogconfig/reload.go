@@ -0,0 +1,128 @@
+package zlogconfig
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hsdfat/go-zlog/logger"
+)
+
+// Apply changes the runtime settings of the pipeline to those of cfg: the
+// level, named logger levels, redaction rules and sampling. Outputs and
+// sinks are left untouched, so no buffered entries are lost. Nothing is
+// changed if cfg is invalid. The named logger levels are replaced by those
+// of cfg, so patterns removed from it stop applying.
+func (p *Pipeline) Apply(cfg *Config) error {
+	level := cfg.Level
+	if level == "" {
+		level = "info"
+	}
+	if _, err := logger.ParseLevel(level); err != nil {
+		return err
+	}
+	var patterns []logger.PatternLevel
+	for _, pattern := range levelPatterns(cfg.Levels) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("levels: invalid pattern %q: %w", pattern, err)
+		}
+		if _, err := logger.ParseLevel(cfg.Levels[pattern]); err != nil {
+			return fmt.Errorf("levels: %w", err)
+		}
+		patterns = append(patterns, logger.PatternLevel{Pattern: pattern, Level: cfg.Levels[pattern]})
+	}
+	redact := logger.RedactConfig{}
+	if cfg.Redaction != nil {
+		var err error
+		if redact, err = cfg.Redaction.redactConfig(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.redactor.Update(redact); err != nil {
+		return err
+	}
+	p.Logger.SetLevel(level)
+	if err := logger.SetLevelPatterns(patterns); err != nil {
+		return fmt.Errorf("levels: %w", err)
+	}
+	p.Logger.SetSampling(cfg.Sampling.config())
+	return nil
+}
+
+// Reload loads the configuration file at path, applies the environment
+// variables over it and applies the result with Apply
+func (p *Pipeline) Reload(path string) error {
+	cfg, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return err
+	}
+	return p.Apply(cfg)
+}
+
+// Watch reloads the configuration file at path whenever it changes (checked
+// every interval, default 2s) and, on platforms that support it, on SIGHUP.
+// Reload failures are logged and the previous settings are kept. The
+// returned function stops watching; calling it again has no effect.
+func (p *Pipeline) Watch(path string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	sigs := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(sigs, reloadSignals...)
+	}
+
+	reload := func(reason string) {
+		if err := p.Reload(path); err != nil {
+			p.Logger.Errorw("zlog config reload failed", "path", path, "reason", reason, "error", err)
+			return
+		}
+		p.Logger.Infow("zlog config reloaded", "path", path, "reason", reason)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := modTime(path)
+		for {
+			select {
+			case <-sigs:
+				last = modTime(path)
+				reload("signal")
+			case <-ticker.C:
+				if mod := modTime(path); !mod.Equal(last) {
+					last = mod
+					reload("file changed")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+		})
+	}
+}
+
+// modTime returns the modification time of path, or the zero time if it
+// cannot be read
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
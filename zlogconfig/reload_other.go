@@ -0,0 +1,8 @@
+//go:build !unix
+
+package zlogconfig
+
+import "os"
+
+// reloadSignals trigger a configuration reload
+var reloadSignals = []os.Signal{}
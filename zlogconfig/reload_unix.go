@@ -0,0 +1,11 @@
+//go:build unix
+
+package zlogconfig
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals trigger a configuration reload
+var reloadSignals = []os.Signal{syscall.SIGHUP}